@@ -1,23 +1,126 @@
 package DB
 
 import (
+	"XsollaSchoolBE/logging"
 	"XsollaSchoolBE/models"
+	"context"
 	"errors"
+	"fmt"
 	_ "github.com/mattn/go-sqlite3"
+	"strings"
+	"time"
 )
 
 var ProductNotFoundError = errors.New("Product not found")
 var ProductAlreadyExistsError = errors.New("Product already exists")
+var ProductChangedError = errors.New("Product has been changed by another request")
+var ParentProductNotFoundError = errors.New("Parent product not found")
+var NestedVariantError = errors.New("Variant can not be a parent of another product")
+var ReservedSKUError = fmt.Errorf("SKU must not be one of %s", strings.Join(models.ReservedSKUs, ", "))
+var InvalidAttributesError = errors.New("Invalid product attributes")
+var AttributeSchemaNotFoundError = errors.New("Attribute schema not found")
+var InvalidAttributeSchemaError = errors.New("Invalid attribute schema")
+var CategoryNotFoundError = errors.New("Category not found")
+var CategoryAlreadyExistsError = errors.New("Category already exists")
+var ParentCategoryNotFoundError = errors.New("Parent category not found")
+var CategoryCycleError = errors.New("Category can not be moved into itself or its subcategory")
+var TagNotFoundError = errors.New("Product is not tagged with such tag")
+var InvalidTagError = errors.New("Tag must not be empty")
+var TranslationNotFoundError = errors.New("Product has no translation to such locale")
+var InvalidLocaleError = errors.New("Invalid locale")
+var InvalidTranslationError = errors.New("Translated name must not be empty")
+var ImageNotFoundError = errors.New("Product has no image with such id")
+var InvalidImageOrderError = errors.New("Image order must contain ids of all product images once")
+var InvalidStatusError = errors.New("Status must be one of draft, published and archived")
+var StatusTransitionError = errors.New("Product status can not be changed")
+var InvalidScheduleError = errors.New("Product must be unpublished after publishing")
+var APIKeyNotFoundError = errors.New("API key not found")
+var InvalidAPIKeyError = errors.New("API key must have a name and scopes among read, write and admin")
+var IdempotencyKeyReusedError = errors.New("Idempotency key is already used for another request")
+var IdempotentRequestInProgressError = errors.New("Request with the idempotency key is still processed")
+var UnsupportedDriverError = errors.New("Unsupported database driver")
 
+// Open connects to the database of the driver with the data source name and migrates it to the current schema
+func Open(driver string, DSN string) (DB, error) {
+	switch driver {
+	case "sqlite3":
+		db, err := InitSqlite3DB(DSN)
+		if err != nil {
+			return nil, err
+		}
+		return db, nil
+	}
+	return nil, fmt.Errorf("%w: %s", UnsupportedDriverError, driver)
+}
+
+// DB stores catalogs of tenants, its methods operate on data of one tenant.
+// Operations are cancelled when their contexts are done.
 type DB interface {
-	AddProduct(product models.InputProduct) (*models.Product, error)
-	GetAllProducts() ([]*models.Product, error)
-	GetGroupOfProducts(groupSize uint, groupNum uint) ([]*models.Product, error)
-	GetProductBySKU(SKU string) (*models.Product, error)
-	GetProductById(id int64) (*models.Product, error)
-	DeleteProductBySKU(SKU string) error
-	DeleteProductById(id int64) error
-	UpdateProductBySKU(SKU string, inputProd models.InputProduct) (*models.Product, error)
-	UpdateProductById(id int64, inputProd models.InputProduct) (*models.Product, error)
+	// WithTenant returns the database operating on data of the tenant
+	WithTenant(tenant string) DB
+	// WithLogger returns the database writing messages about its operations to the logger
+	WithLogger(logger *logging.Logger) DB
+	AddProduct(ctx context.Context, product models.InputProduct) (*models.Product, error)
+	GetAllProducts(ctx context.Context, filter models.ProductFilter) ([]*models.Product, error)
+	GetGroupOfProducts(ctx context.Context, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.Product, error)
+	GetProductBySKU(ctx context.Context, SKU string) (*models.Product, error)
+	GetProductById(ctx context.Context, id int64) (*models.Product, error)
+	SearchProducts(ctx context.Context, query string, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.SearchResult, error)
+	SuggestProducts(ctx context.Context, prefix string, limit uint, locales []string) ([]*models.Suggestion, error)
+	GetVariants(ctx context.Context, SKU string) ([]*models.Product, error)
+	DeleteProductBySKU(ctx context.Context, SKU string) error
+	DeleteProductById(ctx context.Context, id int64) error
+	// UpdateProductBySKU and UpdateProductById update the product only if it is not changed since it was read as expected,
+	// otherwise they return ProductChangedError. If expected is nil, the product is updated as it is read by them.
+	UpdateProductBySKU(ctx context.Context, SKU string, inputProd models.InputProduct, expected *models.Product) (*models.Product, error)
+	UpdateProductById(ctx context.Context, id int64, inputProd models.InputProduct, expected *models.Product) (*models.Product, error)
+	SetProductStatus(ctx context.Context, SKU string, status string) (*models.Product, error)
+	SetProductSchedule(ctx context.Context, SKU string, schedule models.Schedule) (*models.Product, error)
+	GetScheduledChanges(ctx context.Context) ([]*models.ScheduledChange, error)
+	// ApplyScheduledChanges changes statuses of products of all tenants scheduled before now, it returns the time
+	// of the next scheduled change or zero time if there are no scheduled changes and tenants of changed products
+	ApplyScheduledChanges(ctx context.Context, now time.Time) (time.Time, []string, error)
+	SetAttributeSchema(ctx context.Context, schema models.AttributeSchema) error
+	GetAttributeSchema(ctx context.Context, Type string) (*models.AttributeSchema, error)
+	GetAllAttributeSchemas(ctx context.Context) ([]*models.AttributeSchema, error)
+	DeleteAttributeSchema(ctx context.Context, Type string) error
+	AddCategory(ctx context.Context, category models.InputCategory) (*models.Category, error)
+	GetAllCategories(ctx context.Context) ([]*models.Category, error)
+	GetCategoryById(ctx context.Context, id int64) (*models.Category, error)
+	UpdateCategory(ctx context.Context, id int64, category models.InputCategory) (*models.Category, error)
+	DeleteCategory(ctx context.Context, id int64) error
+	GetProductCategories(ctx context.Context, SKU string) ([]*models.Category, error)
+	AddProductToCategory(ctx context.Context, SKU string, categoryId int64) error
+	RemoveProductFromCategory(ctx context.Context, SKU string, categoryId int64) error
+	GetAllTags(ctx context.Context) ([]*models.TagCount, error)
+	GetProductTags(ctx context.Context, SKU string) ([]string, error)
+	AddProductTag(ctx context.Context, SKU string, tag string) error
+	RemoveProductTag(ctx context.Context, SKU string, tag string) error
+	GetProductTranslations(ctx context.Context, SKU string) ([]*models.Translation, error)
+	SetProductTranslation(ctx context.Context, SKU string, translation models.Translation) error
+	DeleteProductTranslation(ctx context.Context, SKU string, locale string) error
+	LocalizeProducts(ctx context.Context, products []*models.Product, locales []string) error
+	AddAPIKey(ctx context.Context, key models.InputAPIKey) (*models.NewAPIKey, error)
+	// GetAPIKey returns the not revoked API key description of any tenant by the secret key
+	GetAPIKey(ctx context.Context, key string) (*models.APIKey, error)
+	GetAllAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int64) error
+	// StartIdempotentRequest reserves the idempotency key of the client for the request with the hash,
+	// it returns the stored response if the key is already used for the request. The key of the request in progress
+	// reserved before abandonedBefore is reserved again, its request is considered lost, for example with a crashed
+	// process. Keys created before expiredBefore are deleted for all tenants.
+	StartIdempotentRequest(ctx context.Context, client string, key string, requestHash string, abandonedBefore time.Time, expiredBefore time.Time) (*models.IdempotentResponse, error)
+	SaveIdempotentResponse(ctx context.Context, client string, key string, response models.IdempotentResponse) error
+	// DeleteIdempotencyKey releases the key, so the request can be retried
+	DeleteIdempotencyKey(ctx context.Context, client string, key string) error
+	GetProductImages(ctx context.Context, SKU string) ([]*models.Image, error)
+	AddProductImage(ctx context.Context, SKU string, image models.Image) (*models.Image, error)
+	ReorderProductImages(ctx context.Context, SKU string, ids []int64) ([]*models.Image, error)
+	DeleteProductImage(ctx context.Context, SKU string, id int64) (*models.Image, error)
+	// Ping checks the connection to the database
+	Ping(ctx context.Context) error
+	// PendingMigrations returns the number of migrations not applied to the database,
+	// it is negative if the database is migrated by a newer version of the service
+	PendingMigrations(ctx context.Context) (int, error)
 	Close() error
 }
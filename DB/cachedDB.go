@@ -0,0 +1,451 @@
+package DB
+
+import (
+	"XsollaSchoolBE/logging"
+	"XsollaSchoolBE/models"
+	"container/list"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cached operations
+const (
+	productLookup = "GetProductBySKU"
+	pageLookup    = "GetGroupOfProducts"
+)
+
+// CacheStats are counters of the product cache
+type CacheStats struct {
+	// Hits and Misses count lookups by cached operation
+	Hits   map[string]uint64
+	Misses map[string]uint64
+	// Evictions counts entries removed to keep the size limit
+	Evictions uint64
+	// Entries is the number of cached results including not yet removed invalidated ones
+	Entries int
+}
+
+// CacheStatistics is implemented by databases caching results of lookups
+type CacheStatistics interface {
+	CacheStats() CacheStats
+}
+
+// productCache is a LRU cache of products and pages of products shared by databases of all tenants.
+// Changes of products invalidate results of their tenant by increasing its generation, results read before
+// the change are not stored after it. Entries expire after TTL in case the database is changed by another process.
+type productCache struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[productCacheKey]*list.Element
+	// generations are increased on changes of products of tenants
+	generations map[string]uint64
+	hits        map[string]uint64
+	misses      map[string]uint64
+	evictions   uint64
+}
+
+type productCacheKey struct {
+	tenant    string
+	operation string
+	// args identify the result of the operation
+	args string
+}
+
+type productCacheEntry struct {
+	key        productCacheKey
+	products   []*models.Product
+	generation uint64
+	expiresAt  time.Time
+}
+
+func newProductCache(size int, ttl time.Duration) *productCache {
+	return &productCache{
+		size:        size,
+		ttl:         ttl,
+		order:       list.New(),
+		entries:     make(map[productCacheKey]*list.Element),
+		generations: make(map[string]uint64),
+		hits:        make(map[string]uint64),
+		misses:      make(map[string]uint64),
+	}
+}
+
+// current returns the generation of the tenant, it must be called with the locked mutex
+func (cache *productCache) current(tenant string) uint64 {
+	return cache.generations[tenant]
+}
+
+// get returns copies of cached products, on a miss it returns the generation to store the result read after it
+func (cache *productCache) get(key productCacheKey) ([]*models.Product, uint64, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	generation := cache.current(key.tenant)
+	if element, ok := cache.entries[key]; ok {
+		entry := element.Value.(*productCacheEntry)
+		if entry.generation == generation && time.Now().Before(entry.expiresAt) {
+			cache.order.MoveToFront(element)
+			cache.hits[key.operation]++
+			return copyProducts(entry.products), generation, true
+		}
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+	}
+	cache.misses[key.operation]++
+	return nil, generation, false
+}
+
+// put stores copies of products read at the generation, they are not stored if products are changed since then
+func (cache *productCache) put(key productCacheKey, products []*models.Product, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.current(key.tenant) != generation {
+		return
+	}
+	entry := &productCacheEntry{key, copyProducts(products), generation, time.Now().Add(cache.ttl)}
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*productCacheEntry).key)
+		cache.evictions++
+	}
+}
+
+// invalidate drops results of the tenant, invalidated entries are removed on lookups or evicted
+func (cache *productCache) invalidate(tenant string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.generations[tenant]++
+}
+
+func (cache *productCache) stats() CacheStats {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	stats := CacheStats{Hits: make(map[string]uint64), Misses: make(map[string]uint64),
+		Evictions: cache.evictions, Entries: cache.order.Len()}
+	for _, operation := range []string{productLookup, pageLookup} {
+		stats.Hits[operation], stats.Misses[operation] = cache.hits[operation], cache.misses[operation]
+	}
+	return stats
+}
+
+// copyProducts copies products, so callers can modify results, for example by localizing them
+func copyProducts(products []*models.Product) []*models.Product {
+	if products == nil {
+		return nil
+	}
+	copies := make([]*models.Product, len(products))
+	for i, product := range products {
+		prod := *product
+		if product.Attributes != nil {
+			prod.Attributes = make(map[string]string, len(product.Attributes))
+			for name, value := range product.Attributes {
+				prod.Attributes[name] = value
+			}
+		}
+		if product.Images != nil {
+			prod.Images = make([]*models.Image, len(product.Images))
+			for j, image := range product.Images {
+				img := *image
+				prod.Images[j] = &img
+			}
+		}
+		prod.PublishAt, prod.UnpublishAt = copyTime(product.PublishAt), copyTime(product.UnpublishAt)
+		copies[i] = &prod
+	}
+	return copies
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	copied := *t
+	return &copied
+}
+
+// cachedDB serves GetProductBySKU and GetGroupOfProducts from the cache, other operations are passed
+// to the wrapped database and changes of products invalidate cached results of the tenant
+type cachedDB struct {
+	db     DB
+	cache  *productCache
+	tenant string
+}
+
+// Cache wraps any implementation of DB, so products and pages of products are cached for ttl, the cache keeps
+// at most size results of all tenants. Changes made by other processes are visible after ttl.
+// The returned database implements CacheStatistics.
+func Cache(db DB, size int, ttl time.Duration) DB {
+	return &cachedDB{db: db, cache: newProductCache(size, ttl), tenant: models.DefaultTenant}
+}
+
+func (db *cachedDB) CacheStats() CacheStats {
+	return db.cache.stats()
+}
+
+func (db *cachedDB) WithTenant(tenant string) DB {
+	scoped := *db
+	scoped.db, scoped.tenant = db.db.WithTenant(tenant), tenant
+	return &scoped
+}
+
+func (db *cachedDB) WithLogger(logger *logging.Logger) DB {
+	scoped := *db
+	scoped.db = db.db.WithLogger(logger)
+	return &scoped
+}
+
+func (db *cachedDB) GetProductBySKU(ctx context.Context, SKU string) (*models.Product, error) {
+	key := productCacheKey{tenant: db.tenant, operation: productLookup, args: SKU}
+	products, generation, ok := db.cache.get(key)
+	if ok {
+		return products[0], nil
+	}
+	product, err := db.db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	db.cache.put(key, []*models.Product{product}, generation)
+	return product, nil
+}
+
+func (db *cachedDB) GetGroupOfProducts(ctx context.Context, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.Product, error) {
+	filterJSON, err := json.Marshal(filter)
+	if err != nil {
+		// Filters with bounds like NaN are not cached
+		return db.db.GetGroupOfProducts(ctx, groupSize, groupNum, filter)
+	}
+	key := productCacheKey{tenant: db.tenant, operation: pageLookup, args: fmt.Sprintf("%d/%d/%s", groupSize, groupNum, filterJSON)}
+	products, generation, ok := db.cache.get(key)
+	if ok {
+		return products, nil
+	}
+	if products, err = db.db.GetGroupOfProducts(ctx, groupSize, groupNum, filter); err != nil {
+		return nil, err
+	}
+	db.cache.put(key, products, generation)
+	return products, nil
+}
+
+func (db *cachedDB) AddProduct(ctx context.Context, product models.InputProduct) (*models.Product, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.AddProduct(ctx, product)
+}
+
+func (db *cachedDB) GetAllProducts(ctx context.Context, filter models.ProductFilter) ([]*models.Product, error) {
+	return db.db.GetAllProducts(ctx, filter)
+}
+
+func (db *cachedDB) GetProductById(ctx context.Context, id int64) (*models.Product, error) {
+	return db.db.GetProductById(ctx, id)
+}
+
+func (db *cachedDB) SearchProducts(ctx context.Context, query string, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.SearchResult, error) {
+	return db.db.SearchProducts(ctx, query, groupSize, groupNum, filter)
+}
+
+func (db *cachedDB) SuggestProducts(ctx context.Context, prefix string, limit uint, locales []string) ([]*models.Suggestion, error) {
+	return db.db.SuggestProducts(ctx, prefix, limit, locales)
+}
+
+func (db *cachedDB) GetVariants(ctx context.Context, SKU string) ([]*models.Product, error) {
+	return db.db.GetVariants(ctx, SKU)
+}
+
+func (db *cachedDB) DeleteProductBySKU(ctx context.Context, SKU string) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.DeleteProductBySKU(ctx, SKU)
+}
+
+func (db *cachedDB) DeleteProductById(ctx context.Context, id int64) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.DeleteProductById(ctx, id)
+}
+
+func (db *cachedDB) UpdateProductBySKU(ctx context.Context, SKU string, inputProd models.InputProduct, expected *models.Product) (*models.Product, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.UpdateProductBySKU(ctx, SKU, inputProd, expected)
+}
+
+func (db *cachedDB) UpdateProductById(ctx context.Context, id int64, inputProd models.InputProduct, expected *models.Product) (*models.Product, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.UpdateProductById(ctx, id, inputProd, expected)
+}
+
+func (db *cachedDB) SetProductStatus(ctx context.Context, SKU string, status string) (*models.Product, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.SetProductStatus(ctx, SKU, status)
+}
+
+func (db *cachedDB) SetProductSchedule(ctx context.Context, SKU string, schedule models.Schedule) (*models.Product, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.SetProductSchedule(ctx, SKU, schedule)
+}
+
+func (db *cachedDB) GetScheduledChanges(ctx context.Context) ([]*models.ScheduledChange, error) {
+	return db.db.GetScheduledChanges(ctx)
+}
+
+func (db *cachedDB) ApplyScheduledChanges(ctx context.Context, now time.Time) (time.Time, []string, error) {
+	// Scheduled changes are applied to products of all tenants, results of other tenants are kept
+	next, tenants, err := db.db.ApplyScheduledChanges(ctx, now)
+	for _, tenant := range tenants {
+		db.cache.invalidate(tenant)
+	}
+	return next, tenants, err
+}
+
+func (db *cachedDB) SetAttributeSchema(ctx context.Context, schema models.AttributeSchema) error {
+	return db.db.SetAttributeSchema(ctx, schema)
+}
+
+func (db *cachedDB) GetAttributeSchema(ctx context.Context, Type string) (*models.AttributeSchema, error) {
+	return db.db.GetAttributeSchema(ctx, Type)
+}
+
+func (db *cachedDB) GetAllAttributeSchemas(ctx context.Context) ([]*models.AttributeSchema, error) {
+	return db.db.GetAllAttributeSchemas(ctx)
+}
+
+func (db *cachedDB) DeleteAttributeSchema(ctx context.Context, Type string) error {
+	return db.db.DeleteAttributeSchema(ctx, Type)
+}
+
+func (db *cachedDB) AddCategory(ctx context.Context, category models.InputCategory) (*models.Category, error) {
+	return db.db.AddCategory(ctx, category)
+}
+
+func (db *cachedDB) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
+	return db.db.GetAllCategories(ctx)
+}
+
+func (db *cachedDB) GetCategoryById(ctx context.Context, id int64) (*models.Category, error) {
+	return db.db.GetCategoryById(ctx, id)
+}
+
+func (db *cachedDB) UpdateCategory(ctx context.Context, id int64, category models.InputCategory) (*models.Category, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.UpdateCategory(ctx, id, category)
+}
+
+func (db *cachedDB) DeleteCategory(ctx context.Context, id int64) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.DeleteCategory(ctx, id)
+}
+
+func (db *cachedDB) GetProductCategories(ctx context.Context, SKU string) ([]*models.Category, error) {
+	return db.db.GetProductCategories(ctx, SKU)
+}
+
+func (db *cachedDB) AddProductToCategory(ctx context.Context, SKU string, categoryId int64) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.AddProductToCategory(ctx, SKU, categoryId)
+}
+
+func (db *cachedDB) RemoveProductFromCategory(ctx context.Context, SKU string, categoryId int64) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.RemoveProductFromCategory(ctx, SKU, categoryId)
+}
+
+func (db *cachedDB) GetAllTags(ctx context.Context) ([]*models.TagCount, error) {
+	return db.db.GetAllTags(ctx)
+}
+
+func (db *cachedDB) GetProductTags(ctx context.Context, SKU string) ([]string, error) {
+	return db.db.GetProductTags(ctx, SKU)
+}
+
+func (db *cachedDB) AddProductTag(ctx context.Context, SKU string, tag string) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.AddProductTag(ctx, SKU, tag)
+}
+
+func (db *cachedDB) RemoveProductTag(ctx context.Context, SKU string, tag string) error {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.RemoveProductTag(ctx, SKU, tag)
+}
+
+func (db *cachedDB) GetProductTranslations(ctx context.Context, SKU string) ([]*models.Translation, error) {
+	return db.db.GetProductTranslations(ctx, SKU)
+}
+
+func (db *cachedDB) SetProductTranslation(ctx context.Context, SKU string, translation models.Translation) error {
+	return db.db.SetProductTranslation(ctx, SKU, translation)
+}
+
+func (db *cachedDB) DeleteProductTranslation(ctx context.Context, SKU string, locale string) error {
+	return db.db.DeleteProductTranslation(ctx, SKU, locale)
+}
+
+func (db *cachedDB) LocalizeProducts(ctx context.Context, products []*models.Product, locales []string) error {
+	return db.db.LocalizeProducts(ctx, products, locales)
+}
+
+func (db *cachedDB) AddAPIKey(ctx context.Context, key models.InputAPIKey) (*models.NewAPIKey, error) {
+	return db.db.AddAPIKey(ctx, key)
+}
+
+func (db *cachedDB) GetAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	return db.db.GetAPIKey(ctx, key)
+}
+
+func (db *cachedDB) GetAllAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return db.db.GetAllAPIKeys(ctx)
+}
+
+func (db *cachedDB) RevokeAPIKey(ctx context.Context, id int64) error {
+	return db.db.RevokeAPIKey(ctx, id)
+}
+
+func (db *cachedDB) StartIdempotentRequest(ctx context.Context, client string, key string, requestHash string, abandonedBefore time.Time, expiredBefore time.Time) (*models.IdempotentResponse, error) {
+	return db.db.StartIdempotentRequest(ctx, client, key, requestHash, abandonedBefore, expiredBefore)
+}
+
+func (db *cachedDB) SaveIdempotentResponse(ctx context.Context, client string, key string, response models.IdempotentResponse) error {
+	return db.db.SaveIdempotentResponse(ctx, client, key, response)
+}
+
+func (db *cachedDB) DeleteIdempotencyKey(ctx context.Context, client string, key string) error {
+	return db.db.DeleteIdempotencyKey(ctx, client, key)
+}
+
+func (db *cachedDB) GetProductImages(ctx context.Context, SKU string) ([]*models.Image, error) {
+	return db.db.GetProductImages(ctx, SKU)
+}
+
+func (db *cachedDB) AddProductImage(ctx context.Context, SKU string, image models.Image) (*models.Image, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.AddProductImage(ctx, SKU, image)
+}
+
+func (db *cachedDB) ReorderProductImages(ctx context.Context, SKU string, ids []int64) ([]*models.Image, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.ReorderProductImages(ctx, SKU, ids)
+}
+
+func (db *cachedDB) DeleteProductImage(ctx context.Context, SKU string, id int64) (*models.Image, error) {
+	defer db.cache.invalidate(db.tenant)
+	return db.db.DeleteProductImage(ctx, SKU, id)
+}
+
+func (db *cachedDB) Ping(ctx context.Context) error {
+	return db.db.Ping(ctx)
+}
+
+func (db *cachedDB) PendingMigrations(ctx context.Context) (int, error) {
+	return db.db.PendingMigrations(ctx)
+}
+
+func (db *cachedDB) Close() error {
+	return db.db.Close()
+}
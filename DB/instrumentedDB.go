@@ -0,0 +1,318 @@
+package DB
+
+import (
+	"XsollaSchoolBE/logging"
+	"XsollaSchoolBE/models"
+	"XsollaSchoolBE/tracing"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Observer receives the duration and the error of each database operation
+type Observer func(operation string, duration time.Duration, err error)
+
+// PoolStats is implemented by databases with a connection pool
+type PoolStats interface {
+	Stats() sql.DBStats
+}
+
+// instrumentedDB reports durations of operations of the wrapped database to the observer,
+// operations are also written to the logger on debug level and traced as children of spans of their contexts
+type instrumentedDB struct {
+	db      DB
+	observe Observer
+	logger  *logging.Logger
+	tenant  string
+}
+
+// Instrument wraps any implementation of DB, so its operations and operations of databases returned by WithTenant
+// and WithLogger are observed
+func Instrument(db DB, observe Observer) DB {
+	return &instrumentedDB{db: db, observe: observe, tenant: models.DefaultTenant}
+}
+
+func (db *instrumentedDB) measure(ctx context.Context, operation string, start time.Time, err *error) {
+	duration := time.Since(start)
+	db.observe(operation, duration, *err)
+	if parent := tracing.SpanFromContext(ctx); parent != nil {
+		_, span := parent.Tracer().StartAt(ctx, operation, tracing.ClientKind, start)
+		span.SetAttributes("db.operation.name", operation, "tenant", db.tenant)
+		span.SetError(*err)
+		span.End()
+	}
+	if db.logger.Enabled(logging.DebugLevel) {
+		if *err != nil {
+			db.logger.Debug("db operation", "operation", operation, "latency_ms", milliseconds(duration), "error", *err)
+		} else {
+			db.logger.Debug("db operation", "operation", operation, "latency_ms", milliseconds(duration))
+		}
+	}
+}
+
+func (db *instrumentedDB) WithTenant(tenant string) DB {
+	scoped := *db
+	scoped.db, scoped.tenant = db.db.WithTenant(tenant), tenant
+	return &scoped
+}
+
+func (db *instrumentedDB) WithLogger(logger *logging.Logger) DB {
+	scoped := *db
+	scoped.db, scoped.logger = db.db.WithLogger(logger), logger
+	return &scoped
+}
+
+// milliseconds converts the duration to fractional milliseconds
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration.Microseconds()) / 1000
+}
+
+func (db *instrumentedDB) AddProduct(ctx context.Context, product models.InputProduct) (_ *models.Product, err error) {
+	defer db.measure(ctx, "AddProduct", time.Now(), &err)
+	return db.db.AddProduct(ctx, product)
+}
+
+func (db *instrumentedDB) GetAllProducts(ctx context.Context, filter models.ProductFilter) (_ []*models.Product, err error) {
+	defer db.measure(ctx, "GetAllProducts", time.Now(), &err)
+	return db.db.GetAllProducts(ctx, filter)
+}
+
+func (db *instrumentedDB) GetGroupOfProducts(ctx context.Context, groupSize uint, groupNum uint, filter models.ProductFilter) (_ []*models.Product, err error) {
+	defer db.measure(ctx, "GetGroupOfProducts", time.Now(), &err)
+	return db.db.GetGroupOfProducts(ctx, groupSize, groupNum, filter)
+}
+
+func (db *instrumentedDB) GetProductBySKU(ctx context.Context, SKU string) (_ *models.Product, err error) {
+	defer db.measure(ctx, "GetProductBySKU", time.Now(), &err)
+	return db.db.GetProductBySKU(ctx, SKU)
+}
+
+func (db *instrumentedDB) GetProductById(ctx context.Context, id int64) (_ *models.Product, err error) {
+	defer db.measure(ctx, "GetProductById", time.Now(), &err)
+	return db.db.GetProductById(ctx, id)
+}
+
+func (db *instrumentedDB) SearchProducts(ctx context.Context, query string, groupSize uint, groupNum uint, filter models.ProductFilter) (_ []*models.SearchResult, err error) {
+	defer db.measure(ctx, "SearchProducts", time.Now(), &err)
+	return db.db.SearchProducts(ctx, query, groupSize, groupNum, filter)
+}
+
+func (db *instrumentedDB) SuggestProducts(ctx context.Context, prefix string, limit uint, locales []string) (_ []*models.Suggestion, err error) {
+	defer db.measure(ctx, "SuggestProducts", time.Now(), &err)
+	return db.db.SuggestProducts(ctx, prefix, limit, locales)
+}
+
+func (db *instrumentedDB) GetVariants(ctx context.Context, SKU string) (_ []*models.Product, err error) {
+	defer db.measure(ctx, "GetVariants", time.Now(), &err)
+	return db.db.GetVariants(ctx, SKU)
+}
+
+func (db *instrumentedDB) DeleteProductBySKU(ctx context.Context, SKU string) (err error) {
+	defer db.measure(ctx, "DeleteProductBySKU", time.Now(), &err)
+	return db.db.DeleteProductBySKU(ctx, SKU)
+}
+
+func (db *instrumentedDB) DeleteProductById(ctx context.Context, id int64) (err error) {
+	defer db.measure(ctx, "DeleteProductById", time.Now(), &err)
+	return db.db.DeleteProductById(ctx, id)
+}
+
+func (db *instrumentedDB) UpdateProductBySKU(ctx context.Context, SKU string, inputProd models.InputProduct, expected *models.Product) (_ *models.Product, err error) {
+	defer db.measure(ctx, "UpdateProductBySKU", time.Now(), &err)
+	return db.db.UpdateProductBySKU(ctx, SKU, inputProd, expected)
+}
+
+func (db *instrumentedDB) UpdateProductById(ctx context.Context, id int64, inputProd models.InputProduct, expected *models.Product) (_ *models.Product, err error) {
+	defer db.measure(ctx, "UpdateProductById", time.Now(), &err)
+	return db.db.UpdateProductById(ctx, id, inputProd, expected)
+}
+
+func (db *instrumentedDB) SetProductStatus(ctx context.Context, SKU string, status string) (_ *models.Product, err error) {
+	defer db.measure(ctx, "SetProductStatus", time.Now(), &err)
+	return db.db.SetProductStatus(ctx, SKU, status)
+}
+
+func (db *instrumentedDB) SetProductSchedule(ctx context.Context, SKU string, schedule models.Schedule) (_ *models.Product, err error) {
+	defer db.measure(ctx, "SetProductSchedule", time.Now(), &err)
+	return db.db.SetProductSchedule(ctx, SKU, schedule)
+}
+
+func (db *instrumentedDB) GetScheduledChanges(ctx context.Context) (_ []*models.ScheduledChange, err error) {
+	defer db.measure(ctx, "GetScheduledChanges", time.Now(), &err)
+	return db.db.GetScheduledChanges(ctx)
+}
+
+func (db *instrumentedDB) ApplyScheduledChanges(ctx context.Context, now time.Time) (_ time.Time, _ []string, err error) {
+	defer db.measure(ctx, "ApplyScheduledChanges", time.Now(), &err)
+	return db.db.ApplyScheduledChanges(ctx, now)
+}
+
+func (db *instrumentedDB) SetAttributeSchema(ctx context.Context, schema models.AttributeSchema) (err error) {
+	defer db.measure(ctx, "SetAttributeSchema", time.Now(), &err)
+	return db.db.SetAttributeSchema(ctx, schema)
+}
+
+func (db *instrumentedDB) GetAttributeSchema(ctx context.Context, Type string) (_ *models.AttributeSchema, err error) {
+	defer db.measure(ctx, "GetAttributeSchema", time.Now(), &err)
+	return db.db.GetAttributeSchema(ctx, Type)
+}
+
+func (db *instrumentedDB) GetAllAttributeSchemas(ctx context.Context) (_ []*models.AttributeSchema, err error) {
+	defer db.measure(ctx, "GetAllAttributeSchemas", time.Now(), &err)
+	return db.db.GetAllAttributeSchemas(ctx)
+}
+
+func (db *instrumentedDB) DeleteAttributeSchema(ctx context.Context, Type string) (err error) {
+	defer db.measure(ctx, "DeleteAttributeSchema", time.Now(), &err)
+	return db.db.DeleteAttributeSchema(ctx, Type)
+}
+
+func (db *instrumentedDB) AddCategory(ctx context.Context, category models.InputCategory) (_ *models.Category, err error) {
+	defer db.measure(ctx, "AddCategory", time.Now(), &err)
+	return db.db.AddCategory(ctx, category)
+}
+
+func (db *instrumentedDB) GetAllCategories(ctx context.Context) (_ []*models.Category, err error) {
+	defer db.measure(ctx, "GetAllCategories", time.Now(), &err)
+	return db.db.GetAllCategories(ctx)
+}
+
+func (db *instrumentedDB) GetCategoryById(ctx context.Context, id int64) (_ *models.Category, err error) {
+	defer db.measure(ctx, "GetCategoryById", time.Now(), &err)
+	return db.db.GetCategoryById(ctx, id)
+}
+
+func (db *instrumentedDB) UpdateCategory(ctx context.Context, id int64, category models.InputCategory) (_ *models.Category, err error) {
+	defer db.measure(ctx, "UpdateCategory", time.Now(), &err)
+	return db.db.UpdateCategory(ctx, id, category)
+}
+
+func (db *instrumentedDB) DeleteCategory(ctx context.Context, id int64) (err error) {
+	defer db.measure(ctx, "DeleteCategory", time.Now(), &err)
+	return db.db.DeleteCategory(ctx, id)
+}
+
+func (db *instrumentedDB) GetProductCategories(ctx context.Context, SKU string) (_ []*models.Category, err error) {
+	defer db.measure(ctx, "GetProductCategories", time.Now(), &err)
+	return db.db.GetProductCategories(ctx, SKU)
+}
+
+func (db *instrumentedDB) AddProductToCategory(ctx context.Context, SKU string, categoryId int64) (err error) {
+	defer db.measure(ctx, "AddProductToCategory", time.Now(), &err)
+	return db.db.AddProductToCategory(ctx, SKU, categoryId)
+}
+
+func (db *instrumentedDB) RemoveProductFromCategory(ctx context.Context, SKU string, categoryId int64) (err error) {
+	defer db.measure(ctx, "RemoveProductFromCategory", time.Now(), &err)
+	return db.db.RemoveProductFromCategory(ctx, SKU, categoryId)
+}
+
+func (db *instrumentedDB) GetAllTags(ctx context.Context) (_ []*models.TagCount, err error) {
+	defer db.measure(ctx, "GetAllTags", time.Now(), &err)
+	return db.db.GetAllTags(ctx)
+}
+
+func (db *instrumentedDB) GetProductTags(ctx context.Context, SKU string) (_ []string, err error) {
+	defer db.measure(ctx, "GetProductTags", time.Now(), &err)
+	return db.db.GetProductTags(ctx, SKU)
+}
+
+func (db *instrumentedDB) AddProductTag(ctx context.Context, SKU string, tag string) (err error) {
+	defer db.measure(ctx, "AddProductTag", time.Now(), &err)
+	return db.db.AddProductTag(ctx, SKU, tag)
+}
+
+func (db *instrumentedDB) RemoveProductTag(ctx context.Context, SKU string, tag string) (err error) {
+	defer db.measure(ctx, "RemoveProductTag", time.Now(), &err)
+	return db.db.RemoveProductTag(ctx, SKU, tag)
+}
+
+func (db *instrumentedDB) GetProductTranslations(ctx context.Context, SKU string) (_ []*models.Translation, err error) {
+	defer db.measure(ctx, "GetProductTranslations", time.Now(), &err)
+	return db.db.GetProductTranslations(ctx, SKU)
+}
+
+func (db *instrumentedDB) SetProductTranslation(ctx context.Context, SKU string, translation models.Translation) (err error) {
+	defer db.measure(ctx, "SetProductTranslation", time.Now(), &err)
+	return db.db.SetProductTranslation(ctx, SKU, translation)
+}
+
+func (db *instrumentedDB) DeleteProductTranslation(ctx context.Context, SKU string, locale string) (err error) {
+	defer db.measure(ctx, "DeleteProductTranslation", time.Now(), &err)
+	return db.db.DeleteProductTranslation(ctx, SKU, locale)
+}
+
+func (db *instrumentedDB) LocalizeProducts(ctx context.Context, products []*models.Product, locales []string) (err error) {
+	defer db.measure(ctx, "LocalizeProducts", time.Now(), &err)
+	return db.db.LocalizeProducts(ctx, products, locales)
+}
+
+func (db *instrumentedDB) AddAPIKey(ctx context.Context, key models.InputAPIKey) (_ *models.NewAPIKey, err error) {
+	defer db.measure(ctx, "AddAPIKey", time.Now(), &err)
+	return db.db.AddAPIKey(ctx, key)
+}
+
+func (db *instrumentedDB) GetAPIKey(ctx context.Context, key string) (_ *models.APIKey, err error) {
+	defer db.measure(ctx, "GetAPIKey", time.Now(), &err)
+	return db.db.GetAPIKey(ctx, key)
+}
+
+func (db *instrumentedDB) GetAllAPIKeys(ctx context.Context) (_ []*models.APIKey, err error) {
+	defer db.measure(ctx, "GetAllAPIKeys", time.Now(), &err)
+	return db.db.GetAllAPIKeys(ctx)
+}
+
+func (db *instrumentedDB) RevokeAPIKey(ctx context.Context, id int64) (err error) {
+	defer db.measure(ctx, "RevokeAPIKey", time.Now(), &err)
+	return db.db.RevokeAPIKey(ctx, id)
+}
+
+func (db *instrumentedDB) StartIdempotentRequest(ctx context.Context, client string, key string, requestHash string, abandonedBefore time.Time, expiredBefore time.Time) (_ *models.IdempotentResponse, err error) {
+	defer db.measure(ctx, "StartIdempotentRequest", time.Now(), &err)
+	return db.db.StartIdempotentRequest(ctx, client, key, requestHash, abandonedBefore, expiredBefore)
+}
+
+func (db *instrumentedDB) SaveIdempotentResponse(ctx context.Context, client string, key string, response models.IdempotentResponse) (err error) {
+	defer db.measure(ctx, "SaveIdempotentResponse", time.Now(), &err)
+	return db.db.SaveIdempotentResponse(ctx, client, key, response)
+}
+
+func (db *instrumentedDB) DeleteIdempotencyKey(ctx context.Context, client string, key string) (err error) {
+	defer db.measure(ctx, "DeleteIdempotencyKey", time.Now(), &err)
+	return db.db.DeleteIdempotencyKey(ctx, client, key)
+}
+
+func (db *instrumentedDB) GetProductImages(ctx context.Context, SKU string) (_ []*models.Image, err error) {
+	defer db.measure(ctx, "GetProductImages", time.Now(), &err)
+	return db.db.GetProductImages(ctx, SKU)
+}
+
+func (db *instrumentedDB) AddProductImage(ctx context.Context, SKU string, image models.Image) (_ *models.Image, err error) {
+	defer db.measure(ctx, "AddProductImage", time.Now(), &err)
+	return db.db.AddProductImage(ctx, SKU, image)
+}
+
+func (db *instrumentedDB) ReorderProductImages(ctx context.Context, SKU string, ids []int64) (_ []*models.Image, err error) {
+	defer db.measure(ctx, "ReorderProductImages", time.Now(), &err)
+	return db.db.ReorderProductImages(ctx, SKU, ids)
+}
+
+func (db *instrumentedDB) DeleteProductImage(ctx context.Context, SKU string, id int64) (_ *models.Image, err error) {
+	defer db.measure(ctx, "DeleteProductImage", time.Now(), &err)
+	return db.db.DeleteProductImage(ctx, SKU, id)
+}
+
+func (db *instrumentedDB) Ping(ctx context.Context) (err error) {
+	defer db.measure(ctx, "Ping", time.Now(), &err)
+	return db.db.Ping(ctx)
+}
+
+func (db *instrumentedDB) PendingMigrations(ctx context.Context) (_ int, err error) {
+	defer db.measure(ctx, "PendingMigrations", time.Now(), &err)
+	return db.db.PendingMigrations(ctx)
+}
+
+func (db *instrumentedDB) Close() (err error) {
+	defer db.measure(context.Background(), "Close", time.Now(), &err)
+	return db.db.Close()
+}
@@ -0,0 +1,99 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// AddAPIKey generates a random secret key, only its SHA-256 hash is stored
+func (db *sqlite3DB) AddAPIKey(ctx context.Context, input models.InputAPIKey) (*models.NewAPIKey, error) {
+	if input.Name == "" || len(input.Scopes) == 0 {
+		return nil, InvalidAPIKeyError
+	}
+	for _, scope := range input.Scopes {
+		if !models.ValidScope(scope) {
+			return nil, InvalidAPIKeyError
+		}
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	key := models.NewAPIKey{
+		APIKey: models.APIKey{Name: input.Name, Tenant: db.tenant, Scopes: input.Scopes, CreatedAt: time.Unix(time.Now().Unix(), 0).UTC()},
+		Key:    hex.EncodeToString(secret),
+	}
+	res, err := db.ExecContext(ctx, sqlQueries["insertAPIKey"], db.tenant, key.Name, hashAPIKey(key.Key), strings.Join(key.Scopes, ","), key.CreatedAt.Unix())
+	if err != nil {
+		return nil, err
+	}
+	if key.Id, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (db *sqlite3DB) GetAPIKey(ctx context.Context, key string) (*models.APIKey, error) {
+	apiKey, err := scanAPIKey(db.QueryRowContext(ctx, sqlQueries["getAPIKeyByHash"], hashAPIKey(key)))
+	if err == sql.ErrNoRows {
+		return nil, APIKeyNotFoundError
+	}
+	return apiKey, err
+}
+
+func (db *sqlite3DB) GetAllAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	rows, err := db.QueryContext(ctx, sqlQueries["getAllAPIKeys"], db.tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	keys := make([]*models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey marks the key as revoked, descriptions of revoked keys are kept for auditing
+func (db *sqlite3DB) RevokeAPIKey(ctx context.Context, id int64) error {
+	res, err := db.ExecContext(ctx, sqlQueries["revokeAPIKey"], time.Now().Unix(), id, db.tenant)
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return APIKeyNotFoundError
+	}
+	return nil
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var scopes string
+	var createdAt int64
+	var revokedAt sql.NullInt64
+	if err := row.Scan(&key.Id, &key.Name, &key.Tenant, &scopes, &createdAt, &revokedAt); err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	key.CreatedAt = time.Unix(createdAt, 0).UTC()
+	key.RevokedAt = timeFromUnix(revokedAt)
+	return &key, nil
+}
+
+// hashAPIKey returns hex encoded SHA-256 hash of the key, random keys are long enough for a fast hash
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
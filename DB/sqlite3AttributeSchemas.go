@@ -0,0 +1,183 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+func (db *sqlite3DB) SetAttributeSchema(ctx context.Context, schema models.AttributeSchema) error {
+	if err := schema.Check(); err != nil {
+		return fmt.Errorf("%w: %v", InvalidAttributeSchemaError, err)
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, sqlQueries["insertAttributeSchema"], db.tenant, schema.Type); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, sqlQueries["deleteAttributeDefinitions"], db.tenant, schema.Type); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i, attr := range schema.Attributes {
+		enum, _ := json.Marshal(attr.Enum)
+		_, err = tx.ExecContext(ctx, sqlQueries["insertAttributeDefinition"], db.tenant, schema.Type, attr.Name, attr.DataType, attr.Required, string(enum), i)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	// Products can not be changed until the commit, so they are checked with the schema they will have
+	SKU, err := checkProductsAttributes(ctx, tx, &schema, sqlQueries["getAttributesOfType"], db.tenant, schema.Type)
+	if SKU != "" {
+		tx.Rollback()
+		return fmt.Errorf("%w: attributes of product %s do not match it: %v", InvalidAttributeSchemaError, SKU, err)
+	} else if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *sqlite3DB) GetAttributeSchema(ctx context.Context, Type string) (*models.AttributeSchema, error) {
+	return db.getAttributeSchema(ctx, db, Type)
+}
+
+// getAttributeSchema reads the schema with the database or the transaction
+func (db *sqlite3DB) getAttributeSchema(ctx context.Context, q queryer, Type string) (*models.AttributeSchema, error) {
+	schemas, err := queryAttributeSchemas(ctx, q, sqlQueries["getAttributeSchema"], db.tenant, Type)
+	if err != nil {
+		return nil, err
+	} else if len(schemas) == 0 {
+		return nil, AttributeSchemaNotFoundError
+	}
+	return schemas[0], nil
+}
+
+func (db *sqlite3DB) GetAllAttributeSchemas(ctx context.Context) ([]*models.AttributeSchema, error) {
+	return queryAttributeSchemas(ctx, db, sqlQueries["getAllAttributeSchemas"], db.tenant)
+}
+
+func (db *sqlite3DB) DeleteAttributeSchema(ctx context.Context, Type string) error {
+	res, err := db.ExecContext(ctx, sqlQueries["deleteAttributeSchema"], db.tenant, Type)
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return AttributeSchemaNotFoundError
+	}
+	return nil
+}
+
+// validateAttributes checks attributes of the product with schema of its type.
+// Attributes of products without schema for their type are not validated.
+// The product is validated in the transaction writing it, so the schema can not be changed until the commit.
+func (db *sqlite3DB) validateAttributes(ctx context.Context, tx *sql.Tx, product models.InputProduct) error {
+	schema, err := db.getAttributeSchema(ctx, tx, product.Type)
+	if err == AttributeSchemaNotFoundError {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err = schema.Validate(product.Attributes); err != nil {
+		return fmt.Errorf("%w: %v", InvalidAttributesError, err)
+	}
+	return nil
+}
+
+// validateVariants checks attributes of variants of the product with schema of their type
+func (db *sqlite3DB) validateVariants(ctx context.Context, tx *sql.Tx, parentId int64, Type string) error {
+	schema, err := db.getAttributeSchema(ctx, tx, Type)
+	if err == AttributeSchemaNotFoundError {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	SKU, err := checkProductsAttributes(ctx, tx, schema, sqlQueries["getVariantsAttributes"], parentId)
+	if SKU != "" {
+		return fmt.Errorf("%w: variant %s: %v", InvalidAttributesError, SKU, err)
+	}
+	return err
+}
+
+// checkProductsAttributes validates attributes of products selected by the query as rows of id, SKU,
+// attribute name and value ordered by id. It returns SKU of the first invalid product with the validation error.
+func checkProductsAttributes(ctx context.Context, q queryer, schema *models.AttributeSchema, query string, args ...interface{}) (string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var id int64
+	var SKU string
+	var attributes map[string]string
+	validate := func() (string, error) {
+		if id == 0 {
+			return "", nil
+		} else if err := schema.Validate(attributes); err != nil {
+			return SKU, err
+		}
+		return "", nil
+	}
+	for rows.Next() {
+		var rowId int64
+		var rowSKU string
+		// Attribute columns are NULL for products without attributes
+		var name, value sql.NullString
+		if err = rows.Scan(&rowId, &rowSKU, &name, &value); err != nil {
+			return "", err
+		}
+		if rowId != id {
+			if invalid, err := validate(); invalid != "" {
+				return invalid, err
+			}
+			id, SKU, attributes = rowId, rowSKU, make(map[string]string)
+		}
+		if name.Valid {
+			attributes[name.String] = value.String
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	return validate()
+}
+
+// queryAttributeSchemas collects attribute definitions ordered by type into schemas
+func queryAttributeSchemas(ctx context.Context, q queryer, query string, args ...interface{}) ([]*models.AttributeSchema, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schemas := make([]*models.AttributeSchema, 0)
+	for rows.Next() {
+		var Type string
+		// Definition columns are NULL for schemas without attributes
+		var name, dataType, enum sql.NullString
+		var required sql.NullBool
+		if err = rows.Scan(&Type, &name, &dataType, &required, &enum); err != nil {
+			return nil, err
+		}
+		if len(schemas) == 0 || schemas[len(schemas)-1].Type != Type {
+			schemas = append(schemas, &models.AttributeSchema{Type: Type, Attributes: []models.AttributeDefinition{}})
+		}
+		if !name.Valid {
+			continue
+		}
+		attr := models.AttributeDefinition{Name: name.String, DataType: dataType.String, Required: required.Bool}
+		if err = json.Unmarshal([]byte(enum.String), &attr.Enum); err != nil {
+			return nil, err
+		}
+		schema := schemas[len(schemas)-1]
+		schema.Attributes = append(schema.Attributes, attr)
+	}
+	return schemas, rows.Err()
+}
@@ -0,0 +1,153 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"database/sql"
+	"github.com/mattn/go-sqlite3"
+)
+
+func (db *sqlite3DB) AddCategory(ctx context.Context, category models.InputCategory) (*models.Category, error) {
+	if err := db.checkParentCategory(ctx, category.ParentId); err != nil {
+		return nil, err
+	}
+	res, err := db.ExecContext(ctx, sqlQueries["insertCategory"], db.tenant, category.Name, nullableId(category.ParentId))
+	if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == 2067 {
+		// Error code 2067 means UNIQUE constraint failed (https://www.sqlite.org/rescode.html#constraint_unique)
+		return nil, CategoryAlreadyExistsError
+	} else if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &models.Category{InputCategory: category, Id: id}, nil
+}
+
+func (db *sqlite3DB) GetAllCategories(ctx context.Context) ([]*models.Category, error) {
+	return db.queryCategories(ctx, sqlQueries["getAllCategories"], db.tenant)
+}
+
+func (db *sqlite3DB) GetCategoryById(ctx context.Context, id int64) (*models.Category, error) {
+	var category models.Category
+	err := db.QueryRowContext(ctx, sqlQueries["getCategoryById"], id, db.tenant).Scan(&category.Id, &category.Name, &category.ParentId)
+	if err == sql.ErrNoRows {
+		return nil, CategoryNotFoundError
+	} else if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// UpdateCategory renames the category and moves it to another parent category
+func (db *sqlite3DB) UpdateCategory(ctx context.Context, id int64, category models.InputCategory) (*models.Category, error) {
+	if _, err := db.GetCategoryById(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := db.checkParentCategory(ctx, category.ParentId); err != nil {
+		return nil, err
+	}
+	if category.ParentId != 0 {
+		var count int
+		if err := db.QueryRowContext(ctx, sqlQueries["isSubcategory"], id, category.ParentId).Scan(&count); err != nil {
+			return nil, err
+		} else if count > 0 {
+			return nil, CategoryCycleError
+		}
+	}
+	_, err := db.ExecContext(ctx, sqlQueries["updateCategory"], category.Name, nullableId(category.ParentId), id)
+	if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == 2067 {
+		// Error code 2067 means UNIQUE constraint failed (https://www.sqlite.org/rescode.html#constraint_unique)
+		return nil, CategoryAlreadyExistsError
+	} else if err != nil {
+		return nil, err
+	}
+	return &models.Category{InputCategory: category, Id: id}, nil
+}
+
+// DeleteCategory deletes the category, its subcategories are moved to its parent category
+func (db *sqlite3DB) DeleteCategory(ctx context.Context, id int64) error {
+	category, err := db.GetCategoryById(ctx, id)
+	if err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, sqlQueries["updateCategoriesParent"], nullableId(category.ParentId), id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, sqlQueries["deleteCategory"], id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (db *sqlite3DB) GetProductCategories(ctx context.Context, SKU string) ([]*models.Category, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	return db.queryCategories(ctx, sqlQueries["getProductCategories"], prod.Id)
+}
+
+func (db *sqlite3DB) AddProductToCategory(ctx context.Context, SKU string, categoryId int64) error {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	if _, err = db.GetCategoryById(ctx, categoryId); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sqlQueries["insertProductCategory"], prod.Id, categoryId)
+	return err
+}
+
+func (db *sqlite3DB) RemoveProductFromCategory(ctx context.Context, SKU string, categoryId int64) error {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	if _, err = db.GetCategoryById(ctx, categoryId); err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sqlQueries["deleteProductCategory"], prod.Id, categoryId)
+	return err
+}
+
+func (db *sqlite3DB) checkParentCategory(ctx context.Context, parentId int64) error {
+	if parentId == 0 {
+		return nil
+	}
+	_, err := db.GetCategoryById(ctx, parentId)
+	if err == CategoryNotFoundError {
+		return ParentCategoryNotFoundError
+	}
+	return err
+}
+
+func (db *sqlite3DB) queryCategories(ctx context.Context, query string, args ...interface{}) ([]*models.Category, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	categories := make([]*models.Category, 0)
+	for rows.Next() {
+		var category models.Category
+		if err = rows.Scan(&category.Id, &category.Name, &category.ParentId); err != nil {
+			return nil, err
+		}
+		categories = append(categories, &category)
+	}
+	return categories, rows.Err()
+}
+
+// nullableId converts id of a referenced row to NULL if it is 0
+func nullableId(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
@@ -1,12 +1,20 @@
 package DB
 
 import (
+	"XsollaSchoolBE/logging"
 	"XsollaSchoolBE/models"
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/mattn/go-sqlite3"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+const productColumns = "p.id, p.SKU, p.name, p.type, p.cost, p.description, IFNULL(parent.SKU, ''), p.status, p.publishAt, p.unpublishAt"
+const productsSource = "Products p LEFT JOIN Products parent ON p.parentId=parent.id"
+
 var sqlQueries = map[string]string{
 	"init": `
 	CREATE TABLE IF NOT EXISTS Products (
@@ -17,32 +25,325 @@ var sqlQueries = map[string]string{
 		cost INTEGER,
 		UNIQUE(SKU)
 	)`,
-	"getProductById":     "SELECT * FROM Products WHERE id=?",
-	"getProductBySKU":    "SELECT * From Products WHERE SKU=?",
-	"getAllProducts":     "SELECT * FROM Products",
-	"getGroupOfProducts": "SELECT * FROM Products ORDER BY id LIMIT ? OFFSET ?",
-	"insertProduct":      "INSERT INTO Products(SKU, name, type, cost) VALUES(?, ?, ?, ?)",
-	"deleteProductBySKU": "DELETE FROM Products WHERE SKU=?",
-	"deleteProductById":  "DELETE FROM Products WHERE id=?",
-	"updateProductBySKU": "UPDATE Products SET SKU=?, name=?, type=?, cost=? WHERE SKU=?",
-	"updateProductById":  "UPDATE Products SET SKU=?, name=?, type=?, cost=? WHERE id=?",
+	"getUserVersion":          "PRAGMA user_version",
+	"getProductById":          "SELECT " + productColumns + " FROM " + productsSource + " WHERE p.id=? AND p.tenant=?",
+	"getProductBySKU":         "SELECT " + productColumns + " FROM " + productsSource + " WHERE p.SKU=? AND p.tenant=?",
+	"getAllProducts":          "SELECT " + productColumns + " FROM " + productsSource,
+	"getVariants":             "SELECT " + productColumns + " FROM " + productsSource + " WHERE p.parentId=? ORDER BY p.id",
+	"getVariantsCount":        "SELECT COUNT(*) FROM Products WHERE parentId=?",
+	"getProductAttributes":    "SELECT name, value FROM ProductAttributes WHERE productId=?",
+	"insertProduct":           "INSERT INTO Products(tenant, SKU, name, type, cost, description, parentId, status) VALUES(?, ?, ?, ?, ?, ?, ?, ?)",
+	"insertProductAttribute":  "INSERT INTO ProductAttributes(productId, name, value) VALUES(?, ?, ?)",
+	"deleteProductBySKU":      "DELETE FROM Products WHERE SKU=? AND tenant=?",
+	"deleteProductById":       "DELETE FROM Products WHERE id=? AND tenant=?",
+	"deleteProductAttributes": "DELETE FROM ProductAttributes WHERE productId=?",
+	"updateVariants":          "UPDATE Products SET name=?, type=? WHERE parentId=?",
+	"updateProductStatus":     "UPDATE Products SET status=? WHERE id=? AND status=?",
+	"updateProductSchedule":   "UPDATE Products SET publishAt=?, unpublishAt=? WHERE id=?",
+	// The product is updated only if it is not changed by another request since it is read
+	"updateProduct": "UPDATE Products SET SKU=?, name=?, type=?, cost=?, description=?, parentId=? " +
+		"WHERE id=? AND SKU=? AND name=? AND type=? AND cost=? AND description=? " +
+		"AND parentId IS (SELECT id FROM Products WHERE tenant=? AND SKU=?)",
+	// Scheduled changes are applied once as their times are cleared,
+	// they are skipped if the product status has been changed manually
+	"getScheduledTenants": "SELECT DISTINCT tenant FROM Products WHERE publishAt<=? OR unpublishAt<=? ORDER BY tenant",
+	"publishScheduledProducts": `
+	UPDATE Products SET status=CASE WHEN status='draft' THEN 'published' ELSE status END, publishAt=NULL
+	WHERE publishAt<=?`,
+	"unpublishScheduledProducts": `
+	UPDATE Products SET status=CASE WHEN status='published' THEN 'draft' ELSE status END, unpublishAt=NULL
+	WHERE unpublishAt<=?`,
+	"getNextScheduledTime": "SELECT MIN(at) FROM (SELECT MIN(publishAt) AS at FROM Products UNION ALL SELECT MIN(unpublishAt) FROM Products)",
+	"getScheduledChanges": `
+	SELECT SKU, 'published', publishAt FROM Products WHERE tenant=? AND publishAt IS NOT NULL
+	UNION ALL
+	SELECT SKU, 'draft', unpublishAt FROM Products WHERE tenant=? AND unpublishAt IS NOT NULL
+	ORDER BY 3, 1`,
+	"getAttributeSchema": `
+	SELECT s.type, d.name, d.dataType, d.required, d.enum
+	FROM AttributeSchemas s LEFT JOIN AttributeDefinitions d ON s.tenant=d.tenant AND s.type=d.type
+	WHERE s.tenant=? AND s.type=? ORDER BY d.position`,
+	"getAllAttributeSchemas": `
+	SELECT s.type, d.name, d.dataType, d.required, d.enum
+	FROM AttributeSchemas s LEFT JOIN AttributeDefinitions d ON s.tenant=d.tenant AND s.type=d.type
+	WHERE s.tenant=? ORDER BY s.type, d.position`,
+	"getAttributesOfType": `
+	SELECT p.id, p.SKU, a.name, a.value FROM Products p LEFT JOIN ProductAttributes a ON a.productId=p.id
+	WHERE p.tenant=? AND p.type=? ORDER BY p.id`,
+	"getVariantsAttributes": `
+	SELECT p.id, p.SKU, a.name, a.value FROM Products p LEFT JOIN ProductAttributes a ON a.productId=p.id
+	WHERE p.parentId=? ORDER BY p.id`,
+	"insertAttributeSchema":      "INSERT OR IGNORE INTO AttributeSchemas(tenant, type) VALUES(?, ?)",
+	"insertAttributeDefinition":  "INSERT INTO AttributeDefinitions(tenant, type, name, dataType, required, enum, position) VALUES(?, ?, ?, ?, ?, ?, ?)",
+	"deleteAttributeSchema":      "DELETE FROM AttributeSchemas WHERE tenant=? AND type=?",
+	"deleteAttributeDefinitions": "DELETE FROM AttributeDefinitions WHERE tenant=? AND type=?",
+	"getCategoryById":            "SELECT id, name, IFNULL(parentId, 0) FROM Categories WHERE id=? AND tenant=?",
+	"getAllCategories":           "SELECT id, name, IFNULL(parentId, 0) FROM Categories WHERE tenant=? ORDER BY id",
+	"getProductCategories": `
+	SELECT c.id, c.name, IFNULL(c.parentId, 0)
+	FROM Categories c JOIN ProductCategories pc ON c.id=pc.categoryId
+	WHERE pc.productId=? ORDER BY c.id`,
+	"isSubcategory": `
+	WITH RECURSIVE subcategories(id) AS (
+		SELECT ? UNION SELECT c.id FROM Categories c JOIN subcategories s ON c.parentId=s.id
+	)
+	SELECT COUNT(*) FROM subcategories WHERE id=?`,
+	"insertCategory":         "INSERT INTO Categories(tenant, name, parentId) VALUES(?, ?, ?)",
+	"insertProductCategory":  "INSERT OR IGNORE INTO ProductCategories(productId, categoryId) VALUES(?, ?)",
+	"updateCategory":         "UPDATE Categories SET name=?, parentId=? WHERE id=?",
+	"updateCategoriesParent": "UPDATE Categories SET parentId=? WHERE parentId=?",
+	"deleteCategory":         "DELETE FROM Categories WHERE id=?",
+	"deleteProductCategory":  "DELETE FROM ProductCategories WHERE productId=? AND categoryId=?",
+	"getAllTags": `
+	SELECT t.tag, COUNT(*) FROM ProductTags t JOIN Products p ON t.productId=p.id
+	WHERE p.tenant=? GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag`,
+	"getProductTags":   "SELECT tag FROM ProductTags WHERE productId=? ORDER BY tag",
+	"insertProductTag": "INSERT OR IGNORE INTO ProductTags(productId, tag) VALUES(?, ?)",
+	"deleteProductTag": "DELETE FROM ProductTags WHERE productId=? AND tag=?",
+	// LIKE uses NOCASE indexes on SKU and name for prefix patterns, only published products are suggested
+	// Names are translated to the first locale of the comma separated chain, for which products have translations
+	"suggestProducts": `
+	WITH localized AS (
+		SELECT p.SKU, IFNULL((
+			SELECT t.name FROM ProductTranslations t WHERE t.productId=p.id AND instr(?, ',' || t.locale || ',') > 0
+			ORDER BY instr(?, ',' || t.locale || ',') LIMIT 1
+		), p.name) AS name
+		FROM Products p WHERE p.tenant=? AND p.status='published'
+	)
+	SELECT SKU, name FROM (
+		SELECT SKU, name, 0 AS priority FROM localized WHERE SKU LIKE ? ESCAPE '\'
+		UNION ALL
+		SELECT SKU, name, 1 AS priority FROM localized WHERE name LIKE ? ESCAPE '\'
+	)
+	GROUP BY SKU ORDER BY MIN(priority), length(name), name LIMIT ?`,
+	"getProductTranslations":   "SELECT locale, name, description FROM ProductTranslations WHERE productId=? ORDER BY locale",
+	"insertProductTranslation": "INSERT OR REPLACE INTO ProductTranslations(productId, locale, name, description) VALUES(?, ?, ?, ?)",
+	"deleteProductTranslation": "DELETE FROM ProductTranslations WHERE productId=? AND locale=?",
+	"getProductImages":         "SELECT id, url, thumbnailUrl, blobKey, thumbnailKey FROM ProductImages WHERE productId=? ORDER BY position, id",
+	"insertProductImage": `
+	INSERT INTO ProductImages(productId, position, url, thumbnailUrl, blobKey, thumbnailKey)
+	SELECT ?, IFNULL(MAX(position), 0) + 1, ?, ?, ?, ? FROM ProductImages WHERE productId=?`,
+	"updateProductImagePosition":   "UPDATE ProductImages SET position=? WHERE id=? AND productId=?",
+	"deleteProductImage":           "DELETE FROM ProductImages WHERE id=? AND productId=?",
+	"insertAPIKey":                 "INSERT INTO APIKeys(tenant, name, keyHash, scopes, createdAt) VALUES(?, ?, ?, ?, ?)",
+	"getAPIKeyByHash":              "SELECT id, name, tenant, scopes, createdAt, revokedAt FROM APIKeys WHERE keyHash=? AND revokedAt IS NULL",
+	"getAllAPIKeys":                "SELECT id, name, tenant, scopes, createdAt, revokedAt FROM APIKeys WHERE tenant=? ORDER BY id",
+	"insertIdempotencyKey":         "INSERT OR IGNORE INTO IdempotencyKeys(tenant, client, key, requestHash, createdAt, lockedAt) VALUES(?, ?, ?, ?, ?, ?)",
+	"getIdempotentResponse":        "SELECT requestHash, completed, statusCode, contentType, location, body, createdAt, lockedAt FROM IdempotencyKeys WHERE tenant=? AND client=? AND key=?",
+	"relockIdempotencyKey":         "UPDATE IdempotencyKeys SET lockedAt=? WHERE tenant=? AND client=? AND key=? AND completed=0 AND lockedAt=?",
+	"updateIdempotentResponse":     "UPDATE IdempotencyKeys SET completed=1, statusCode=?, contentType=?, location=?, body=? WHERE tenant=? AND client=? AND key=?",
+	"deleteIdempotencyKey":         "DELETE FROM IdempotencyKeys WHERE tenant=? AND client=? AND key=?",
+	"deleteExpiredIdempotencyKeys": "DELETE FROM IdempotencyKeys WHERE createdAt<?",
+	"revokeAPIKey":                 "UPDATE APIKeys SET revokedAt=? WHERE id=? AND tenant=? AND revokedAt IS NULL",
+}
+
+// migrations change the schema created with "init" query, they are applied in order.
+// The number of applied migrations is stored in user_version pragma of the database.
+var migrations = []string{
+	`
+	ALTER TABLE Products ADD COLUMN parentId INTEGER REFERENCES Products(id) ON DELETE CASCADE;
+	CREATE INDEX IF NOT EXISTS ProductsParentIdIndex ON Products(parentId);
+	CREATE TABLE IF NOT EXISTS ProductAttributes (
+		productId INTEGER REFERENCES Products(id) ON DELETE CASCADE,
+		name TEXT,
+		value TEXT,
+		PRIMARY KEY(productId, name)
+	);
+	CREATE INDEX IF NOT EXISTS ProductAttributesNameValueIndex ON ProductAttributes(name, value);`,
+	`
+	CREATE TABLE IF NOT EXISTS AttributeSchemas (
+		type TEXT PRIMARY KEY
+	);
+	CREATE TABLE IF NOT EXISTS AttributeDefinitions (
+		type TEXT REFERENCES AttributeSchemas(type) ON DELETE CASCADE,
+		name TEXT,
+		dataType TEXT,
+		required INTEGER,
+		enum TEXT,
+		position INTEGER,
+		PRIMARY KEY(type, name)
+	);`,
+	`
+	CREATE TABLE IF NOT EXISTS Categories (
+		id INTEGER PRIMARY KEY,
+		name TEXT,
+		parentId INTEGER REFERENCES Categories(id),
+		UNIQUE(name)
+	);
+	CREATE INDEX IF NOT EXISTS CategoriesParentIdIndex ON Categories(parentId);
+	CREATE TABLE IF NOT EXISTS ProductCategories (
+		productId INTEGER REFERENCES Products(id) ON DELETE CASCADE,
+		categoryId INTEGER REFERENCES Categories(id) ON DELETE CASCADE,
+		PRIMARY KEY(productId, categoryId)
+	);
+	CREATE INDEX IF NOT EXISTS ProductCategoriesCategoryIdIndex ON ProductCategories(categoryId);`,
+	`
+	CREATE TABLE IF NOT EXISTS ProductTags (
+		productId INTEGER REFERENCES Products(id) ON DELETE CASCADE,
+		tag TEXT,
+		PRIMARY KEY(productId, tag)
+	);
+	CREATE INDEX IF NOT EXISTS ProductTagsTagIndex ON ProductTags(tag, productId);`,
+	`ALTER TABLE Products ADD COLUMN description TEXT NOT NULL DEFAULT ''`,
+	`
+	CREATE INDEX IF NOT EXISTS ProductsSKUNocaseIndex ON Products(SKU COLLATE NOCASE);
+	CREATE INDEX IF NOT EXISTS ProductsNameNocaseIndex ON Products(name COLLATE NOCASE);`,
+	`
+	CREATE TABLE IF NOT EXISTS ProductTranslations (
+		productId INTEGER REFERENCES Products(id) ON DELETE CASCADE,
+		locale TEXT,
+		name TEXT,
+		description TEXT,
+		PRIMARY KEY(productId, locale)
+	);`,
+	`
+	CREATE TABLE IF NOT EXISTS ProductImages (
+		id INTEGER PRIMARY KEY,
+		productId INTEGER REFERENCES Products(id) ON DELETE CASCADE,
+		position INTEGER,
+		url TEXT,
+		thumbnailUrl TEXT,
+		blobKey TEXT,
+		thumbnailKey TEXT
+	);
+	CREATE INDEX IF NOT EXISTS ProductImagesProductIdIndex ON ProductImages(productId, position);`,
+	// Products existing before lifecycle statuses were shown in the catalog, so they are published
+	`
+	ALTER TABLE Products ADD COLUMN status TEXT NOT NULL DEFAULT 'published';
+	CREATE INDEX IF NOT EXISTS ProductsStatusIndex ON Products(status);`,
+	`
+	ALTER TABLE Products ADD COLUMN publishAt INTEGER;
+	ALTER TABLE Products ADD COLUMN unpublishAt INTEGER;
+	CREATE INDEX IF NOT EXISTS ProductsPublishAtIndex ON Products(publishAt);
+	CREATE INDEX IF NOT EXISTS ProductsUnpublishAtIndex ON Products(unpublishAt);`,
+	`
+	CREATE TABLE IF NOT EXISTS APIKeys (
+		id INTEGER PRIMARY KEY,
+		name TEXT,
+		keyHash TEXT UNIQUE,
+		scopes TEXT,
+		createdAt INTEGER,
+		revokedAt INTEGER
+	);`,
+	// Tables are rebuilt to make SKUs of products, names of categories and types of schemas unique per tenant,
+	// existing data belongs to the default tenant
+	`
+	CREATE TABLE TenantProducts (
+		id INTEGER PRIMARY KEY,
+		tenant TEXT NOT NULL DEFAULT 'default',
+		SKU TEXT,
+		name TEXT,
+		type TEXT,
+		cost INTEGER,
+		parentId INTEGER REFERENCES Products(id) ON DELETE CASCADE,
+		description TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'published',
+		publishAt INTEGER,
+		unpublishAt INTEGER,
+		UNIQUE(tenant, SKU)
+	);
+	INSERT INTO TenantProducts(id, SKU, name, type, cost, parentId, description, status, publishAt, unpublishAt)
+	SELECT id, SKU, name, type, cost, parentId, description, status, publishAt, unpublishAt FROM Products;
+	DROP TABLE Products;
+	ALTER TABLE TenantProducts RENAME TO Products;
+	CREATE INDEX ProductsParentIdIndex ON Products(parentId);
+	CREATE INDEX ProductsSKUNocaseIndex ON Products(SKU COLLATE NOCASE);
+	CREATE INDEX ProductsNameNocaseIndex ON Products(name COLLATE NOCASE);
+	CREATE INDEX ProductsStatusIndex ON Products(tenant, status);
+	CREATE INDEX ProductsPublishAtIndex ON Products(publishAt);
+	CREATE INDEX ProductsUnpublishAtIndex ON Products(unpublishAt);
+	CREATE TABLE TenantCategories (
+		id INTEGER PRIMARY KEY,
+		tenant TEXT NOT NULL DEFAULT 'default',
+		name TEXT,
+		parentId INTEGER REFERENCES Categories(id),
+		UNIQUE(tenant, name)
+	);
+	INSERT INTO TenantCategories(id, name, parentId) SELECT id, name, parentId FROM Categories;
+	DROP TABLE Categories;
+	ALTER TABLE TenantCategories RENAME TO Categories;
+	CREATE INDEX CategoriesParentIdIndex ON Categories(parentId);
+	CREATE TABLE TenantAttributeSchemas (
+		tenant TEXT NOT NULL DEFAULT 'default',
+		type TEXT,
+		PRIMARY KEY(tenant, type)
+	);
+	CREATE TABLE TenantAttributeDefinitions (
+		tenant TEXT NOT NULL DEFAULT 'default',
+		type TEXT,
+		name TEXT,
+		dataType TEXT,
+		required INTEGER,
+		enum TEXT,
+		position INTEGER,
+		PRIMARY KEY(tenant, type, name),
+		FOREIGN KEY(tenant, type) REFERENCES AttributeSchemas(tenant, type) ON DELETE CASCADE
+	);
+	INSERT INTO TenantAttributeSchemas(type) SELECT type FROM AttributeSchemas;
+	INSERT INTO TenantAttributeDefinitions(type, name, dataType, required, enum, position)
+	SELECT type, name, dataType, required, enum, position FROM AttributeDefinitions;
+	DROP TABLE AttributeDefinitions;
+	DROP TABLE AttributeSchemas;
+	ALTER TABLE TenantAttributeSchemas RENAME TO AttributeSchemas;
+	ALTER TABLE TenantAttributeDefinitions RENAME TO AttributeDefinitions;
+	ALTER TABLE APIKeys ADD COLUMN tenant TEXT NOT NULL DEFAULT 'default';`,
+	`
+	CREATE TABLE IF NOT EXISTS IdempotencyKeys (
+		tenant TEXT,
+		client TEXT,
+		key TEXT,
+		requestHash TEXT,
+		completed INTEGER NOT NULL DEFAULT 0,
+		statusCode INTEGER,
+		contentType TEXT,
+		location TEXT,
+		body BLOB,
+		createdAt INTEGER,
+		PRIMARY KEY(tenant, client, key)
+	);
+	CREATE INDEX IF NOT EXISTS IdempotencyKeysCreatedAtIndex ON IdempotencyKeys(createdAt);`,
+	`
+	ALTER TABLE IdempotencyKeys ADD COLUMN lockedAt INTEGER;
+	UPDATE IdempotencyKeys SET lockedAt=createdAt;`,
 }
 
 type sqlite3DB struct {
 	*sql.DB
+	// tenant owns the data, which methods operate on
+	tenant string
+	// logger is nil if messages are not written
+	logger *logging.Logger
+	// fullTextSearch is true if SQLite is built with FTS5 extension
+	fullTextSearch bool
+	suggestions    *suggestionCache
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer is a database or a transaction
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 }
 
 func InitSqlite3DB(DBfilename string) (*sqlite3DB, error) {
 	var err error
-	sqlDB, err := sql.Open("sqlite3", DBfilename)
+	sqlDB, err := sql.Open("sqlite3", withForeignKeys(DBfilename))
 	if err != nil {
 		return nil, fmt.Errorf("db init error: %v", err)
 	}
-	db := sqlite3DB{sqlDB}
+	db := sqlite3DB{DB: sqlDB, tenant: models.DefaultTenant, suggestions: newSuggestionCache(suggestionCacheSize, suggestionCacheTTL)}
 	_, err = db.Exec(sqlQueries["init"])
 	if err != nil {
 		return nil, err
 	}
+	if err = db.migrate(); err != nil {
+		return nil, err
+	}
+	if err = db.initFullTextSearch(); err != nil {
+		return nil, err
+	}
 	for _, query := range sqlQueries {
 		_, err = db.Prepare(query)
 		if err != nil {
@@ -52,18 +353,143 @@ func InitSqlite3DB(DBfilename string) (*sqlite3DB, error) {
 	return &db, nil
 }
 
-func (db *sqlite3DB) AddProduct(product models.InputProduct) (*models.Product, error) {
-	prod, err := db.GetProductBySKU(product.SKU)
+// WithTenant returns the database sharing the connection with db and operating on data of the tenant
+func (db *sqlite3DB) WithTenant(tenant string) DB {
+	scoped := *db
+	scoped.tenant = tenant
+	return &scoped
+}
+
+// WithLogger returns the database sharing the connection with db and writing messages to the logger
+func (db *sqlite3DB) WithLogger(logger *logging.Logger) DB {
+	scoped := *db
+	scoped.logger = logger
+	return &scoped
+}
+
+// withForeignKeys adds to DSN the parameter enabling foreign key constraints,
+// which are disabled in SQLite by default
+func withForeignKeys(DSN string) string {
+	if strings.Contains(DSN, "?") {
+		return DSN + "&_foreign_keys=on"
+	}
+	return DSN + "?_foreign_keys=on"
+}
+
+// migrate applies migrations on one connection with foreign key constraints disabled, so tables can be rebuilt
+// without cascade deletes. Constraints are checked before every migration is committed.
+func (db *sqlite3DB) migrate() error {
+	var version int
+	if err := db.QueryRow(sqlQueries["getUserVersion"]).Scan(&version); err != nil {
+		return err
+	}
+	if version == len(migrations) {
+		return nil
+	}
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// foreign_keys pragma can't be changed inside a transaction
+	if _, err = conn.ExecContext(ctx, "PRAGMA foreign_keys=OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys=ON")
+	for ; version < len(migrations); version++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(migrations[version]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d error: %v", version+1, err)
+		}
+		if err = checkForeignKeys(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d error: %v", version+1, err)
+		}
+		// PRAGMA statements can't have bound parameters
+		if _, err = tx.Exec(fmt.Sprintf("PRAGMA user_version=%d", version+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *sqlite3DB) Ping(ctx context.Context) error {
+	return db.PingContext(ctx)
+}
+
+func (db *sqlite3DB) PendingMigrations(ctx context.Context) (int, error) {
+	var version int
+	if err := db.QueryRowContext(ctx, sqlQueries["getUserVersion"]).Scan(&version); err != nil {
+		return 0, err
+	}
+	return len(migrations) - version, nil
+}
+
+func checkForeignKeys(tx *sql.Tx) error {
+	rows, err := tx.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table string
+		var rowid sql.NullInt64
+		var parent string
+		var fkid int
+		if err = rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return err
+		}
+		return fmt.Errorf("foreign key constraint failed in %s table", table)
+	}
+	return rows.Err()
+}
+
+func (db *sqlite3DB) AddProduct(ctx context.Context, product models.InputProduct) (*models.Product, error) {
+	if models.ReservedSKU(product.SKU) {
+		return nil, ReservedSKUError
+	}
+	prod, err := db.GetProductBySKU(ctx, product.SKU)
 	if err == ProductNotFoundError {
-		res, err := db.Exec(sqlQueries["insertProduct"], product.SKU, product.Name, product.Type, product.Cost)
+		parentId, err := db.resolveParent(ctx, &product)
 		if err != nil {
 			return nil, err
 		}
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := tx.ExecContext(ctx, sqlQueries["insertProduct"], db.tenant, product.SKU, product.Name, product.Type, product.Cost, product.Description, parentId, models.DraftStatus)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 		id, err := res.LastInsertId()
 		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err = insertAttributes(ctx, tx, id, product.Attributes); err != nil {
+			tx.Rollback()
 			return nil, err
 		}
-		return &models.Product{product, id}, nil
+		if err = db.validateAttributes(ctx, tx, product); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		db.suggestions.clear()
+		return &models.Product{InputProduct: product, Id: id, Status: models.DraftStatus}, nil
 	} else if err != nil {
 		return nil, err
 	} else {
@@ -71,98 +497,413 @@ func (db *sqlite3DB) AddProduct(product models.InputProduct) (*models.Product, e
 	}
 }
 
-func (db *sqlite3DB) GetAllProducts() ([]*models.Product, error) {
-	rows, err := db.Query(sqlQueries["getAllProducts"])
+func (db *sqlite3DB) GetAllProducts(ctx context.Context, filter models.ProductFilter) ([]*models.Product, error) {
+	condition, args := db.filterCondition(filter)
+	return db.queryProducts(ctx, sqlQueries["getAllProducts"]+condition+" ORDER BY p.id", args...)
+}
+
+func (db *sqlite3DB) GetGroupOfProducts(ctx context.Context, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.Product, error) {
+	condition, args := db.filterCondition(filter)
+	args = append(args, groupSize, (groupNum-1)*groupSize)
+	return db.queryProducts(ctx, sqlQueries["getAllProducts"]+condition+" ORDER BY p.id LIMIT ? OFFSET ?", args...)
+}
+
+func (db *sqlite3DB) GetProductBySKU(ctx context.Context, SKU string) (*models.Product, error) {
+	return db.queryProduct(ctx, sqlQueries["getProductBySKU"], SKU, db.tenant)
+}
+
+func (db *sqlite3DB) GetProductById(ctx context.Context, id int64) (*models.Product, error) {
+	return db.queryProduct(ctx, sqlQueries["getProductById"], id, db.tenant)
+}
+
+func (db *sqlite3DB) GetVariants(ctx context.Context, SKU string) ([]*models.Product, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
 	if err != nil {
 		return nil, err
 	}
-	products := make([]*models.Product, 0)
-	for rows.Next() {
-		var product models.Product
-		rows.Scan(&product.Id, &product.SKU, &product.Name, &product.Type, &product.Cost)
-		products = append(products, &product)
+	return db.queryProducts(ctx, sqlQueries["getVariants"], prod.Id)
+}
+
+func (db *sqlite3DB) DeleteProductById(ctx context.Context, id int64) error {
+	_, err := db.GetProductById(ctx, id)
+	if err != nil {
+		return err
 	}
-	return products, nil
+	_, err = db.ExecContext(ctx, sqlQueries["deleteProductById"], id, db.tenant)
+	db.suggestions.clear()
+	return err
 }
 
-func (db *sqlite3DB) GetGroupOfProducts(groupSize uint, groupNum uint) ([]*models.Product, error) {
-	rows, err := db.Query(sqlQueries["getGroupOfProducts"], groupSize, (groupNum-1)*groupSize)
+func (db *sqlite3DB) DeleteProductBySKU(ctx context.Context, SKU string) error {
+	_, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sqlQueries["deleteProductBySKU"], SKU, db.tenant)
+	db.suggestions.clear()
+	return err
+}
+
+func (db *sqlite3DB) UpdateProductBySKU(ctx context.Context, SKU string, inputProd models.InputProduct, expected *models.Product) (*models.Product, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return prod, err
+	}
+	return db.updateProduct(ctx, prod, inputProd, expected)
+}
+
+func (db *sqlite3DB) UpdateProductById(ctx context.Context, id int64, inputProd models.InputProduct, expected *models.Product) (*models.Product, error) {
+	prod, err := db.GetProductById(ctx, id)
+	if err != nil {
+		return prod, err
+	}
+	return db.updateProduct(ctx, prod, inputProd, expected)
+}
+
+func (db *sqlite3DB) updateProduct(ctx context.Context, prod *models.Product, inputProd models.InputProduct, expected *models.Product) (*models.Product, error) {
+	if expected == nil {
+		expected = prod
+	} else if expected.Id != prod.Id {
+		return nil, ProductChangedError
+	}
+	// Products created before reserving SKUs keep them
+	if inputProd.SKU != prod.SKU && models.ReservedSKU(inputProd.SKU) {
+		return nil, ReservedSKUError
+	}
+	parentId, err := db.resolveParent(ctx, &inputProd)
 	if err != nil {
 		return nil, err
 	}
-	products := make([]*models.Product, 0)
-	for i := 0; rows.Next(); i++ {
-		var product models.Product
-		rows.Scan(&product.Id, &product.SKU, &product.Name, &product.Type, &product.Cost)
-		products = append(products, &product)
+	if parentId.Valid {
+		var variantsCount int
+		if err = db.QueryRowContext(ctx, sqlQueries["getVariantsCount"], prod.Id).Scan(&variantsCount); err != nil {
+			return nil, err
+		}
+		if variantsCount > 0 || parentId.Int64 == prod.Id {
+			return nil, NestedVariantError
+		}
 	}
-	return products, nil
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx, sqlQueries["updateProduct"], inputProd.SKU, inputProd.Name, inputProd.Type, inputProd.Cost, inputProd.Description, parentId,
+		prod.Id, expected.SKU, expected.Name, expected.Type, expected.Cost, expected.Description, db.tenant, expected.ParentSKU)
+	if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == 2067 {
+		// Error code 2067 means UNIQUE constraint failed (https://www.sqlite.org/rescode.html#constraint_unique)
+		tx.Rollback()
+		prod, _ = db.GetProductBySKU(ctx, inputProd.SKU)
+		return prod, ProductAlreadyExistsError
+	} else if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		tx.Rollback()
+		return nil, err
+	} else if count == 0 {
+		tx.Rollback()
+		return nil, ProductChangedError
+	}
+	// Attributes are compared after the update, so they can not be changed by another request until the commit
+	if attributes, err := queryAttributes(ctx, tx, prod.Id); err != nil {
+		tx.Rollback()
+		return nil, err
+	} else if !models.EqualAttributes(attributes, expected.Attributes) {
+		tx.Rollback()
+		return nil, ProductChangedError
+	}
+	// Variants inherit name and type of the parent product
+	if _, err = tx.ExecContext(ctx, sqlQueries["updateVariants"], inputProd.Name, inputProd.Type, prod.Id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, sqlQueries["deleteProductAttributes"], prod.Id); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = insertAttributes(ctx, tx, prod.Id, inputProd.Attributes); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	// The product and its variants are validated after writing them, so the schema is not changed until the commit
+	if err = db.validateAttributes(ctx, tx, inputProd); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if inputProd.Type != prod.Type {
+		if err = db.validateVariants(ctx, tx, prod.Id, inputProd.Type); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	db.suggestions.clear()
+	return &models.Product{InputProduct: inputProd, Id: prod.Id, Status: prod.Status, Schedule: prod.Schedule, Images: prod.Images}, nil
 }
 
-func (db *sqlite3DB) GetProductBySKU(SKU string) (*models.Product, error) {
-	var product models.Product
-	err := db.QueryRow(sqlQueries["getProductBySKU"], SKU).Scan(&product.Id, &product.SKU, &product.Name, &product.Type, &product.Cost)
+// resolveParent checks the parent of the product and copies inherited fields from it.
+// Returns id of the parent or NULL if product is not a variant.
+func (db *sqlite3DB) resolveParent(ctx context.Context, product *models.InputProduct) (sql.NullInt64, error) {
+	if product.ParentSKU == "" {
+		return sql.NullInt64{}, nil
+	}
+	parent, err := db.GetProductBySKU(ctx, product.ParentSKU)
+	if err == ProductNotFoundError {
+		return sql.NullInt64{}, ParentProductNotFoundError
+	} else if err != nil {
+		return sql.NullInt64{}, err
+	} else if parent.ParentSKU != "" {
+		return sql.NullInt64{}, NestedVariantError
+	}
+	product.Name = parent.Name
+	product.Type = parent.Type
+	return sql.NullInt64{Int64: parent.Id, Valid: true}, nil
+}
+
+func (db *sqlite3DB) queryProduct(ctx context.Context, query string, args ...interface{}) (*models.Product, error) {
+	product, err := scanProduct(db.QueryRowContext(ctx, query, args...))
 	if err == sql.ErrNoRows {
 		return nil, ProductNotFoundError
 	} else if err != nil {
 		return nil, err
 	}
-	return &product, nil
+	if err = db.loadDetails(ctx, product); err != nil {
+		return nil, err
+	}
+	return product, nil
 }
 
-func (db *sqlite3DB) GetProductById(id int64) (*models.Product, error) {
+func (db *sqlite3DB) queryProducts(ctx context.Context, query string, args ...interface{}) ([]*models.Product, error) {
+	products, err := db.scanProducts(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.loadDetails(ctx, products...); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (db *sqlite3DB) scanProducts(ctx context.Context, query string, args ...interface{}) ([]*models.Product, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	products := make([]*models.Product, 0)
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
+func scanProduct(row rowScanner) (*models.Product, error) {
 	var product models.Product
-	err := db.QueryRow(sqlQueries["getProductById"], id).Scan(&product.Id, &product.SKU, &product.Name, &product.Type, &product.Cost)
-	if err == sql.ErrNoRows {
-		return nil, ProductNotFoundError
-	} else if err != nil {
+	var publishAt, unpublishAt sql.NullInt64
+	err := row.Scan(&product.Id, &product.SKU, &product.Name, &product.Type, &product.Cost, &product.Description, &product.ParentSKU,
+		&product.Status, &publishAt, &unpublishAt)
+	if err != nil {
 		return nil, err
 	}
+	product.PublishAt, product.UnpublishAt = timeFromUnix(publishAt), timeFromUnix(unpublishAt)
 	return &product, nil
 }
 
-func (db *sqlite3DB) DeleteProductById(id int64) error {
-	_, err := db.GetProductById(id)
+// detailsQueries load details of pages of products, placeholders of their ids are substituted for %s,
+// so unlike sqlQueries they are not prepared on opening the database
+var detailsQueries = map[string]string{
+	"getAttributes": "SELECT productId, name, value FROM ProductAttributes WHERE productId IN (%s)",
+	"getImages": `
+	SELECT productId, id, url, thumbnailUrl, blobKey, thumbnailKey FROM ProductImages WHERE productId IN (%s)
+	ORDER BY productId, position, id`,
+}
+
+// maxDetailsBatch is the maximal number of products, which details are loaded by one query,
+// it keeps the number of query parameters below the limit of SQLite
+const maxDetailsBatch = 500
+
+// loadDetails loads attributes and images of the products with one query of each per batch of products
+func (db *sqlite3DB) loadDetails(ctx context.Context, products ...*models.Product) error {
+	for start := 0; start < len(products); start += maxDetailsBatch {
+		end := start + maxDetailsBatch
+		if end > len(products) {
+			end = len(products)
+		}
+		if err := db.loadDetailsBatch(ctx, products[start:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *sqlite3DB) loadDetailsBatch(ctx context.Context, products []*models.Product) error {
+	byId := make(map[int64]*models.Product, len(products))
+	ids := make([]interface{}, 0, len(products))
+	for _, product := range products {
+		byId[product.Id] = product
+		ids = append(ids, product.Id)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+
+	rows, err := db.QueryContext(ctx, fmt.Sprintf(detailsQueries["getAttributes"], placeholders), ids...)
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec(sqlQueries["deleteProductById"], id)
-	return err
-}
+	defer rows.Close()
+	for rows.Next() {
+		var productId int64
+		var name, value string
+		if err = rows.Scan(&productId, &name, &value); err != nil {
+			return err
+		}
+		product := byId[productId]
+		if product.Attributes == nil {
+			product.Attributes = make(map[string]string)
+		}
+		product.Attributes[name] = value
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
 
-func (db *sqlite3DB) DeleteProductBySKU(SKU string) error {
-	_, err := db.GetProductBySKU(SKU)
+	rows, err = db.QueryContext(ctx, fmt.Sprintf(detailsQueries["getImages"], placeholders), ids...)
 	if err != nil {
 		return err
 	}
-	_, err = db.Exec(sqlQueries["deleteProductBySKU"], SKU)
-	return err
+	defer rows.Close()
+	for rows.Next() {
+		var productId int64
+		var image models.Image
+		if err = rows.Scan(&productId, &image.Id, &image.URL, &image.ThumbnailURL, &image.Key, &image.ThumbnailKey); err != nil {
+			return err
+		}
+		product := byId[productId]
+		product.Images = append(product.Images, &image)
+	}
+	return rows.Err()
 }
 
-func (db *sqlite3DB) UpdateProductBySKU(SKU string, inputProd models.InputProduct) (*models.Product, error) {
-	prod, err := db.GetProductBySKU(SKU)
+// queryAttributes returns attributes of the product or nil if it has no attributes
+func queryAttributes(ctx context.Context, q queryer, productId int64) (map[string]string, error) {
+	rows, err := q.QueryContext(ctx, sqlQueries["getProductAttributes"], productId)
 	if err != nil {
-		return prod, err
+		return nil, err
 	}
-	_, err = db.Exec(sqlQueries["updateProductBySKU"], inputProd.SKU, inputProd.Name, inputProd.Type, inputProd.Cost, SKU)
-	if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == 2067 {
-		// Error code 2067 means UNIQUE constraint failed (https://www.sqlite.org/rescode.html#constraint_unique)
-		prod, _ = db.GetProductBySKU(inputProd.SKU)
-		return prod, ProductAlreadyExistsError
+	defer rows.Close()
+	var attributes map[string]string
+	for rows.Next() {
+		var name, value string
+		if err = rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+		attributes[name] = value
 	}
-	return &models.Product{inputProd, prod.Id}, err
+	return attributes, rows.Err()
 }
 
-func (db *sqlite3DB) UpdateProductById(id int64, inputProd models.InputProduct) (*models.Product, error) {
-	prod, err := db.GetProductById(id)
-	if err != nil {
-		return prod, err
+func insertAttributes(ctx context.Context, tx *sql.Tx, productId int64, attributes map[string]string) error {
+	for name, value := range attributes {
+		if _, err := tx.ExecContext(ctx, sqlQueries["insertProductAttribute"], productId, name, value); err != nil {
+			return err
+		}
 	}
-	_, err = db.Exec(sqlQueries["updateProductById"], inputProd.SKU, inputProd.Name, inputProd.Type, inputProd.Cost, id)
-	if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == 2067 {
-		// Error code 2067 means UNIQUE constraint failed (https://www.sqlite.org/rescode.html#constraint_unique)
-		prod, _ = db.GetProductBySKU(inputProd.SKU)
-		return prod, ProductAlreadyExistsError
+	return nil
+}
+
+// filterCondition returns WHERE clause of a query for selection of the tenant products and its arguments
+func (db *sqlite3DB) filterCondition(filter models.ProductFilter) (string, []interface{}) {
+	conditions, args := db.filterConditions(filter)
+	return whereClause(conditions), args
+}
+
+func (db *sqlite3DB) filterConditions(filter models.ProductFilter) ([]string, []interface{}) {
+	conditions := []string{"p.tenant=?"}
+	args := []interface{}{db.tenant}
+
+	if len(filter.Statuses) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Statuses)), ", ")
+		conditions = append(conditions, "p.status IN ("+placeholders+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "p.type=?")
+		args = append(args, filter.Type)
+	}
+	if filter.Category != "" && filter.IncludeSubcategories {
+		conditions = append(conditions, `p.id IN (
+			WITH RECURSIVE subcategories(id) AS (
+				SELECT id FROM Categories WHERE name=? AND tenant=?
+				UNION SELECT c.id FROM Categories c JOIN subcategories s ON c.parentId=s.id
+			)
+			SELECT pc.productId FROM ProductCategories pc JOIN subcategories s ON pc.categoryId=s.id
+		)`)
+		args = append(args, filter.Category, db.tenant)
+	} else if filter.Category != "" {
+		conditions = append(conditions, `p.id IN (
+			SELECT pc.productId FROM ProductCategories pc JOIN Categories c ON pc.categoryId=c.id WHERE c.name=? AND c.tenant=?
+		)`)
+		args = append(args, filter.Category, db.tenant)
+	}
+	if tags := normalizeTags(filter.Tags); len(tags) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tags)), ", ")
+		condition := "p.id IN (SELECT productId FROM ProductTags WHERE tag IN (" + placeholders + ")"
+		if !filter.AnyTag {
+			condition += " GROUP BY productId HAVING COUNT(*)=" + strconv.Itoa(len(tags))
+		}
+		conditions = append(conditions, condition+")")
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+	}
+	for _, name := range sortedKeys(filter.Attributes) {
+		conditions = append(conditions, "p.id IN (SELECT productId FROM ProductAttributes WHERE name=? AND value=?)")
+		args = append(args, name, filter.Attributes[name])
+	}
+	for _, bound := range []struct {
+		values   map[string]float64
+		operator string
+	}{{filter.AttributesMin, ">="}, {filter.AttributesMax, "<="}} {
+		names := make([]string, 0, len(bound.values))
+		for name := range bound.values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			conditions = append(conditions,
+				"p.id IN (SELECT productId FROM ProductAttributes WHERE name=? AND CAST(value AS REAL)"+bound.operator+"?)")
+			args = append(args, name, bound.values[name])
+		}
+	}
+
+	return conditions, args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
-	return &models.Product{inputProd, prod.Id}, err
+	sort.Strings(keys)
+	return keys
 }
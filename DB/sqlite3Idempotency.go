@@ -0,0 +1,67 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+func (db *sqlite3DB) StartIdempotentRequest(ctx context.Context, client string, key string, requestHash string, abandonedBefore time.Time, expiredBefore time.Time) (*models.IdempotentResponse, error) {
+	if _, err := db.ExecContext(ctx, sqlQueries["deleteExpiredIdempotencyKeys"], expiredBefore.Unix()); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	res, err := db.ExecContext(ctx, sqlQueries["insertIdempotencyKey"], db.tenant, client, key, requestHash, now, now)
+	if err != nil {
+		return nil, err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if count == 1 {
+		return nil, nil
+	}
+	var response models.IdempotentResponse
+	var statusCode sql.NullInt64
+	var contentType, location sql.NullString
+	var createdAt, lockedAt int64
+	err = db.QueryRowContext(ctx, sqlQueries["getIdempotentResponse"], db.tenant, client, key).
+		Scan(&response.RequestHash, &response.Completed, &statusCode, &contentType, &location, &response.Body, &createdAt, &lockedAt)
+	if err == sql.ErrNoRows {
+		// The key is released by the first request after the insertion attempt
+		return db.StartIdempotentRequest(ctx, client, key, requestHash, abandonedBefore, expiredBefore)
+	} else if err != nil {
+		return nil, err
+	}
+	if response.RequestHash != requestHash {
+		return nil, IdempotencyKeyReusedError
+	} else if !response.Completed && lockedAt >= abandonedBefore.Unix() {
+		return nil, IdempotentRequestInProgressError
+	} else if !response.Completed {
+		// Only one of concurrent retries takes the key of the abandoned request
+		res, err := db.ExecContext(ctx, sqlQueries["relockIdempotencyKey"], now, db.tenant, client, key, lockedAt)
+		if err != nil {
+			return nil, err
+		} else if count, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if count == 0 {
+			return nil, IdempotentRequestInProgressError
+		}
+		return nil, nil
+	}
+	response.StatusCode = int(statusCode.Int64)
+	response.ContentType = contentType.String
+	response.Location = location.String
+	response.CreatedAt = time.Unix(createdAt, 0).UTC()
+	return &response, nil
+}
+
+func (db *sqlite3DB) SaveIdempotentResponse(ctx context.Context, client string, key string, response models.IdempotentResponse) error {
+	_, err := db.ExecContext(ctx, sqlQueries["updateIdempotentResponse"], response.StatusCode, response.ContentType, response.Location, response.Body, db.tenant, client, key)
+	return err
+}
+
+func (db *sqlite3DB) DeleteIdempotencyKey(ctx context.Context, client string, key string) error {
+	_, err := db.ExecContext(ctx, sqlQueries["deleteIdempotencyKey"], db.tenant, client, key)
+	return err
+}
@@ -0,0 +1,101 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+)
+
+func (db *sqlite3DB) GetProductImages(ctx context.Context, SKU string) ([]*models.Image, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	return db.queryImages(ctx, prod.Id)
+}
+
+// AddProductImage adds the image of the product after its other images
+func (db *sqlite3DB) AddProductImage(ctx context.Context, SKU string, image models.Image) (*models.Image, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	res, err := db.ExecContext(ctx, sqlQueries["insertProductImage"], prod.Id, image.URL, image.ThumbnailURL, image.Key, image.ThumbnailKey, prod.Id)
+	if err != nil {
+		return nil, err
+	}
+	if image.Id, err = res.LastInsertId(); err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ReorderProductImages sets the order of the product images to the order of their ids
+func (db *sqlite3DB) ReorderProductImages(ctx context.Context, SKU string, ids []int64) ([]*models.Image, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) != len(prod.Images) {
+		return nil, InvalidImageOrderError
+	}
+	byId := make(map[int64]*models.Image, len(prod.Images))
+	for _, image := range prod.Images {
+		byId[image.Id] = image
+	}
+	reordered := make([]*models.Image, 0, len(ids))
+	for _, id := range ids {
+		image, ok := byId[id]
+		if !ok {
+			return nil, InvalidImageOrderError
+		}
+		delete(byId, id)
+		reordered = append(reordered, image)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	for position, id := range ids {
+		if _, err = tx.ExecContext(ctx, sqlQueries["updateProductImagePosition"], position+1, id, prod.Id); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return reordered, nil
+}
+
+// DeleteProductImage deletes the image of the product and returns it, so its files can be removed from blob storage
+func (db *sqlite3DB) DeleteProductImage(ctx context.Context, SKU string, id int64) (*models.Image, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range prod.Images {
+		if image.Id == id {
+			_, err = db.ExecContext(ctx, sqlQueries["deleteProductImage"], id, prod.Id)
+			return image, err
+		}
+	}
+	return nil, ImageNotFoundError
+}
+
+func (db *sqlite3DB) queryImages(ctx context.Context, productId int64) ([]*models.Image, error) {
+	rows, err := db.QueryContext(ctx, sqlQueries["getProductImages"], productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	images := make([]*models.Image, 0)
+	for rows.Next() {
+		var image models.Image
+		if err = rows.Scan(&image.Id, &image.URL, &image.ThumbnailURL, &image.Key, &image.ThumbnailKey); err != nil {
+			return nil, err
+		}
+		images = append(images, &image)
+	}
+	return images, rows.Err()
+}
@@ -0,0 +1,142 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SetProductSchedule replaces times of automatic publishing and unpublishing of the product.
+// Only drafts can be scheduled for publishing and only published or scheduled for publishing products can be
+// scheduled for unpublishing. Times are stored with precision of seconds.
+func (db *sqlite3DB) SetProductSchedule(ctx context.Context, SKU string, schedule models.Schedule) (*models.Product, error) {
+	schedule.PublishAt, schedule.UnpublishAt = truncateTime(schedule.PublishAt), truncateTime(schedule.UnpublishAt)
+	if schedule.PublishAt != nil && schedule.UnpublishAt != nil && !schedule.UnpublishAt.After(*schedule.PublishAt) {
+		return nil, InvalidScheduleError
+	}
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.PublishAt != nil && prod.Status != models.DraftStatus {
+		return nil, fmt.Errorf("%w: only drafts can be scheduled for publishing", StatusTransitionError)
+	} else if schedule.UnpublishAt != nil && schedule.PublishAt == nil && prod.Status != models.PublishedStatus {
+		return nil, fmt.Errorf("%w: only published products can be scheduled for unpublishing", StatusTransitionError)
+	}
+	_, err = db.ExecContext(ctx, sqlQueries["updateProductSchedule"], unixFromTime(schedule.PublishAt), unixFromTime(schedule.UnpublishAt), prod.Id)
+	if err != nil {
+		return nil, err
+	}
+	db.suggestions.clear()
+	prod.Schedule = schedule
+	return prod, nil
+}
+
+func (db *sqlite3DB) GetScheduledChanges(ctx context.Context) ([]*models.ScheduledChange, error) {
+	rows, err := db.QueryContext(ctx, sqlQueries["getScheduledChanges"], db.tenant, db.tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	changes := make([]*models.ScheduledChange, 0)
+	for rows.Next() {
+		var change models.ScheduledChange
+		var at int64
+		if err = rows.Scan(&change.SKU, &change.Status, &at); err != nil {
+			return nil, err
+		}
+		change.At = time.Unix(at, 0).UTC()
+		changes = append(changes, &change)
+	}
+	return changes, rows.Err()
+}
+
+// ApplyScheduledChanges publishes and then unpublishes products of all tenants in one transaction,
+// so a product scheduled for both before now ends up unpublished
+func (db *sqlite3DB) ApplyScheduledChanges(ctx context.Context, now time.Time) (time.Time, []string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	tenants, err := scheduledTenants(ctx, tx, now)
+	if err != nil {
+		tx.Rollback()
+		return time.Time{}, nil, err
+	}
+	var changed int64
+	for _, query := range []string{"publishScheduledProducts", "unpublishScheduledProducts"} {
+		res, err := tx.ExecContext(ctx, sqlQueries[query], now.Unix())
+		if err != nil {
+			tx.Rollback()
+			return time.Time{}, nil, err
+		}
+		count, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return time.Time{}, nil, err
+		}
+		changed += count
+	}
+	var next sql.NullInt64
+	if err = tx.QueryRowContext(ctx, sqlQueries["getNextScheduledTime"]).Scan(&next); err != nil {
+		tx.Rollback()
+		return time.Time{}, nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return time.Time{}, nil, err
+	}
+	if changed > 0 {
+		db.suggestions.clear()
+		db.logger.Info("scheduled changes are applied", "products", changed)
+	}
+	if !next.Valid {
+		return time.Time{}, tenants, nil
+	}
+	return time.Unix(next.Int64, 0), tenants, nil
+}
+
+// scheduledTenants returns tenants of products with scheduled changes before now
+func scheduledTenants(ctx context.Context, tx *sql.Tx, now time.Time) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, sqlQueries["getScheduledTenants"], now.Unix(), now.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tenants []string
+	for rows.Next() {
+		var tenant string
+		if err := rows.Scan(&tenant); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, rows.Err()
+}
+
+// truncateTime drops fractions of a second, which are not stored
+func truncateTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	truncated := time.Unix(t.Unix(), 0).UTC()
+	return &truncated
+}
+
+// unixFromTime converts time to Unix seconds or NULL if it is nil
+func unixFromTime(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: t.Unix(), Valid: true}
+}
+
+// timeFromUnix converts Unix seconds to UTC time or nil if they are NULL
+func timeFromUnix(unix sql.NullInt64) *time.Time {
+	if !unix.Valid {
+		return nil
+	}
+	t := time.Unix(unix.Int64, 0).UTC()
+	return &t
+}
@@ -0,0 +1,198 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+const highlightStart = "<mark>"
+const highlightEnd = "</mark>"
+
+// FTS5 encloses matches in control characters, they are replaced with highlight marks after escaping the text
+const matchStart = "\x02"
+const matchEnd = "\x03"
+
+var matchMarks = strings.NewReplacer(matchStart, highlightStart, matchEnd, highlightEnd)
+
+// Full text search queries can be prepared only if SQLite is built with FTS5 extension
+// (go-sqlite3 requires sqlite_fts5 build tag for this)
+var fullTextSearchQueries = map[string]string{
+	// ProductsSearch is an external content table, its content is stored in Products table.
+	// It is kept up to date with triggers and rebuilt on start,
+	// because Products could be changed while the database was used without FTS5.
+	"init": `
+	CREATE VIRTUAL TABLE IF NOT EXISTS ProductsSearch USING fts5(name, description, content='Products', content_rowid='id');
+	CREATE TRIGGER IF NOT EXISTS ProductsSearchInsert AFTER INSERT ON Products BEGIN
+		INSERT INTO ProductsSearch(rowid, name, description) VALUES(new.id, new.name, new.description);
+	END;
+	CREATE TRIGGER IF NOT EXISTS ProductsSearchDelete AFTER DELETE ON Products BEGIN
+		INSERT INTO ProductsSearch(ProductsSearch, rowid, name, description) VALUES('delete', old.id, old.name, old.description);
+	END;
+	CREATE TRIGGER IF NOT EXISTS ProductsSearchUpdate AFTER UPDATE ON Products BEGIN
+		INSERT INTO ProductsSearch(ProductsSearch, rowid, name, description) VALUES('delete', old.id, old.name, old.description);
+		INSERT INTO ProductsSearch(rowid, name, description) VALUES(new.id, new.name, new.description);
+	END;
+	INSERT INTO ProductsSearch(ProductsSearch) VALUES('rebuild');`,
+	// Triggers must be dropped if FTS5 is unavailable, otherwise they fail every change of Products
+	"dropTriggers": `
+	DROP TRIGGER IF EXISTS ProductsSearchInsert;
+	DROP TRIGGER IF EXISTS ProductsSearchDelete;
+	DROP TRIGGER IF EXISTS ProductsSearchUpdate;`,
+	"searchProducts": `
+	SELECT ` + productColumns + `,
+		highlight(ProductsSearch, 0, char(2), char(3)),
+		highlight(ProductsSearch, 1, char(2), char(3))
+	FROM ProductsSearch JOIN Products p ON p.id=ProductsSearch.rowid LEFT JOIN Products parent ON p.parentId=parent.id`,
+}
+
+func (db *sqlite3DB) initFullTextSearch() error {
+	_, err := db.Exec(fullTextSearchQueries["init"])
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		_, err = db.Exec(fullTextSearchQueries["dropTriggers"])
+		return err
+	} else if err != nil {
+		return fmt.Errorf("full text search init error: %v", err)
+	}
+	if _, err = db.Prepare(fullTextSearchQueries["searchProducts"]); err != nil {
+		return fmt.Errorf("sql query prepare error: %v\n%s", err, fullTextSearchQueries["searchProducts"])
+	}
+	db.fullTextSearch = true
+	return nil
+}
+
+// SearchProducts returns products with names or descriptions containing words starting with words of the query.
+// Results are ordered by relevance, all of them are returned if groupSize is 0.
+func (db *sqlite3DB) SearchProducts(ctx context.Context, query string, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.SearchResult, error) {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return make([]*models.SearchResult, 0), nil
+	}
+	conditions, filterArgs := db.filterConditions(filter)
+	var sqlQuery string
+	var args []interface{}
+	if db.fullTextSearch {
+		sqlQuery, args = fullTextSearchQuery(terms, conditions, filterArgs)
+	} else {
+		sqlQuery, args = likeSearchQuery(terms, conditions, filterArgs)
+	}
+	if groupSize > 0 {
+		sqlQuery += " LIMIT ? OFFSET ?"
+		args = append(args, groupSize, (groupNum-1)*groupSize)
+	}
+
+	rows, err := db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	highlighter := termsHighlighter(terms)
+	results := make([]*models.SearchResult, 0)
+	for rows.Next() {
+		var result models.SearchResult
+		prod := &result.Product
+		var publishAt, unpublishAt sql.NullInt64
+		dest := []interface{}{&prod.Id, &prod.SKU, &prod.Name, &prod.Type, &prod.Cost, &prod.Description, &prod.ParentSKU,
+			&prod.Status, &publishAt, &unpublishAt}
+		if db.fullTextSearch {
+			dest = append(dest, &result.HighlightedName, &result.HighlightedDescription)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		prod.PublishAt, prod.UnpublishAt = timeFromUnix(publishAt), timeFromUnix(unpublishAt)
+		if db.fullTextSearch {
+			result.HighlightedName = escapeMatches(result.HighlightedName)
+			result.HighlightedDescription = escapeMatches(result.HighlightedDescription)
+		} else {
+			result.HighlightedName = highlightTerms(highlighter, prod.Name)
+			result.HighlightedDescription = highlightTerms(highlighter, prod.Description)
+		}
+		results = append(results, &result)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	products := make([]*models.Product, len(results))
+	for i, result := range results {
+		products[i] = &result.Product
+	}
+	if err = db.loadDetails(ctx, products...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// fullTextSearchQuery makes FTS5 query matching tokens starting with every of the terms
+func fullTextSearchQuery(terms []string, conditions []string, args []interface{}) (string, []interface{}) {
+	matchTerms := make([]string, len(terms))
+	for i, term := range terms {
+		matchTerms[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"*`
+	}
+	conditions = append([]string{"ProductsSearch MATCH ?"}, conditions...)
+	// Matches in the name are more relevant than in the description
+	query := fullTextSearchQueries["searchProducts"] + whereClause(conditions) + " ORDER BY bm25(ProductsSearch, 10.0, 1.0), p.id"
+	return query, append([]interface{}{strings.Join(matchTerms, " ")}, args...)
+}
+
+// likeSearchQuery makes query similar to the full text search one with LIKE operator,
+// it is used if FTS5 is unavailable
+func likeSearchQuery(terms []string, conditions []string, args []interface{}) (string, []interface{}) {
+	var searchConditions, nameMatches []string
+	var searchArgs, orderArgs []interface{}
+	for _, term := range terms {
+		searchConditions = append(searchConditions, `(
+			p.name LIKE ? ESCAPE '\' OR p.name LIKE ? ESCAPE '\' OR
+			p.description LIKE ? ESCAPE '\' OR p.description LIKE ? ESCAPE '\')`)
+		nameMatches = append(nameMatches, `(p.name LIKE ? ESCAPE '\' OR p.name LIKE ? ESCAPE '\')`)
+		prefix, wordPrefix := likePatterns(term)
+		searchArgs = append(searchArgs, prefix, wordPrefix, prefix, wordPrefix)
+		orderArgs = append(orderArgs, prefix, wordPrefix)
+	}
+	// Products are ordered by the number of terms found in their names
+	query := sqlQueries["getAllProducts"] + whereClause(append(searchConditions, conditions...)) +
+		" ORDER BY " + strings.Join(nameMatches, " + ") + " DESC, p.id"
+	args = append(append(searchArgs, args...), orderArgs...)
+	return query, args
+}
+
+// likePatterns returns LIKE patterns matching strings starting with the term and containing a word starting with it
+func likePatterns(term string) (string, string) {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(term)
+	return escaped + "%", "% " + escaped + "%"
+}
+
+// escapeMatches escapes HTML of the text highlighted by FTS5 and replaces match marks with highlight marks.
+// Control characters of the product data can only produce unpaired marks.
+func escapeMatches(text string) string {
+	return matchMarks.Replace(html.EscapeString(text))
+}
+
+// highlightTerms escapes HTML of the text and encloses words matched by the highlighter in highlight marks
+func highlightTerms(highlighter *regexp.Regexp, text string) string {
+	var highlighted strings.Builder
+	last := 0
+	for _, match := range highlighter.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[4], match[5]
+		highlighted.WriteString(html.EscapeString(text[last:start]))
+		highlighted.WriteString(highlightStart + html.EscapeString(text[start:end]) + highlightEnd)
+		last = end
+	}
+	highlighted.WriteString(html.EscapeString(text[last:]))
+	return highlighted.String()
+}
+
+// termsHighlighter returns regexp matching words starting with any of the terms,
+// the second group of matches is the word highlighted by highlightTerms
+func termsHighlighter(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = regexp.QuoteMeta(term)
+	}
+	return regexp.MustCompile(`(?i)(^|[^\p{L}\p{N}])((?:` + strings.Join(quoted, "|") + `)[\p{L}\p{N}]*)`)
+}
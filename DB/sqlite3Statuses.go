@@ -0,0 +1,34 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"fmt"
+)
+
+// SetProductStatus moves the product to the status if the transition from its current status is allowed
+func (db *sqlite3DB) SetProductStatus(ctx context.Context, SKU string, status string) (*models.Product, error) {
+	if !models.ValidStatus(status) {
+		return nil, InvalidStatusError
+	}
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanTransition(prod.Status, status) {
+		return nil, fmt.Errorf("%w: from %s to %s", StatusTransitionError, prod.Status, status)
+	}
+	// The status is changed only if it is not changed by another request since reading
+	res, err := db.ExecContext(ctx, sqlQueries["updateProductStatus"], status, prod.Id, prod.Status)
+	if err != nil {
+		return nil, err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if count == 0 {
+		return nil, fmt.Errorf("%w: it has been changed concurrently", StatusTransitionError)
+	}
+	db.suggestions.clear()
+	prod.Status = status
+	return prod, nil
+}
@@ -0,0 +1,40 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"strings"
+)
+
+// SuggestProducts returns up to limit products with SKU or name starting with the prefix (case insensitive).
+// Names are translated to the first of the normalized locales for which a product has a translation,
+// and the prefix is matched against translated names. Products with matching SKU go first,
+// then products with shorter names.
+func (db *sqlite3DB) SuggestProducts(ctx context.Context, prefix string, limit uint, locales []string) ([]*models.Suggestion, error) {
+	// Valid locales contain no commas
+	chain := "," + strings.Join(locales, ",") + ","
+	key := suggestionKey{db.tenant, strings.ToLower(prefix), limit, chain}
+	suggestions, generation, ok := db.suggestions.get(key)
+	if ok {
+		return suggestions, nil
+	}
+	pattern, _ := likePatterns(prefix)
+	rows, err := db.QueryContext(ctx, sqlQueries["suggestProducts"], chain, chain, db.tenant, pattern, pattern, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	suggestions = make([]*models.Suggestion, 0, limit)
+	for rows.Next() {
+		var suggestion models.Suggestion
+		if err = rows.Scan(&suggestion.SKU, &suggestion.Name); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, &suggestion)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	db.suggestions.put(key, suggestions, generation)
+	return suggestions, nil
+}
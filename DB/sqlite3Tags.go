@@ -0,0 +1,88 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+)
+
+func (db *sqlite3DB) GetAllTags(ctx context.Context) ([]*models.TagCount, error) {
+	rows, err := db.QueryContext(ctx, sqlQueries["getAllTags"], db.tenant)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := make([]*models.TagCount, 0)
+	for rows.Next() {
+		var tag models.TagCount
+		if err = rows.Scan(&tag.Tag, &tag.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, &tag)
+	}
+	return tags, rows.Err()
+}
+
+func (db *sqlite3DB) GetProductTags(ctx context.Context, SKU string) ([]string, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.QueryContext(ctx, sqlQueries["getProductTags"], prod.Id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := make([]string, 0)
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (db *sqlite3DB) AddProductTag(ctx context.Context, SKU string, tag string) error {
+	tag = models.NormalizeTag(tag)
+	if tag == "" {
+		return InvalidTagError
+	}
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sqlQueries["insertProductTag"], prod.Id, tag)
+	return err
+}
+
+func (db *sqlite3DB) RemoveProductTag(ctx context.Context, SKU string, tag string) error {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, sqlQueries["deleteProductTag"], prod.Id, models.NormalizeTag(tag))
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return TagNotFoundError
+	}
+	return nil
+}
+
+// normalizeTags returns normalized unique non-empty tags
+func normalizeTags(tags []string) []string {
+	unique := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = models.NormalizeTag(tag)
+		if tag != "" && !unique[tag] {
+			unique[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
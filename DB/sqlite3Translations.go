@@ -0,0 +1,108 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+)
+
+func (db *sqlite3DB) GetProductTranslations(ctx context.Context, SKU string) ([]*models.Translation, error) {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return nil, err
+	}
+	return db.queryTranslations(ctx, prod.Id)
+}
+
+// SetProductTranslation adds the translation of the product or replaces the existing one for the same locale
+func (db *sqlite3DB) SetProductTranslation(ctx context.Context, SKU string, translation models.Translation) error {
+	translation.Locale = models.NormalizeLocale(translation.Locale)
+	if !models.ValidLocale(translation.Locale) {
+		return InvalidLocaleError
+	} else if translation.Name == "" {
+		return InvalidTranslationError
+	}
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, sqlQueries["insertProductTranslation"], prod.Id, translation.Locale, translation.Name, translation.Description)
+	return err
+}
+
+func (db *sqlite3DB) DeleteProductTranslation(ctx context.Context, SKU string, locale string) error {
+	prod, err := db.GetProductBySKU(ctx, SKU)
+	if err != nil {
+		return err
+	}
+	res, err := db.ExecContext(ctx, sqlQueries["deleteProductTranslation"], prod.Id, models.NormalizeLocale(locale))
+	if err != nil {
+		return err
+	}
+	if count, err := res.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return TranslationNotFoundError
+	}
+	return nil
+}
+
+// LocalizeProducts replaces names and descriptions of the products with their translations.
+// The first of the normalized locales for which a product has a translation is used,
+// the product keeps its own name and description if there is no such translation.
+func (db *sqlite3DB) LocalizeProducts(ctx context.Context, products []*models.Product, locales []string) error {
+	if len(locales) == 0 {
+		return nil
+	}
+	for _, product := range products {
+		translations, err := db.queryTranslations(ctx, product.Id)
+		if err != nil {
+			return err
+		}
+		if len(translations) == 0 {
+			continue
+		}
+		byLocale := make(map[string]*models.Translation, len(translations))
+		for _, translation := range translations {
+			byLocale[translation.Locale] = translation
+		}
+		localizeProduct(product, byLocale, locales)
+	}
+	return nil
+}
+
+// localizeProduct takes the name and the description from the first translation having them
+func localizeProduct(product *models.Product, translations map[string]*models.Translation, locales []string) {
+	nameFound, descriptionFound := false, false
+	for _, locale := range locales {
+		translation, ok := translations[locale]
+		if !ok {
+			continue
+		}
+		if !nameFound {
+			product.Name, nameFound = translation.Name, true
+		}
+		if !descriptionFound && translation.Description != "" {
+			product.Description, descriptionFound = translation.Description, true
+		}
+		if descriptionFound {
+			return
+		}
+	}
+}
+
+func (db *sqlite3DB) queryTranslations(ctx context.Context, productId int64) ([]*models.Translation, error) {
+	rows, err := db.QueryContext(ctx, sqlQueries["getProductTranslations"], productId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	translations := make([]*models.Translation, 0)
+	for rows.Next() {
+		var translation models.Translation
+		if err = rows.Scan(&translation.Locale, &translation.Name, &translation.Description); err != nil {
+			return nil, err
+		}
+		translations = append(translations, &translation)
+	}
+	return translations, rows.Err()
+}
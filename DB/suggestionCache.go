@@ -0,0 +1,94 @@
+package DB
+
+import (
+	"XsollaSchoolBE/models"
+	"container/list"
+	"sync"
+	"time"
+)
+
+const suggestionCacheSize = 256
+const suggestionCacheTTL = time.Minute
+
+// suggestionCache is a LRU cache of suggestions for hot prefixes.
+// It must be cleared on every change of products, entries expire after TTL
+// in case the database is changed by another process.
+type suggestionCache struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[suggestionKey]*list.Element
+	// generation is increased by clear, suggestions read before that are not stored
+	generation uint64
+}
+
+type suggestionKey struct {
+	tenant string
+	prefix string
+	limit  uint
+	// locales is the comma separated chain of locales of names
+	locales string
+}
+
+type suggestionEntry struct {
+	key         suggestionKey
+	suggestions []*models.Suggestion
+	expiresAt   time.Time
+}
+
+func newSuggestionCache(size int, ttl time.Duration) *suggestionCache {
+	return &suggestionCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[suggestionKey]*list.Element),
+	}
+}
+
+// get returns cached suggestions, on a miss it returns the generation to store suggestions read after it
+func (cache *suggestionCache) get(key suggestionKey) ([]*models.Suggestion, uint64, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	element, ok := cache.entries[key]
+	if !ok {
+		return nil, cache.generation, false
+	}
+	entry := element.Value.(*suggestionEntry)
+	if time.Now().After(entry.expiresAt) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return nil, cache.generation, false
+	}
+	cache.order.MoveToFront(element)
+	return entry.suggestions, cache.generation, true
+}
+
+// put stores suggestions read at the generation, they are not stored if the cache is cleared since then
+func (cache *suggestionCache) put(key suggestionKey, suggestions []*models.Suggestion, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if generation != cache.generation {
+		return
+	}
+	entry := &suggestionEntry{key, suggestions, time.Now().Add(cache.ttl)}
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[key] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*suggestionEntry).key)
+	}
+}
+
+func (cache *suggestionCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	cache.order.Init()
+	cache.entries = make(map[suggestionKey]*list.Element)
+	cache.generation++
+}
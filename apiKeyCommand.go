@@ -0,0 +1,77 @@
+package main
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/config"
+	"XsollaSchoolBE/models"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+const apiKeyUsage = `usage:
+	XsollaSchoolBE apikey create [-config FILE] [-tenant TENANT] -name NAME -scopes read,write,admin
+	XsollaSchoolBE apikey list [-config FILE] [-tenant TENANT]
+	XsollaSchoolBE apikey revoke [-config FILE] [-tenant TENANT] -id ID`
+
+// runAPIKeyCommand manages API keys in the database without running the server,
+// so the first admin key can be created
+func runAPIKeyCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New(apiKeyUsage)
+	}
+	flags := flag.NewFlagSet("apikey "+args[0], flag.ContinueOnError)
+	name := flags.String("name", "", "name of the created key")
+	scopes := flags.String("scopes", models.ReadScope, "comma separated scopes of the created key")
+	id := flags.Int64("id", 0, "id of the revoked key")
+	tenant := flags.String("tenant", models.DefaultTenant, "tenant, which catalog the key gives access to")
+	// The database is selected with settings of the server
+	loader := config.NewLoader(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	} else if !models.ValidTenant(*tenant) {
+		return errors.New("tenant must consist of up to 63 lowercase latin letters, digits, hyphens and underscores")
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	rootDB, err := DB.Open(cfg.DB.Driver, cfg.DB.DSN)
+	if err != nil {
+		return err
+	}
+	defer rootDB.Close()
+	db := rootDB.WithTenant(*tenant)
+	switch args[0] {
+	case "create":
+		key, err := db.AddAPIKey(context.Background(), models.InputAPIKey{Name: *name, Scopes: strings.Split(*scopes, ",")})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Created key %d (%s) of tenant %s with scopes %s, it is shown only once:\n%s\n",
+			key.Id, key.Name, key.Tenant, strings.Join(key.Scopes, ","), key.Key)
+	case "list":
+		keys, err := db.GetAllAPIKeys(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			status := "active"
+			if key.RevokedAt != nil {
+				status = "revoked " + key.RevokedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%d\t%s\t%s\t%s\n", key.Id, key.Name, strings.Join(key.Scopes, ","), status)
+		}
+	case "revoke":
+		if err := db.RevokeAPIKey(context.Background(), *id); err != nil {
+			return err
+		}
+		fmt.Printf("Key %d is revoked\n", *id)
+	default:
+		return errors.New(apiKeyUsage)
+	}
+	return nil
+}
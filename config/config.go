@@ -0,0 +1,329 @@
+package config
+
+import (
+	"XsollaSchoolBE/jwtAuth"
+	"XsollaSchoolBE/models"
+	"XsollaSchoolBE/rateLimit"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Log levels, messages of lower levels are not written
+const (
+	DebugLevel = "debug"
+	InfoLevel  = "info"
+	WarnLevel  = "warn"
+	ErrorLevel = "error"
+)
+
+var logLevels = []string{DebugLevel, InfoLevel, WarnLevel, ErrorLevel}
+
+// Drivers are names of supported databases
+var Drivers = []string{"sqlite3"}
+
+// Minimum versions of TLS
+const (
+	TLS12 = "1.2"
+	TLS13 = "1.3"
+)
+
+var tlsVersions = []string{TLS12, TLS13}
+
+// Modes of verifying client certificates, OptionalClientAuth verifies certificates only if clients send them
+const (
+	NoClientAuth       = "none"
+	OptionalClientAuth = "optional"
+	RequiredClientAuth = "require"
+)
+
+var clientAuthModes = []string{NoClientAuth, OptionalClientAuth, RequiredClientAuth}
+
+// Exporters of spans, traces are not collected with NoExporter
+const (
+	NoExporter     = "none"
+	StdoutExporter = "stdout"
+	OTLPExporter   = "otlp"
+)
+
+var exporters = []string{NoExporter, StdoutExporter, OTLPExporter}
+
+// Config contains settings of the server and commands
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	DB        DBConfig        `yaml:"db"`
+	Log       LogConfig       `yaml:"log"`
+	TLS       TLSConfig       `yaml:"tls"`
+	CORS      CORSConfig      `yaml:"cors"`
+	JWT       jwtAuth.Config  `yaml:"jwt"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Cache     CacheConfig     `yaml:"cache"`
+}
+
+type ServerConfig struct {
+	// Address is a TCP address to listen on, port 0 selects a free port
+	Address string `yaml:"address"`
+	// MediaDir stores files of product images
+	MediaDir string `yaml:"mediaDir"`
+	// ReadTimeout limits reading of the whole request including the body
+	ReadTimeout  time.Duration `yaml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	// IdleTimeout limits waiting for the next request on keep-alive connections
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	// RequestTimeout is a deadline of database operations of a request, 0 disables it
+	RequestTimeout time.Duration `yaml:"requestTimeout"`
+	// ShutdownDelay is a time between failing readiness checks and closing the listener on shutdown,
+	// it lets load balancers notice that the server is stopping
+	ShutdownDelay time.Duration `yaml:"shutdownDelay"`
+	// ShutdownTimeout limits waiting for active requests on shutdown, they are cancelled after it
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	// TrustedProxies are addresses and networks like "10.0.0.0/8" of proxies, which are allowed to set
+	// the client address with X-Forwarded-For and X-Real-IP headers, the headers are ignored without them
+	TrustedProxies []string `yaml:"trustedProxies"`
+}
+
+type DBConfig struct {
+	Driver string `yaml:"driver"`
+	// DSN is a data source name of the driver, it is a file name for sqlite3
+	DSN string `yaml:"dsn"`
+}
+
+type LogConfig struct {
+	// Level is one of debug, info, warn and error, requests are logged on debug and info levels
+	Level string `yaml:"level"`
+}
+
+// TLSConfig enables HTTPS if both files are set, the files are loaded again when they are modified
+type TLSConfig struct {
+	CertFile   string `yaml:"certFile"`
+	KeyFile    string `yaml:"keyFile"`
+	MinVersion string `yaml:"minVersion"`
+	// ClientCAFile is a PEM file with certificates of authorities issuing certificates of internal clients
+	ClientCAFile string `yaml:"clientCAFile"`
+	ClientAuth   string `yaml:"clientAuth"`
+}
+
+// Enabled reports if the server must use HTTPS
+func (config TLSConfig) Enabled() bool {
+	return config.CertFile != "" || config.KeyFile != ""
+}
+
+// CORSConfig allows browsers to send requests from other origins, CORS is disabled without allowed origins
+type CORSConfig struct {
+	// AllowedOrigins are origins like "https://shop.example.com" or "*" for any origin
+	AllowedOrigins []string      `yaml:"allowedOrigins"`
+	AllowedMethods []string      `yaml:"allowedMethods"`
+	AllowedHeaders []string      `yaml:"allowedHeaders"`
+	MaxAge         time.Duration `yaml:"maxAge"`
+}
+
+// TracingConfig selects the exporter of spans of requests and database operations
+type TracingConfig struct {
+	// Exporter is one of none, stdout and otlp
+	Exporter string `yaml:"exporter"`
+	// Endpoint is a base URL of OTLP/HTTP collector like http://localhost:4318
+	Endpoint    string `yaml:"endpoint"`
+	ServiceName string `yaml:"serviceName"`
+	// SampleRatio is a share of traced requests without sampled parent spans from 0 to 1
+	SampleRatio float64 `yaml:"sampleRatio"`
+}
+
+// CacheConfig limits the cache of products and pages of products, it is disabled if Size or TTL is 0
+type CacheConfig struct {
+	// Size is the maximum number of cached results of all tenants
+	Size int `yaml:"size"`
+	// TTL limits the time of serving results changed by other processes
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Enabled reports if products are cached
+func (config CacheConfig) Enabled() bool {
+	return config.Size > 0 && config.TTL > 0
+}
+
+// RateLimitConfig contains limits in format of rateLimit.ParseLimit
+type RateLimitConfig struct {
+	Default string            `yaml:"default"`
+	Routes  map[string]string `yaml:"routes"`
+	Clients map[string]string `yaml:"clients"`
+}
+
+// Limits parses limits of the config
+func (config RateLimitConfig) Limits() (rateLimit.Config, error) {
+	var limits rateLimit.Config
+	var err error
+	if config.Default != "" {
+		if limits.Default, err = rateLimit.ParseLimit(config.Default); err != nil {
+			return limits, err
+		}
+	}
+	parse := func(values map[string]string) (map[string]rateLimit.Limit, error) {
+		parsed := make(map[string]rateLimit.Limit, len(values))
+		for key, value := range values {
+			limit, err := rateLimit.ParseLimit(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			parsed[key] = limit
+		}
+		return parsed, nil
+	}
+	if limits.Routes, err = parse(config.Routes); err != nil {
+		return limits, err
+	}
+	limits.Clients, err = parse(config.Clients)
+	return limits, err
+}
+
+// Default returns the config used when settings are not specified
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Address:         ":8080",
+			MediaDir:        "media",
+			ReadTimeout:     time.Minute,
+			WriteTimeout:    time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			RequestTimeout:  30 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		DB:      DBConfig{Driver: "sqlite3", DSN: "products.db"},
+		Log:     LogConfig{Level: InfoLevel},
+		TLS:     TLSConfig{MinVersion: TLS12, ClientAuth: NoClientAuth},
+		Tracing: TracingConfig{Exporter: NoExporter, ServiceName: "XsollaSchoolBE", SampleRatio: 1},
+		Cache:   CacheConfig{Size: 1000, TTL: 30 * time.Second},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "X-Tenant", "Idempotency-Key", "Accept-Language", "X-Request-ID"},
+			MaxAge:         10 * time.Minute,
+		},
+	}
+}
+
+// ValidationError lists all problems of the config
+type ValidationError struct {
+	Problems []string
+}
+
+func (err *ValidationError) Error() string {
+	return "invalid config:\n  " + strings.Join(err.Problems, "\n  ")
+}
+
+// Validate checks values of the config and existence of files, it does not load keys of tokens
+func (config Config) Validate() error {
+	var problems []string
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+	if _, _, err := net.SplitHostPort(config.Server.Address); err != nil {
+		addProblem("server.address: %v", err)
+	}
+	if config.Server.MediaDir == "" {
+		addProblem("server.mediaDir must not be empty")
+	}
+	for _, proxy := range config.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			addProblem("server.trustedProxies: %q must be an IP address or a network like 10.0.0.0/8", proxy)
+		}
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.readTimeout", config.Server.ReadTimeout},
+		{"server.writeTimeout", config.Server.WriteTimeout},
+		{"server.idleTimeout", config.Server.IdleTimeout},
+		{"server.requestTimeout", config.Server.RequestTimeout},
+		{"server.shutdownDelay", config.Server.ShutdownDelay},
+		{"server.shutdownTimeout", config.Server.ShutdownTimeout},
+		{"cors.maxAge", config.CORS.MaxAge},
+		{"cache.ttl", config.Cache.TTL},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			addProblem("%s must not be negative", timeout.name)
+		}
+	}
+	if config.Cache.Size < 0 {
+		addProblem("cache.size must not be negative")
+	}
+	if !contains(Drivers, config.DB.Driver) {
+		addProblem("db.driver %q is not supported, supported drivers: %s", config.DB.Driver, strings.Join(Drivers, ", "))
+	}
+	if config.DB.DSN == "" {
+		addProblem("db.dsn must not be empty")
+	}
+	if !contains(logLevels, config.Log.Level) {
+		addProblem("log.level %q must be one of %s", config.Log.Level, strings.Join(logLevels, ", "))
+	}
+	if config.TLS.Enabled() {
+		files := [][2]string{{"tls.certFile", config.TLS.CertFile}, {"tls.keyFile", config.TLS.KeyFile}}
+		for _, f := range files {
+			name, file := f[0], f[1]
+			if file == "" {
+				addProblem("%s must be set to enable TLS", name)
+			} else if _, err := os.Stat(file); err != nil {
+				addProblem("%s: %v", name, err)
+			}
+		}
+	}
+	if !contains(tlsVersions, config.TLS.MinVersion) {
+		addProblem("tls.minVersion %q must be one of %s", config.TLS.MinVersion, strings.Join(tlsVersions, ", "))
+	}
+	if !contains(clientAuthModes, config.TLS.ClientAuth) {
+		addProblem("tls.clientAuth %q must be one of %s", config.TLS.ClientAuth, strings.Join(clientAuthModes, ", "))
+	} else if config.TLS.ClientAuth != NoClientAuth && config.TLS.ClientCAFile == "" {
+		addProblem("tls.clientCAFile must be set to verify client certificates")
+	}
+	if config.TLS.ClientCAFile != "" {
+		if !config.TLS.Enabled() {
+			addProblem("tls.clientCAFile requires tls.certFile and tls.keyFile")
+		} else if _, err := os.Stat(config.TLS.ClientCAFile); err != nil {
+			addProblem("tls.clientCAFile: %v", err)
+		}
+	}
+	if !contains(exporters, config.Tracing.Exporter) {
+		addProblem("tracing.exporter %q must be one of %s", config.Tracing.Exporter, strings.Join(exporters, ", "))
+	}
+	if config.Tracing.Exporter == OTLPExporter {
+		if u, err := url.Parse(config.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			addProblem("tracing.endpoint %q must be http or https URL of OTLP collector", config.Tracing.Endpoint)
+		}
+	}
+	if config.Tracing.SampleRatio < 0 || config.Tracing.SampleRatio > 1 {
+		addProblem("tracing.sampleRatio must be from 0 to 1")
+	}
+	for _, origin := range config.CORS.AllowedOrigins {
+		if u, err := url.Parse(origin); origin != "*" && (err != nil || u.Scheme == "" || u.Host == "" || u.Path != "") {
+			addProblem("cors.allowedOrigins: %q must be \"*\" or scheme and host like https://example.com", origin)
+		}
+	}
+	if config.JWT.DefaultTenant != "" && !models.ValidTenant(config.JWT.DefaultTenant) {
+		addProblem("jwt.defaultTenant %q is not a valid tenant", config.JWT.DefaultTenant)
+	}
+	for _, file := range config.JWT.KeyFiles {
+		if _, err := os.Stat(file); err != nil {
+			addProblem("jwt.keyFiles: %v", err)
+		}
+	}
+	if _, err := config.RateLimit.Limits(); err != nil {
+		addProblem("rateLimit: %v", err)
+	}
+	if len(problems) != 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
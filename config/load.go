@@ -0,0 +1,198 @@
+package config
+
+import (
+	"XsollaSchoolBE/rateLimit"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileEnv is an environment variable with the path of the config file, -config flag overrides it
+const fileEnv = "CONFIG_FILE"
+
+// setting can be changed by an environment variable and a command-line flag
+type setting struct {
+	env string
+	// flag is empty for secrets, which must not be visible in the process list
+	flag  string
+	usage string
+	set   func(config *Config, value string) error
+}
+
+var settings = []setting{
+	{"LISTEN_ADDRESS", "address", "TCP address to listen on", setString(func(c *Config) *string { return &c.Server.Address })},
+	{"MEDIA_DIR", "media-dir", "directory of product image files", setString(func(c *Config) *string { return &c.Server.MediaDir })},
+	{"READ_TIMEOUT", "read-timeout", "timeout of reading requests", setDuration(func(c *Config) *time.Duration { return &c.Server.ReadTimeout })},
+	{"WRITE_TIMEOUT", "write-timeout", "timeout of writing responses", setDuration(func(c *Config) *time.Duration { return &c.Server.WriteTimeout })},
+	{"IDLE_TIMEOUT", "idle-timeout", "timeout of idle keep-alive connections", setDuration(func(c *Config) *time.Duration { return &c.Server.IdleTimeout })},
+	{"REQUEST_TIMEOUT", "request-timeout", "deadline of database operations of a request, 0s disables it", setDuration(func(c *Config) *time.Duration { return &c.Server.RequestTimeout })},
+	{"SHUTDOWN_DELAY", "shutdown-delay", "delay between failing readiness checks and closing the listener on shutdown", setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownDelay })},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "timeout of waiting for active requests on shutdown, they are cancelled after it", setDuration(func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout })},
+	{"TRUSTED_PROXIES", "trusted-proxies", "comma separated addresses and networks of proxies allowed to set X-Forwarded-For", setList(func(c *Config) *[]string { return &c.Server.TrustedProxies })},
+	{"DB_DRIVER", "db-driver", "database driver: " + strings.Join(Drivers, ", "), setString(func(c *Config) *string { return &c.DB.Driver })},
+	{"DB_DSN", "db-dsn", "data source name, database file for sqlite3", setString(func(c *Config) *string { return &c.DB.DSN })},
+	{"LOG_LEVEL", "log-level", "log level: " + strings.Join(logLevels, ", "), setString(func(c *Config) *string { return &c.Log.Level })},
+	{"TLS_CERT_FILE", "tls-cert", "PEM file with TLS certificate chain", setString(func(c *Config) *string { return &c.TLS.CertFile })},
+	{"TLS_KEY_FILE", "tls-key", "PEM file with TLS private key", setString(func(c *Config) *string { return &c.TLS.KeyFile })},
+	{"TLS_MIN_VERSION", "tls-min-version", "minimum TLS version: " + strings.Join(tlsVersions, ", "), setString(func(c *Config) *string { return &c.TLS.MinVersion })},
+	{"TLS_CLIENT_CA_FILE", "tls-client-ca", "PEM file with CA certificates of client certificates", setString(func(c *Config) *string { return &c.TLS.ClientCAFile })},
+	{"TLS_CLIENT_AUTH", "tls-client-auth", "verification of client certificates: " + strings.Join(clientAuthModes, ", "), setString(func(c *Config) *string { return &c.TLS.ClientAuth })},
+	{"CORS_ALLOWED_ORIGINS", "cors-origins", "comma separated origins allowed to send cross-origin requests", setList(func(c *Config) *[]string { return &c.CORS.AllowedOrigins })},
+	{"CORS_ALLOWED_METHODS", "cors-methods", "comma separated methods of cross-origin requests", setList(func(c *Config) *[]string { return &c.CORS.AllowedMethods })},
+	{"CORS_ALLOWED_HEADERS", "cors-headers", "comma separated headers of cross-origin requests", setList(func(c *Config) *[]string { return &c.CORS.AllowedHeaders })},
+	{"CORS_MAX_AGE", "cors-max-age", "caching time of preflight responses", setDuration(func(c *Config) *time.Duration { return &c.CORS.MaxAge })},
+	{"JWT_ISSUER", "jwt-issuer", "expected iss claim of tokens", setString(func(c *Config) *string { return &c.JWT.Issuer })},
+	{"JWT_AUDIENCE", "jwt-audience", "expected aud claim of tokens", setString(func(c *Config) *string { return &c.JWT.Audience })},
+	{"JWT_KEY_FILES", "jwt-key-files", "comma separated PEM files with public keys of tokens", setList(func(c *Config) *[]string { return &c.JWT.KeyFiles })},
+	{"JWT_SECRET", "", "", setString(func(c *Config) *string { return &c.JWT.Secret })},
+	{"JWT_JWKS", "jwt-jwks", "path or URL of JWKS document with public keys of tokens", setString(func(c *Config) *string { return &c.JWT.JWKS })},
+	{"JWT_ROLES_CLAIM", "jwt-roles-claim", "dot separated path of roles claim", setString(func(c *Config) *string { return &c.JWT.RolesClaim })},
+	{"JWT_TENANT_CLAIM", "jwt-tenant-claim", "dot separated path of tenant claim", setString(func(c *Config) *string { return &c.JWT.TenantClaim })},
+	{"JWT_DEFAULT_TENANT", "jwt-default-tenant", "tenant of tokens without tenant claim, such tokens are rejected without it", setString(func(c *Config) *string { return &c.JWT.DefaultTenant })},
+	{"TRACING_EXPORTER", "tracing-exporter", "exporter of spans: " + strings.Join(exporters, ", "), setString(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"OTEL_EXPORTER_OTLP_ENDPOINT", "otlp-endpoint", "base URL of OTLP/HTTP collector", setString(func(c *Config) *string { return &c.Tracing.Endpoint })},
+	{"OTEL_SERVICE_NAME", "service-name", "service name of exported spans", setString(func(c *Config) *string { return &c.Tracing.ServiceName })},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "share of traced requests from 0 to 1", setFloat(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+	{"CACHE_SIZE", "cache-size", "maximum number of cached products and pages of products, 0 disables the cache", setInt(func(c *Config) *int { return &c.Cache.Size })},
+	{"CACHE_TTL", "cache-ttl", "time of caching products and pages of products", setDuration(func(c *Config) *time.Duration { return &c.Cache.TTL })},
+	{"RATE_LIMIT", "rate-limit", "limit of all requests of a client like 100/1m", setString(func(c *Config) *string { return &c.RateLimit.Default })},
+	{"RATE_LIMIT_ROUTES", "rate-limit-routes", "comma separated limits of routes like \"POST /api/v1/products=10/1m\"", setLimits(func(c *Config) *map[string]string { return &c.RateLimit.Routes })},
+	{"RATE_LIMIT_CLIENTS", "rate-limit-clients", "comma separated limits of clients like key:1=1000/1m", setLimits(func(c *Config) *map[string]string { return &c.RateLimit.Clients })},
+}
+
+// Loader reads the config from the file, environment variables and flags of the flag set,
+// each source overrides settings of the previous one
+type Loader struct {
+	file  *string
+	flags *flag.FlagSet
+}
+
+// NewLoader defines -config flag and flags of settings in the flag set
+func NewLoader(flags *flag.FlagSet) *Loader {
+	loader := Loader{file: flags.String("config", "", "YAML config file, "+fileEnv+" environment variable by default"), flags: flags}
+	for _, s := range settings {
+		if s.flag != "" {
+			flags.String(s.flag, "", s.usage+", "+s.env+" environment variable by default")
+		}
+	}
+	return &loader
+}
+
+// Load returns the config after the flag set is parsed, settings missing in all sources have default values
+func (loader *Loader) Load() (Config, error) {
+	config := Default()
+	file := *loader.file
+	if file == "" {
+		file = os.Getenv(fileEnv)
+	}
+	if file != "" {
+		if err := loadFile(&config, file); err != nil {
+			return config, err
+		}
+	}
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.env); ok {
+			if err := s.set(&config, value); err != nil {
+				return config, fmt.Errorf("%s: %v", s.env, err)
+			}
+		}
+	}
+	var err error
+	loader.flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && err == nil {
+				if setErr := s.set(&config, f.Value.String()); setErr != nil {
+					err = fmt.Errorf("-%s: %v", f.Name, setErr)
+				}
+			}
+		}
+	})
+	return config, err
+}
+
+// loadFile overrides settings of the config with ones present in YAML file, unknown settings are errors
+func loadFile(config *Config, file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err = yaml.UnmarshalStrict(data, config); err != nil {
+		return fmt.Errorf("%s: %v", file, err)
+	}
+	return nil
+}
+
+func setString(field func(*Config) *string) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		*field(config) = value
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = duration
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(config) = number
+		return nil
+	}
+}
+
+func setFloat(field func(*Config) *float64) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		*field(config) = number
+		return nil
+	}
+}
+
+// setList splits comma separated values, an empty value makes the list empty
+func setList(field func(*Config) *[]string) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		list := make([]string, 0)
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*field(config) = list
+		return nil
+	}
+}
+
+// setLimits parses comma separated key=limit pairs, limits are checked by rateLimit.ParseLimits
+func setLimits(field func(*Config) *map[string]string) func(*Config, string) error {
+	return func(config *Config, value string) error {
+		limits, err := rateLimit.ParseLimits(value)
+		if err != nil {
+			return err
+		}
+		values := make(map[string]string, len(limits))
+		for key, limit := range limits {
+			values[key] = limit.String()
+		}
+		*field(config) = values
+		return nil
+	}
+}
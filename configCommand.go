@@ -0,0 +1,39 @@
+package main
+
+import (
+	"XsollaSchoolBE/config"
+	"XsollaSchoolBE/jwtAuth"
+	"errors"
+	"flag"
+	"fmt"
+)
+
+const configUsage = `usage:
+	XsollaSchoolBE config validate [-config FILE] [flags of the server]`
+
+// runConfigCommand checks the config combined from the file, environment variables and flags without running the server
+func runConfigCommand(args []string) error {
+	if len(args) == 0 || args[0] != "validate" {
+		return errors.New(configUsage)
+	}
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	loader := config.NewLoader(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return err
+	}
+	if err = cfg.Validate(); err != nil {
+		return err
+	}
+	// Keys are loaded to check files and JWKS document
+	if cfg.JWT.Enabled() {
+		if _, err = jwtAuth.NewVerifier(cfg.JWT); err != nil {
+			return fmt.Errorf("jwt: %v", err)
+		}
+	}
+	fmt.Println("Config is valid")
+	return nil
+}
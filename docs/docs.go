@@ -15,7 +15,7 @@ var doc = `{
     "schemes": {{ marshal .Schemes }},
     "swagger": "2.0",
     "info": {
-        "description": "{{.Description}}",
+        "description": "{{escape .Description}}",
         "title": "{{.Title}}",
         "contact": {},
         "version": "{{.Version}}"
@@ -23,54 +23,35 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/products": {
+        "/apikeys": {
             "get": {
-                "description": "Method return product with specific SKU, if related parameter is specified else similarly with Id.\nIf both of parameters aren't specified return all products or group of them, if groupSize and groupNum params are specified",
-                "summary": "get product with specific SKU or Id with it in URL params or all of the products, or part of them",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "SKU of searching product",
-                        "name": "sku",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Id of searching product",
-                        "name": "id",
-                        "in": "query"
-                    },
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Size of requesting products group",
-                        "name": "groupSize",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of requesting products group",
-                        "name": "groupNum",
-                        "in": "query"
+                        "BearerAuth": []
                     }
                 ],
+                "summary": "get descriptions of all API keys including revoked ones",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/Product"
+                                "$ref": "#/definitions/APIKey"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Product with specified SKU or Id not found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -83,39 +64,36 @@ var doc = `{
                     }
                 }
             },
-            "put": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "The secret key is returned only once, it must be sent in X-API-Key header.\nScopes grant roles: \"read\" grants viewer role, \"write\" grants editor role and \"admin\" grants admin role.",
                 "consumes": [
                     "application/json"
                 ],
-                "summary": "update product with specific SKU or Id with it in URL params",
+                "summary": "create API key",
                 "parameters": [
                     {
-                        "description": "new product",
-                        "name": "product",
+                        "description": "name and scopes of the key",
+                        "name": "key",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/InputProduct"
+                            "$ref": "#/definitions/InputAPIKey"
                         }
-                    },
-                    {
-                        "type": "string",
-                        "description": "SKU of updating product",
-                        "name": "sku",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Id of updating product",
-                        "name": "id",
-                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Product has been updated",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/Product"
+                            "$ref": "#/definitions/NewAPIKey"
                         }
                     },
                     "400": {
@@ -124,14 +102,14 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -143,29 +121,31 @@ var doc = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/apikeys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
                 ],
-                "summary": "add new product",
+                "summary": "revoke API key with specific id",
                 "parameters": [
                     {
-                        "description": "adding product",
-                        "name": "product",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/InputProduct"
-                        }
+                        "type": "integer",
+                        "description": "Id of the key",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Product has been created",
-                        "schema": {
-                            "$ref": "#/definitions/Product"
-                        }
+                    "204": {
+                        "description": ""
                     },
                     "400": {
                         "description": "Bad Request",
@@ -173,52 +153,45 @@ var doc = `{
                             "type": "string"
                         }
                     },
-                    "409": {
-                        "description": "Conflict",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Method delete product with specific SKU, if related parameter is specified else similarly with Id.",
-                "summary": "delete product with specific SKU or Id with it in URL params",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "SKU of deleting product",
-                        "name": "sku",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Id of deleting product",
-                        "name": "id",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": ""
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "not revoked key with such id does not exist",
                         "schema": {
                             "type": "string"
                         }
                     },
-                    "404": {
-                        "description": "Product with specified SKU or Id not found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "string"
                         }
+                    }
+                }
+            }
+        },
+        "/categories": {
+            "get": {
+                "summary": "get all categories",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Category"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -228,58 +201,90 @@ var doc = `{
                     }
                 }
             },
-            "head": {
-                "summary": "return headers as a similar get request",
-                "parameters": [
+            "post": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "SKU of searching product",
-                        "name": "sku",
-                        "in": "query"
+                        "ApiKeyAuth": []
                     },
                     {
-                        "type": "integer",
-                        "description": "Id of searching product",
-                        "name": "id",
-                        "in": "query"
-                    },
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "add new category",
+                "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Size of requesting products group",
-                        "name": "groupSize",
-                        "in": "query"
+                        "description": "adding category, ParentId is 0 for root categories",
+                        "name": "category",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/InputCategory"
+                        }
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of requesting products group",
-                        "name": "groupNum",
-                        "in": "query"
+                        "type": "string",
+                        "description": "unique key of the operation, retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": ""
+                    "201": {
+                        "description": "Category has been created",
+                        "schema": {
+                            "$ref": "#/definitions/Category"
+                        }
                     },
                     "400": {
-                        "description": ""
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
-                    "404": {
-                        "description": ""
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "idempotency key is used for another request",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "500": {
-                        "description": ""
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/products/{SKU}": {
+        "/categories/{id}": {
             "get": {
-                "summary": "get product with specific SKU with SKU in URL path",
+                "summary": "get category with specific id",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "SKU of searching product",
-                        "name": "SKU",
+                        "type": "integer",
+                        "description": "Id of searching category",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -288,14 +293,17 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/Product"
-                            }
+                            "$ref": "#/definitions/Category"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
                         }
                     },
                     "404": {
-                        "description": "product with such SKU does not exist",
+                        "description": "category with such id does not exist",
                         "schema": {
                             "type": "string"
                         }
@@ -309,33 +317,41 @@ var doc = `{
                 }
             },
             "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "consumes": [
                     "application/json"
                 ],
-                "summary": "update product with specific SKU with SKU in URL path",
+                "summary": "rename category with specific id or move it to another parent category",
                 "parameters": [
                     {
-                        "description": "new product",
-                        "name": "product",
+                        "type": "integer",
+                        "description": "Id of updating category",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new category data, ParentId is 0 for root categories",
+                        "name": "category",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/InputProduct"
+                            "$ref": "#/definitions/InputCategory"
                         }
-                    },
-                    {
-                        "type": "string",
-                        "description": "SKU of updating product",
-                        "name": "SKU",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Product has been updated",
+                        "description": "Category has been updated",
                         "schema": {
-                            "$ref": "#/definitions/Product"
+                            "$ref": "#/definitions/Category"
                         }
                     },
                     "400": {
@@ -344,6 +360,18 @@ var doc = `{
                             "type": "string"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -365,12 +393,1852 @@ var doc = `{
                 }
             },
             "delete": {
-                "summary": "delete product with specific SKU with SKU in URL path",
-                "parameters": [
+                "security": [
                     {
-                        "type": "string",
-                        "description": "SKU of deleting product",
-                        "name": "SKU",
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Subcategories of the deleting category are moved to its parent category",
+                "summary": "delete category with specific id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Id of deleting category",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "category with such id does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products": {
+            "get": {
+                "description": "Method return product with specific SKU, if related parameter is specified else similarly with Id.\nIf both of parameters aren't specified return all products or group of them, if groupSize and groupNum params are specified.\nProducts of catalog can be filtered by type and by attributes with parameters like attributes[size]=XL,\nnumeric attributes can be bounded with parameters like attributesMin[ageRating]=12 and attributesMax[ageRating]=18",
+                "summary": "get product with specific SKU or Id with it in URL params or all of the products, or part of them",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of searching product",
+                        "name": "sku",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of searching product",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of requesting products group",
+                        "name": "groupSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of requesting products group",
+                        "name": "groupNum",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "default": "published",
+                        "description": "Statuses of catalog products, all for products of any status, other than published require products:read permission",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of catalog products",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of category of catalog products",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include products of subcategories of the category",
+                        "name": "includeSubcategories",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags of catalog products",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "and",
+                            "or"
+                        ],
+                        "type": "string",
+                        "default": "and",
+                        "description": "Products must be tagged with all (and) or any (or) of the tags",
+                        "name": "tagMode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Required value of product attribute with specified name",
+                        "name": "attributes[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimal value of numeric product attribute with specified name",
+                        "name": "attributesMin[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximal value of numeric product attribute with specified name",
+                        "name": "attributesMax[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions, overrides Accept-Language header",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales of names and descriptions",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Product"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Product with specified SKU or Id not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "update product with specific SKU or Id with it in URL params",
+                "parameters": [
+                    {
+                        "description": "new product",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/InputProduct"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "SKU of updating product",
+                        "name": "sku",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of updating product",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product has been updated",
+                        "schema": {
+                            "$ref": "#/definitions/Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "add new product",
+                "parameters": [
+                    {
+                        "description": "adding product",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/InputProduct"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the operation, retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Product has been created",
+                        "schema": {
+                            "$ref": "#/definitions/Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "idempotency key is used for another request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Method delete product with specific SKU, if related parameter is specified else similarly with Id.\nImages of the product and its variants are deleted with it.",
+                "summary": "delete product with specific SKU or Id with it in URL params",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of deleting product",
+                        "name": "sku",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of deleting product",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Product with specified SKU or Id not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "summary": "return headers as a similar get request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of searching product",
+                        "name": "sku",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of searching product",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of requesting products group",
+                        "name": "groupSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of requesting products group",
+                        "name": "groupNum",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "default": "published",
+                        "description": "Statuses of catalog products, all for products of any status, other than published require products:read permission",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of catalog products",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of category of catalog products",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include products of subcategories of the category",
+                        "name": "includeSubcategories",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags of catalog products",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "and",
+                            "or"
+                        ],
+                        "type": "string",
+                        "default": "and",
+                        "description": "Products must be tagged with all (and) or any (or) of the tags",
+                        "name": "tagMode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Required value of product attribute with specified name",
+                        "name": "attributes[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimal value of numeric product attribute with specified name",
+                        "name": "attributesMin[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximal value of numeric product attribute with specified name",
+                        "name": "attributesMax[name]",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": ""
+                    },
+                    "500": {
+                        "description": ""
+                    }
+                }
+            }
+        },
+        "/products/scheduled": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "get upcoming scheduled changes of product statuses ordered by time",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/ScheduledChange"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/search": {
+            "get": {
+                "description": "Method returns products containing words starting with every word of the query ordered by relevance.\nFound words are enclosed in \u003cmark\u003e\u003c/mark\u003e in highlighted name and description of the product, their text is HTML-escaped.\nResults can be requested by parts and filtered the same way as the catalog.\nNames and descriptions of found products are localized, highlighted ones are not.",
+                "summary": "search products by words in their names and descriptions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Size of requesting results group",
+                        "name": "groupSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of requesting results group",
+                        "name": "groupNum",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "default": "published",
+                        "description": "Statuses of found products, all for products of any status, other than published require products:read permission",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Type of found products",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Name of category of found products",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include products of subcategories of the category",
+                        "name": "includeSubcategories",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Tags of found products",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "and",
+                            "or"
+                        ],
+                        "type": "string",
+                        "default": "and",
+                        "description": "Products must be tagged with all (and) or any (or) of the tags",
+                        "name": "tagMode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Required value of product attribute with specified name",
+                        "name": "attributes[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimal value of numeric product attribute with specified name",
+                        "name": "attributesMin[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Maximal value of numeric product attribute with specified name",
+                        "name": "attributesMax[name]",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions, overrides Accept-Language header",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales of names and descriptions",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/suggestions": {
+            "get": {
+                "description": "Method is intended for autocompletion, products with matching SKU go first.\nNames are translated to the requested locale and the prefix is matched against translated names.",
+                "summary": "get SKUs and names of products with SKU or name starting with the prefix",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Typed prefix of SKU or name",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 50,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Maximal number of suggestions",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names, overrides Accept-Language header",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales of names",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Suggestion"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}": {
+            "get": {
+                "summary": "get product with specific SKU with SKU in URL path",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of searching product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of name and description, overrides Accept-Language header",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales of name and description",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Product"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "update product with specific SKU with SKU in URL path",
+                "parameters": [
+                    {
+                        "description": "new product",
+                        "name": "product",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/InputProduct"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "SKU of updating product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Product has been updated",
+                        "schema": {
+                            "$ref": "#/definitions/Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Images of the product and its variants are deleted with it.",
+                "summary": "delete product with specific SKU with SKU in URL path",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of deleting product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "head": {
+                "summary": "return headers as a similar get request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of searching product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": ""
+                    },
+                    "404": {
+                        "description": ""
+                    },
+                    "500": {
+                        "description": ""
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/categories": {
+            "get": {
+                "summary": "get categories which the product with specific SKU is assigned to",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Category"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/categories/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "assign the product with specific SKU to the category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of the category",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product or category does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "remove the product with specific SKU from the category",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of the category",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product or category does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/images": {
+            "get": {
+                "summary": "get images of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Image"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "change order of images of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "ids of all images of the product in the new order",
+                        "name": "ids",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Image"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Image in JPEG, PNG or GIF format is added after other images of the product.\nIts thumbnail fits into 200x200 square.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "summary": "upload image of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "image file up to 10 MB",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "unique key of the operation, retries with the same key get the first response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/Image"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "request with the idempotency key is still processed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "422": {
+                        "description": "idempotency key is used for another request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/images/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "delete image of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Id of the image",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product does not exist or has no such image",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/schedule": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Only drafts can be scheduled for publishing, unpublishing moves a published product back to drafts.\nNull time cancels the change, times are stored with precision of seconds.",
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "set times of automatic publishing and unpublishing of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "times in RFC 3339 format",
+                        "name": "schedule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/Schedule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "product status does not allow the change",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/status": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "New products are drafts, only published products are shown in the catalog by default.\nAllowed transitions: draft to published or archived, published to draft or archived, archived to draft.",
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "move the product with specific SKU to another lifecycle status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new status of the product",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/StatusTransition"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "transition from the current status is not allowed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/tags": {
+            "get": {
+                "summary": "get tags of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/tags/{tag}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tags are case insensitive, they are stored in lower case",
+                "summary": "tag the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Adding tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "remove tag from the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Removing tag",
+                        "name": "tag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist or it is not tagged with the tag",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/translations": {
+            "get": {
+                "summary": "get translations of name and description of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Translation"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/translations/{locale}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Locale is a language tag like \"pt-BR\", it is case insensitive.",
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "add or replace translation of name and description of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the translation",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "translated name and description, empty description falls back to the next locale",
+                        "name": "translation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/InputTranslation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/Translation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "delete translation of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of the translation",
+                        "name": "locale",
                         "in": "path",
                         "required": true
                     }
@@ -379,8 +2247,221 @@ var doc = `{
                     "204": {
                         "description": ""
                     },
-                    "404": {
-                        "description": "product with such SKU does not exist",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product does not exist or has no translation to the locale",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/products/{SKU}/variants": {
+            "get": {
+                "summary": "get variants of the product with specific SKU",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SKU of the parent product",
+                        "name": "SKU",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale of names and descriptions, overrides Accept-Language header",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Preferred locales of names and descriptions",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/Product"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "product with such SKU does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/tags": {
+            "get": {
+                "summary": "get all tags with numbers of tagged products",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/TagCount"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/types": {
+            "get": {
+                "summary": "get attribute schemas of all product types",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/AttributeSchema"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/types/{type}/schema": {
+            "get": {
+                "summary": "get attribute schema of the product type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/AttributeSchema"
+                        }
+                    },
+                    "404": {
+                        "description": "schema for such type does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Attributes of added and updated products of the type are validated with the schema.\nThe schema is rejected, if attributes of existing products of the type do not match it.\nData type of an attribute is one of \"string\", \"integer\", \"number\" and \"boolean\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "summary": "create or replace attribute schema of the product type",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product type",
+                        "name": "type",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "attributes of products of the type",
+                        "name": "attributes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/AttributeDefinition"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/AttributeSchema"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "string"
                         }
@@ -393,41 +2474,194 @@ var doc = `{
                     }
                 }
             },
-            "head": {
-                "summary": "return headers as a similar get request",
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "summary": "delete attribute schema of the product type",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "SKU of searching product",
-                        "name": "SKU",
+                        "description": "Product type",
+                        "name": "type",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
+                    "204": {
                         "description": ""
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
                     "404": {
-                        "description": ""
+                        "description": "schema for such type does not exist",
+                        "schema": {
+                            "type": "string"
+                        }
                     },
                     "500": {
-                        "description": ""
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "APIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant": {
+                    "description": "Tenant is a publisher, which catalog the key gives access to",
+                    "type": "string"
+                }
+            }
+        },
+        "AttributeDefinition": {
+            "type": "object",
+            "properties": {
+                "dataType": {
+                    "type": "string"
+                },
+                "enum": {
+                    "description": "Enum contains allowed values of the attribute, any value of DataType is allowed if it is empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "AttributeSchema": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/AttributeDefinition"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "Category": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "Image": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "thumbnailURL": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "InputAPIKey": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "InputCategory": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                }
+            }
+        },
         "InputProduct": {
             "type": "object",
             "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "cost": {
                     "type": "integer"
                 },
+                "description": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
+                "parentSKU": {
+                    "type": "string"
+                },
                 "sku": {
                     "type": "string"
                 },
@@ -436,26 +2670,230 @@ var doc = `{
                 }
             }
         },
+        "InputTranslation": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "NewAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenant": {
+                    "description": "Tenant is a publisher, which catalog the key gives access to",
+                    "type": "string"
+                }
+            }
+        },
         "Product": {
             "type": "object",
             "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "cost": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Image"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentSKU": {
+                    "type": "string"
+                },
+                "publishAt": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Status is one of \"draft\", \"published\" and \"archived\", it is changed with status transitions",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unpublishAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "Schedule": {
+            "type": "object",
+            "properties": {
+                "publishAt": {
+                    "type": "string"
+                },
+                "unpublishAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "ScheduledChange": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "SearchResult": {
+            "type": "object",
+            "properties": {
+                "attributes": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "cost": {
                     "type": "integer"
                 },
+                "description": {
+                    "type": "string"
+                },
+                "highlightedDescription": {
+                    "type": "string"
+                },
+                "highlightedName": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/Image"
+                    }
+                },
                 "name": {
                     "type": "string"
                 },
+                "parentSKU": {
+                    "type": "string"
+                },
+                "publishAt": {
+                    "type": "string"
+                },
                 "sku": {
                     "type": "string"
                 },
+                "status": {
+                    "description": "Status is one of \"draft\", \"published\" and \"archived\", it is changed with status transitions",
+                    "type": "string"
+                },
                 "type": {
                     "type": "string"
+                },
+                "unpublishAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "StatusTransition": {
+            "type": "object",
+            "properties": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "Suggestion": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "sku": {
+                    "type": "string"
+                }
+            }
+        },
+        "TagCount": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "Translation": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
@@ -475,7 +2913,7 @@ var SwaggerInfo = swaggerInfo{
 	BasePath:    "/api/v1/",
 	Schemes:     []string{},
 	Title:       "almilukXsollaSchoolBE",
-	Description: "This is a service for managing products on internet marketplace",
+	Description: "This is a service for managing products on internet marketplace\nCatalogs of publishers are separated, the catalog is selected with X-Tenant header.\nRequests over rate limits get 429 status code with Retry-After header, limits are described by RateLimit-* headers.\nResponses contain X-Request-ID header with the id sent in the request or generated by the server, it identifies lines of the server log.\nDatabase operations of a request are cancelled after the request timeout, such requests get 503 status code.",
 }
 
 type s struct{}
@@ -489,6 +2927,13 @@ func (s *s) ReadDoc() string {
 			a, _ := json.Marshal(v)
 			return string(a)
 		},
+		"escape": func(v interface{}) string {
+			// escape tabs
+			str := strings.Replace(v.(string), "\t", "\\t", -1)
+			// replace " with \", and if that results in \\", replace that with \\\"
+			str = strings.Replace(str, "\"", "\\\"", -1)
+			return strings.Replace(str, "\\\\\"", "\\\\\\\"", -1)
+		},
 	}).Parse(doc)
 	if err != nil {
 		return doc
@@ -3,9 +3,11 @@ module XsollaSchoolBE
 go 1.15
 
 require (
-	github.com/gin-gonic/gin v1.6.3
+	// gin v1.7 is required to route /products/search next to /products/:SKU, v1.6 panics on such routes
+	github.com/gin-gonic/gin v1.7.7
 	github.com/mattn/go-sqlite3 v1.14.7
 	github.com/swaggo/files v0.0.0-20190704085106-630677cd5c14
 	github.com/swaggo/gin-swagger v1.3.0
 	github.com/swaggo/swag v1.7.0
+	gopkg.in/yaml.v2 v2.3.0
 )
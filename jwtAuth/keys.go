@@ -0,0 +1,170 @@
+package jwtAuth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jwksRefreshInterval limits how often remote JWKS document is requested
+const jwksRefreshInterval = time.Minute
+
+// key is a public key of RSA or ECDSA or a HMAC secret, id and alg may be empty
+type key struct {
+	id     string
+	alg    string
+	public interface{}
+}
+
+// loadPEMKeys reads public keys and certificates from PEM file
+func loadPEMKeys(filename string) ([]*key, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var keys []*key
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		var public interface{}
+		switch block.Type {
+		case "PUBLIC KEY":
+			public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				public = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		keys = append(keys, &key{public: public})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w in %s", NoKeysError, filename)
+	}
+	return keys, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// remoteJWKS is a JWKS document of identity provider, which is requested again when keys are rotated
+type remoteJWKS struct {
+	url         string
+	client      *http.Client
+	mutex       sync.Mutex
+	lastRefresh time.Time
+}
+
+// loadJWKS reads JWKS document from the file or http(s) URL
+func loadJWKS(location string) ([]*key, *remoteJWKS, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		jwks := &remoteJWKS{url: location, client: &http.Client{Timeout: 10 * time.Second}}
+		keys, err := jwks.refresh()
+		return keys, jwks, err
+	}
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys, err := parseJWKS(data)
+	return keys, nil, err
+}
+
+// refresh requests the document, if it was not requested recently
+func (jwks *remoteJWKS) refresh() ([]*key, error) {
+	jwks.mutex.Lock()
+	defer jwks.mutex.Unlock()
+	if time.Since(jwks.lastRefresh) < jwksRefreshInterval {
+		return nil, errors.New("JWKS was refreshed recently")
+	}
+	jwks.lastRefresh = time.Now()
+	resp, err := jwks.client.Get(jwks.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS request failed with status %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseJWKS(data)
+}
+
+// parseJWKS returns RSA and EC signature keys of the document
+func parseJWKS(data []byte) ([]*key, error) {
+	var document struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("JWKS format error: %w", err)
+	}
+	var keys []*key
+	for _, k := range document.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var public interface{}
+		switch k.Kty {
+		case "RSA":
+			n, nErr := decodeSegment(k.N)
+			e, eErr := decodeSegment(k.E)
+			if nErr != nil || eErr != nil || len(e) > 4 {
+				return nil, fmt.Errorf("wrong RSA key %q in JWKS", k.Kid)
+			}
+			public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			x, xErr := decodeSegment(k.X)
+			y, yErr := decodeSegment(k.Y)
+			curve, ok := curves[k.Crv]
+			if xErr != nil || yErr != nil || !ok {
+				return nil, fmt.Errorf("wrong EC key %q in JWKS", k.Kid)
+			}
+			X, Y := new(big.Int).SetBytes(x), new(big.Int).SetBytes(y)
+			if !curve.IsOnCurve(X, Y) {
+				return nil, fmt.Errorf("wrong EC key %q in JWKS", k.Kid)
+			}
+			public = &ecdsa.PublicKey{Curve: curve, X: X, Y: Y}
+		default:
+			continue
+		}
+		keys = append(keys, &key{id: k.Kid, alg: k.Alg, public: public})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w in JWKS", NoKeysError)
+	}
+	return keys, nil
+}
@@ -0,0 +1,334 @@
+package jwtAuth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+var InvalidTokenError = errors.New("Invalid token")
+var NoKeysError = errors.New("No keys for token verification")
+
+// Config describes trusted keys and expected claims of tokens issued by an identity provider
+type Config struct {
+	// Issuer and Audience are compared with iss and aud claims if they are not empty
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// KeyFiles are PEM files with public keys or certificates
+	KeyFiles []string `yaml:"keyFiles"`
+	// Secret is a key of HMAC signed tokens
+	Secret string `yaml:"secret"`
+	// JWKS is a path or an http(s) URL of a JWKS document
+	JWKS string `yaml:"jwks"`
+	// RolesClaim is a dot separated path of the roles claim, "roles" by default
+	RolesClaim string `yaml:"rolesClaim"`
+	// TenantClaim is a dot separated path of the claim with id of the tenant, "tenant" by default
+	TenantClaim string `yaml:"tenantClaim"`
+	// DefaultTenant is the tenant of tokens without the tenant claim, such tokens are rejected if it is empty
+	DefaultTenant string `yaml:"defaultTenant"`
+	// Leeway is an allowed clock difference with the issuer
+	Leeway time.Duration `yaml:"leeway"`
+}
+
+// Enabled reports if any keys are configured
+func (config Config) Enabled() bool {
+	return len(config.KeyFiles) != 0 || config.Secret != "" || config.JWKS != ""
+}
+
+// Claims contains verified claims of a token
+type Claims struct {
+	Subject string
+	Roles   []string
+	// Tenant is DefaultTenant of the config if the token has no tenant claim
+	Tenant    string
+	Issuer    string
+	ExpiresAt time.Time
+}
+
+// Verifier checks signatures and claims of JWTs
+type Verifier struct {
+	config Config
+	// keys are static keys and keys of the JWKS document, jwks refreshes them if the document is remote
+	mutex      sync.RWMutex
+	keys       []*key
+	staticKeys int
+	jwks       *remoteJWKS
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+var hashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// NewVerifier loads keys of the config
+func NewVerifier(config Config) (*Verifier, error) {
+	if config.RolesClaim == "" {
+		config.RolesClaim = "roles"
+	}
+	if config.TenantClaim == "" {
+		config.TenantClaim = "tenant"
+	}
+	if config.Leeway == 0 {
+		config.Leeway = time.Minute
+	}
+	verifier := &Verifier{config: config}
+	for _, file := range config.KeyFiles {
+		fileKeys, err := loadPEMKeys(file)
+		if err != nil {
+			return nil, err
+		}
+		verifier.keys = append(verifier.keys, fileKeys...)
+	}
+	if config.Secret != "" {
+		verifier.keys = append(verifier.keys, &key{public: []byte(config.Secret)})
+	}
+	verifier.staticKeys = len(verifier.keys)
+	if config.JWKS != "" {
+		jwksKeys, jwks, err := loadJWKS(config.JWKS)
+		if err != nil {
+			return nil, err
+		}
+		verifier.keys = append(verifier.keys, jwksKeys...)
+		verifier.jwks = jwks
+	}
+	if len(verifier.keys) == 0 {
+		return nil, NoKeysError
+	}
+	return verifier, nil
+}
+
+// Verify returns claims of the token if its signature is made by a trusted key and its claims are valid at now
+func (verifier *Verifier) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: token must consist of 3 parts", InvalidTokenError)
+	}
+	var head header
+	if err := decodePart(parts[0], &head); err != nil {
+		return nil, err
+	}
+	signature, err := decodeSegment(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if !verifier.verifySignature(head, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, fmt.Errorf("%w: signature is not made by a trusted key", InvalidTokenError)
+	}
+	var payload map[string]interface{}
+	if err := decodePart(parts[1], &payload); err != nil {
+		return nil, err
+	}
+	return verifier.checkClaims(payload, now)
+}
+
+func (verifier *Verifier) verifySignature(head header, signed []byte, signature []byte) bool {
+	verifier.mutex.RLock()
+	found, verified := verifyWithKeys(verifier.keys, head, signed, signature)
+	verifier.mutex.RUnlock()
+	// Unknown key id of remote JWKS means that the provider could rotate its keys
+	if !found && head.Kid != "" && verifier.jwks != nil {
+		if keys, err := verifier.jwks.refresh(); err == nil {
+			verifier.mutex.Lock()
+			verifier.keys = append(verifier.keys[:verifier.staticKeys:verifier.staticKeys], keys...)
+			verifier.mutex.Unlock()
+			_, verified = verifyWithKeys(keys, head, signed, signature)
+		}
+	}
+	return verified
+}
+
+// verifyWithKeys also reports if there is a key with id of the token
+func verifyWithKeys(keys []*key, head header, signed []byte, signature []byte) (bool, bool) {
+	found := false
+	for _, k := range keys {
+		if head.Kid != "" && k.id != "" && k.id != head.Kid {
+			continue
+		}
+		found = found || k.id == head.Kid
+		if k.alg != "" && k.alg != head.Alg {
+			continue
+		}
+		if verify(k, head.Alg, signed, signature) {
+			return true, true
+		}
+	}
+	return found, false
+}
+
+// verify checks the signature by the key, algorithm must correspond to the key type
+func verify(k *key, alg string, signed []byte, signature []byte) bool {
+	if len(alg) != 5 {
+		return false
+	}
+	hash, ok := hashes[alg[2:]]
+	if !ok {
+		return false
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		if alg[:2] == "RS" {
+			return rsa.VerifyPKCS1v15(public, hash, digest, signature) == nil
+		} else if alg[:2] == "PS" {
+			return rsa.VerifyPSS(public, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		if alg[:2] != "ES" || len(signature) != 2*size || public.Curve.Params().BitSize != ecdsaBitSizes[alg] {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(public, digest, r, s)
+	case []byte:
+		if alg[:2] == "HS" {
+			mac := hmac.New(hash.New, public)
+			mac.Write(signed)
+			return hmac.Equal(mac.Sum(nil), signature)
+		}
+	}
+	return false
+}
+
+var ecdsaBitSizes = map[string]int{"ES256": 256, "ES384": 384, "ES512": 521}
+
+func (verifier *Verifier) checkClaims(payload map[string]interface{}, now time.Time) (*Claims, error) {
+	claims := &Claims{}
+	exp, ok := payload["exp"].(json.Number)
+	if !ok {
+		return nil, fmt.Errorf("%w: exp claim is required", InvalidTokenError)
+	}
+	expiresAt, err := exp.Float64()
+	if err != nil {
+		return nil, fmt.Errorf("%w: exp claim must be a number", InvalidTokenError)
+	}
+	claims.ExpiresAt = time.Unix(int64(expiresAt), 0)
+	if !now.Before(claims.ExpiresAt.Add(verifier.config.Leeway)) {
+		return nil, fmt.Errorf("%w: token is expired", InvalidTokenError)
+	}
+	if nbf, ok := payload["nbf"].(json.Number); ok {
+		if notBefore, err := nbf.Float64(); err != nil || now.Add(verifier.config.Leeway).Before(time.Unix(int64(notBefore), 0)) {
+			return nil, fmt.Errorf("%w: token is not valid yet", InvalidTokenError)
+		}
+	}
+	claims.Issuer, _ = payload["iss"].(string)
+	if verifier.config.Issuer != "" && claims.Issuer != verifier.config.Issuer {
+		return nil, fmt.Errorf("%w: wrong issuer", InvalidTokenError)
+	}
+	if verifier.config.Audience != "" && !hasAudience(payload["aud"], verifier.config.Audience) {
+		return nil, fmt.Errorf("%w: token is not issued for this audience", InvalidTokenError)
+	}
+	claims.Subject, _ = payload["sub"].(string)
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: sub claim is required", InvalidTokenError)
+	}
+	claims.Roles = findStrings(payload, verifier.config.RolesClaim)
+	// A token of several tenants or with a malformed claim must not get roles in the default tenant
+	if tenant, ok := findClaim(payload, verifier.config.TenantClaim); !ok && verifier.config.DefaultTenant != "" {
+		claims.Tenant = verifier.config.DefaultTenant
+	} else if !ok {
+		return nil, fmt.Errorf("%w: %s claim is required", InvalidTokenError, verifier.config.TenantClaim)
+	} else if claims.Tenant, ok = singleTenant(tenant); !ok {
+		return nil, fmt.Errorf("%w: %s claim must be one tenant", InvalidTokenError, verifier.config.TenantClaim)
+	}
+	return claims, nil
+}
+
+// singleTenant returns the tenant of the claim, which is a string or an array with one string without spaces
+func singleTenant(claim interface{}) (string, bool) {
+	switch claim := claim.(type) {
+	case string:
+		return claim, claim != "" && !strings.ContainsAny(claim, " \t\r\n")
+	case []interface{}:
+		if len(claim) == 1 {
+			return singleTenant(claim[0])
+		}
+	}
+	return "", false
+}
+
+// hasAudience checks aud claim, which is a string or an array of strings
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, value := range aud {
+			if value == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findClaim returns the claim with path like "realm_access.roles", it reports false if there is no such claim
+func findClaim(payload map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = payload
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, value != nil
+}
+
+// findStrings returns strings of the claim with path like "realm_access.roles", the claim may be a single string
+func findStrings(payload map[string]interface{}, path string) []string {
+	value, _ := findClaim(payload, path)
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		strs := make([]string, 0, len(value))
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+		return strs
+	}
+	return nil
+}
+
+func decodePart(part string, value interface{}) error {
+	data, err := decodeSegment(part)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(value); err != nil {
+		return fmt.Errorf("%w: %s", InvalidTokenError, err.Error())
+	}
+	return nil
+}
+
+func decodeSegment(segment string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", InvalidTokenError, err.Error())
+	}
+	return data, nil
+}
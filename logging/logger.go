@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+var InvalidLevelError = errors.New("invalid log level")
+
+func (level Level) String() string {
+	if level < DebugLevel || level > ErrorLevel {
+		return "level(" + fmt.Sprint(int(level)) + ")"
+	}
+	return levelNames[level]
+}
+
+// ParseLevel converts names of levels: debug, info, warn and error
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return Level(level), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", InvalidLevelError, name)
+}
+
+// output serializes writing of lines by loggers derived from the same logger
+type output struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// Logger writes messages of its level and higher levels as JSON lines with time, level, message and fields.
+// Methods of nil Logger do nothing.
+type Logger struct {
+	out   *output
+	level Level
+	// fields are encoded "key":value pairs with a leading comma
+	fields []byte
+}
+
+func New(w io.Writer, level Level) *Logger {
+	return &Logger{out: &output{w: w}, level: level}
+}
+
+// With returns a logger adding the fields to every line, keysAndValues are pairs of string keys and values
+func (logger *Logger) With(keysAndValues ...interface{}) *Logger {
+	if logger == nil {
+		return nil
+	}
+	derived := *logger
+	derived.fields = appendFields(append([]byte(nil), logger.fields...), keysAndValues)
+	return &derived
+}
+
+// Enabled reports if messages of the level are written
+func (logger *Logger) Enabled(level Level) bool {
+	return logger != nil && level >= logger.level
+}
+
+func (logger *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	logger.Log(DebugLevel, msg, keysAndValues...)
+}
+
+func (logger *Logger) Info(msg string, keysAndValues ...interface{}) {
+	logger.Log(InfoLevel, msg, keysAndValues...)
+}
+
+func (logger *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	logger.Log(WarnLevel, msg, keysAndValues...)
+}
+
+func (logger *Logger) Error(msg string, keysAndValues ...interface{}) {
+	logger.Log(ErrorLevel, msg, keysAndValues...)
+}
+
+func (logger *Logger) Log(level Level, msg string, keysAndValues ...interface{}) {
+	if !logger.Enabled(level) {
+		return
+	}
+	var line bytes.Buffer
+	line.WriteString(`{"time":`)
+	line.Write(encodeValue(time.Now().UTC().Format(time.RFC3339Nano)))
+	line.WriteString(`,"level":`)
+	line.Write(encodeValue(level.String()))
+	line.WriteString(`,"msg":`)
+	line.Write(encodeValue(msg))
+	line.Write(logger.fields)
+	line.Write(appendFields(nil, keysAndValues))
+	line.WriteString("}\n")
+	logger.out.mutex.Lock()
+	defer logger.out.mutex.Unlock()
+	logger.out.w.Write(line.Bytes())
+}
+
+// appendFields encodes pairs of keys and values, a value without a key is added with "!BADKEY" key
+func appendFields(fields []byte, keysAndValues []interface{}) []byte {
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		value := interface{}(nil)
+		if !ok || i+1 == len(keysAndValues) {
+			key, value = "!BADKEY", keysAndValues[i]
+			i--
+		} else {
+			value = keysAndValues[i+1]
+		}
+		fields = append(fields, ',')
+		fields = append(fields, encodeValue(key)...)
+		fields = append(fields, ':')
+		fields = append(fields, encodeValue(value)...)
+	}
+	return fields
+}
+
+// encodeValue encodes the value as JSON, errors and durations are written as strings
+func encodeValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Duration:
+		value = v.String()
+	case fmt.Stringer:
+		value = v.String()
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(value))
+	}
+	return data
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of the context carrying the logger
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of the context or nil if it has no logger
+func FromContext(ctx context.Context) *Logger {
+	logger, _ := ctx.Value(contextKey{}).(*Logger)
+	return logger
+}
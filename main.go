@@ -1,32 +1,98 @@
 package main
 
 import (
+	"XsollaSchoolBE/config"
 	"XsollaSchoolBE/productServer"
 	"context"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
+	"syscall"
 )
 
 // @title almilukXsollaSchoolBE
 // @description This is a service for managing products on internet marketplace
+// @description Catalogs of publishers are separated, the catalog is selected with X-Tenant header.
+// @description Requests over rate limits get 429 status code with Retry-After header, limits are described by RateLimit-* headers.
+// @description Responses contain X-Request-ID header with the id sent in the request or generated by the server, it identifies lines of the server log.
+// @description Database operations of a request are cancelled after the request timeout, such requests get 503 status code.
 // @version 0.1
 
 // @host localhost:8080
 // @BasePath /api/v1/
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+
 func main() {
-	srv, err := productServer.Run(":8080", "products.db")
+	if len(os.Args) > 1 && os.Args[1] == "apikey" {
+		if err := runAPIKeyCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	loader := config.NewLoader(flags)
+	flags.Parse(os.Args[1:])
+	cfg, err := loader.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv, err := productServer.Run(cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Println("Server started")
-	quit := make(chan os.Signal)
-	signal.Notify(quit, os.Interrupt)
-	<-quit
-	log.Println("Shutdown Server ...")
-	if err := srv.Shutdown(context.Background()); err != nil {
-		log.Fatal("Server Shutdown:", err)
+	logger := srv.Logger()
+	logger.Info("server started", "address", srv.Addr)
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	// SIGHUP loads TLS certificates again, established connections keep using the old ones
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := srv.ReloadCertificates(); err != nil {
+				logger.Error("certificates are not reloaded", "error", err)
+			} else {
+				logger.Info("certificates are reloaded")
+			}
+		}
+	}()
+	failed := false
+	select {
+	case sig := <-quit:
+		logger.Info("shutting down server", "signal", sig)
+	case err := <-srv.Errors():
+		logger.Error("server failed", "error", err)
+		failed = true
+	}
+	// The second signal stops the process without waiting for active requests
+	go func() {
+		sig := <-quit
+		logger.Warn("server is stopped without waiting for active requests", "signal", sig)
+		os.Exit(1)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownDelay+cfg.Server.ShutdownTimeout)
+	err = srv.Shutdown(ctx)
+	cancel()
+	if err != nil {
+		logger.Error("server shutdown failed", "error", err)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
 	}
-	log.Println("Server exiting")
+	logger.Info("server stopped")
 }
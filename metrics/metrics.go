@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are upper bounds of histogram buckets in seconds suitable for request latencies
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector writes samples of a metric family in Prometheus text format
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry exposes metrics in Prometheus text exposition format
+type Registry struct {
+	mutex      sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (registry *Registry) register(c collector) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	for _, registered := range registry.collectors {
+		if registered.name() == c.name() {
+			panic("metric " + c.name() + " is already registered")
+		}
+	}
+	registry.collectors = append(registry.collectors, c)
+	sort.Slice(registry.collectors, func(i, j int) bool {
+		return registry.collectors[i].name() < registry.collectors[j].name()
+	})
+}
+
+// ServeHTTP writes all metrics sorted by name
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	buffer := bufio.NewWriter(w)
+	registry.mutex.Lock()
+	collectors := append([]collector(nil), registry.collectors...)
+	registry.mutex.Unlock()
+	for _, c := range collectors {
+		c.write(buffer)
+	}
+	buffer.Flush()
+}
+
+// family contains common parts of metrics with labels
+type family struct {
+	metricName string
+	help       string
+	metricType string
+	labelNames []string
+}
+
+func (f *family) name() string {
+	return f.metricName
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	w.WriteString("# HELP " + f.metricName + " " + strings.NewReplacer("\\", `\\`, "\n", `\n`).Replace(f.help) + "\n")
+	w.WriteString("# TYPE " + f.metricName + " " + f.metricType + "\n")
+}
+
+// writeSample writes the sample with label values of the family and extra label
+func (f *family) writeSample(w *bufio.Writer, suffix string, labelValues []string, extraLabel string, extraValue string, value float64) {
+	w.WriteString(f.metricName + suffix)
+	if len(labelValues) != 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, labelValue := range labelValues {
+			if i != 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, f.labelNames[i], labelValue)
+		}
+		if extraLabel != "" {
+			if len(labelValues) != 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func (f *family) checkLabels(labelValues []string) {
+	if len(labelValues) != len(f.labelNames) {
+		panic("metric " + f.metricName + " has labels " + strings.Join(f.labelNames, ", ") + ", got " + strconv.Itoa(len(labelValues)) + " values")
+	}
+}
+
+var labelValueEscaper = strings.NewReplacer("\\", `\\`, "\n", `\n`, `"`, `\"`)
+
+func writeLabel(w *bufio.Writer, name string, value string) {
+	w.WriteString(name + `="` + labelValueEscaper.Replace(value) + `"`)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// labelKey joins label values into a map key, the separator can not appear in valid UTF-8 strings
+func labelKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
@@ -0,0 +1,162 @@
+package metrics
+
+import (
+	"bufio"
+	"sort"
+	"sync"
+)
+
+// Counter is a monotonically increasing value for each combination of label values
+type Counter struct {
+	family
+	mutex  sync.Mutex
+	series map[string]*counterSeries
+}
+
+type counterSeries struct {
+	labelValues []string
+	value       float64
+}
+
+// NewCounter registers a counter, its name should end with _total
+func (registry *Registry) NewCounter(name string, help string, labelNames ...string) *Counter {
+	counter := &Counter{family: family{name, help, "counter", labelNames}, series: make(map[string]*counterSeries)}
+	registry.register(counter)
+	return counter
+}
+
+func (counter *Counter) Inc(labelValues ...string) {
+	counter.Add(1, labelValues...)
+}
+
+// Add increases the counter, value must not be negative
+func (counter *Counter) Add(value float64, labelValues ...string) {
+	counter.checkLabels(labelValues)
+	key := labelKey(labelValues)
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	s, ok := counter.series[key]
+	if !ok {
+		s = &counterSeries{labelValues: append([]string(nil), labelValues...)}
+		counter.series[key] = s
+	}
+	s.value += value
+}
+
+// write writes a snapshot of the series, so slow scrapes do not block updates
+func (counter *Counter) write(w *bufio.Writer) {
+	counter.writeHeader(w)
+	for _, s := range counter.snapshot() {
+		counter.writeSample(w, "", s.labelValues, "", "", s.value)
+	}
+}
+
+// snapshot returns copies of the series sorted by label values
+func (counter *Counter) snapshot() []counterSeries {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	keys := make([]string, 0, len(counter.series))
+	for key := range counter.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]counterSeries, len(keys))
+	for i, key := range keys {
+		series[i] = *counter.series[key]
+	}
+	return series
+}
+
+// Histogram counts observed values in cumulative buckets for each combination of label values
+type Histogram struct {
+	family
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	// counts are numbers of values in each bucket, they are accumulated on writing
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a histogram with upper bounds of buckets, +Inf bucket is added automatically
+func (registry *Registry) NewHistogram(name string, help string, buckets []float64, labelNames ...string) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	histogram := &Histogram{family: family{name, help, "histogram", labelNames}, buckets: sorted, series: make(map[string]*histogramSeries)}
+	registry.register(histogram)
+	return histogram
+}
+
+func (histogram *Histogram) Observe(value float64, labelValues ...string) {
+	histogram.checkLabels(labelValues)
+	key := labelKey(labelValues)
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	s, ok := histogram.series[key]
+	if !ok {
+		s = &histogramSeries{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(histogram.buckets))}
+		histogram.series[key] = s
+	}
+	if i := sort.SearchFloat64s(histogram.buckets, value); i < len(histogram.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+// write writes a snapshot of the series, so slow scrapes do not block observations
+func (histogram *Histogram) write(w *bufio.Writer) {
+	histogram.writeHeader(w)
+	for _, s := range histogram.snapshot() {
+		var cumulative uint64
+		for i, bound := range histogram.buckets {
+			cumulative += s.counts[i]
+			histogram.writeSample(w, "_bucket", s.labelValues, "le", formatFloat(bound), float64(cumulative))
+		}
+		histogram.writeSample(w, "_bucket", s.labelValues, "le", "+Inf", float64(s.count))
+		histogram.writeSample(w, "_sum", s.labelValues, "", "", s.sum)
+		histogram.writeSample(w, "_count", s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// snapshot returns copies of the series sorted by label values, label values are not changed after creation of series
+func (histogram *Histogram) snapshot() []histogramSeries {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+	keys := make([]string, 0, len(histogram.series))
+	for key := range histogram.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	series := make([]histogramSeries, len(keys))
+	for i, key := range keys {
+		s := histogram.series[key]
+		series[i] = histogramSeries{labelValues: s.labelValues, counts: append([]uint64(nil), s.counts...), count: s.count, sum: s.sum}
+	}
+	return series
+}
+
+// funcMetric reports values collected on each scrape, for example statistics of a connection pool
+type funcMetric struct {
+	family
+	collect func(set func(value float64, labelValues ...string))
+}
+
+// NewFunc registers a metric of type "gauge" or "counter", which values are collected from another source on each scrape,
+// collect calls set for each combination of label values
+func (registry *Registry) NewFunc(name string, help string, metricType string, labelNames []string, collect func(set func(value float64, labelValues ...string))) {
+	registry.register(&funcMetric{family: family{name, help, metricType, labelNames}, collect: collect})
+}
+
+func (metric *funcMetric) write(w *bufio.Writer) {
+	metric.writeHeader(w)
+	metric.collect(func(value float64, labelValues ...string) {
+		metric.checkLabels(labelValues)
+		metric.writeSample(w, "", labelValues, "", "", value)
+	})
+}
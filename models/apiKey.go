@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Scopes of API keys, they grant roles of ScopeRoles
+const (
+	ReadScope  = "read"
+	WriteScope = "write"
+	AdminScope = "admin"
+)
+
+// ScopeRoles contains roles granted by API key scopes
+var ScopeRoles = map[string]string{
+	ReadScope:  ViewerRole,
+	WriteScope: EditorRole,
+	AdminScope: AdminRole,
+}
+
+// APIKey describes a key used by clients for authentication, the key itself is not stored
+type APIKey struct {
+	Id   int64
+	Name string
+	// Tenant is a publisher, which catalog the key gives access to
+	Tenant    string
+	Scopes    []string
+	CreatedAt time.Time
+	RevokedAt *time.Time
+} // @name APIKey
+
+// InputAPIKey is an API key data sent by client.
+// Scopes are "read" for viewer role, "write" for editor role and "admin" for admin role.
+type InputAPIKey struct {
+	Name   string
+	Scopes []string
+} // @name InputAPIKey
+
+// NewAPIKey contains the secret key, which is returned only once after the key creation
+type NewAPIKey struct {
+	APIKey
+	Key string
+} // @name NewAPIKey
+
+func ValidScope(scope string) bool {
+	_, ok := ScopeRoles[scope]
+	return ok
+}
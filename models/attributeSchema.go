@@ -0,0 +1,112 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Data types of product attributes. Attribute values are always sent as strings,
+// data type defines which strings are valid values.
+const (
+	StringAttribute  = "string"
+	IntegerAttribute = "integer"
+	NumberAttribute  = "number"
+	BooleanAttribute = "boolean"
+)
+
+var attributeDataTypes = map[string]bool{
+	StringAttribute:  true,
+	IntegerAttribute: true,
+	NumberAttribute:  true,
+	BooleanAttribute: true,
+}
+
+// AttributeDefinition describes an attribute of products of some type
+type AttributeDefinition struct {
+	Name     string
+	DataType string
+	Required bool
+	// Enum contains allowed values of the attribute, any value of DataType is allowed if it is empty
+	Enum []string
+} // @name AttributeDefinition
+
+// AttributeSchema describes attributes which products of the Type can have
+type AttributeSchema struct {
+	Type       string
+	Attributes []AttributeDefinition
+} // @name AttributeSchema
+
+// Check returns an error if the schema itself is incorrect
+func (schema *AttributeSchema) Check() error {
+	names := make(map[string]bool, len(schema.Attributes))
+	for _, attr := range schema.Attributes {
+		if attr.Name == "" {
+			return fmt.Errorf("attribute name must be specified")
+		} else if names[attr.Name] {
+			return fmt.Errorf("attribute %q is defined more than once", attr.Name)
+		}
+		names[attr.Name] = true
+		if !attributeDataTypes[attr.DataType] {
+			return fmt.Errorf("unknown data type %q of attribute %q", attr.DataType, attr.Name)
+		}
+		for _, value := range attr.Enum {
+			if err := attr.checkDataType(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate returns an error if attributes don't satisfy the schema
+func (schema *AttributeSchema) Validate(attributes map[string]string) error {
+	definitions := make(map[string]*AttributeDefinition, len(schema.Attributes))
+	for i := range schema.Attributes {
+		attr := &schema.Attributes[i]
+		definitions[attr.Name] = attr
+		if _, ok := attributes[attr.Name]; attr.Required && !ok {
+			return fmt.Errorf("attribute %q is required for products of type %q", attr.Name, schema.Type)
+		}
+	}
+	for name, value := range attributes {
+		attr, ok := definitions[name]
+		if !ok {
+			return fmt.Errorf("attribute %q is not defined for products of type %q", name, schema.Type)
+		}
+		if err := attr.checkDataType(value); err != nil {
+			return err
+		}
+		if !attr.allows(value) {
+			return fmt.Errorf("value %q is not allowed for attribute %q, allowed values: %v", value, name, attr.Enum)
+		}
+	}
+	return nil
+}
+
+func (attr *AttributeDefinition) checkDataType(value string) error {
+	var err error
+	switch attr.DataType {
+	case IntegerAttribute:
+		_, err = strconv.ParseInt(value, 10, 64)
+	case NumberAttribute:
+		_, err = strconv.ParseFloat(value, 64)
+	case BooleanAttribute:
+		_, err = strconv.ParseBool(value)
+	}
+	if err != nil {
+		return fmt.Errorf("value %q of attribute %q is not %s", value, attr.Name, attr.DataType)
+	}
+	return nil
+}
+
+func (attr *AttributeDefinition) allows(value string) bool {
+	if len(attr.Enum) == 0 {
+		return true
+	}
+	for _, allowed := range attr.Enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
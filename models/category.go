@@ -0,0 +1,17 @@
+package models
+
+type Category struct {
+	InputCategory
+	Id int64
+} // @name Category
+
+// InputCategory is a category data sent by client.
+// Name of a category is unique, root categories have ParentId equal to 0.
+type InputCategory struct {
+	Name     string
+	ParentId int64
+} // @name InputCategory
+
+func EmptyInputCategory() *InputCategory {
+	return &InputCategory{}
+}
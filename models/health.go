@@ -0,0 +1,20 @@
+package models
+
+// Health statuses of the service and its components
+const (
+	UpStatus   = "up"
+	DownStatus = "down"
+)
+
+// Health is a status of the service, it is up if all its components are up
+type Health struct {
+	Status     string
+	Components map[string]ComponentHealth `json:",omitempty"`
+} // @name Health
+
+// ComponentHealth is a status of a part or a dependency of the service
+type ComponentHealth struct {
+	Status string
+	// Error describes why the component is down
+	Error string `json:",omitempty"`
+} // @name ComponentHealth
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// IdempotentResponse is a response to the first request with an idempotency key, it is replayed for retries of the request
+type IdempotentResponse struct {
+	// RequestHash identifies method, path and body of the request
+	RequestHash string
+	// Completed is false while the first request is processed
+	Completed   bool
+	StatusCode  int
+	ContentType string
+	// Location is a URL of the created resource
+	Location  string
+	Body      []byte
+	CreatedAt time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+// Image is a product image stored in blob storage, images of a product are ordered by their positions
+type Image struct {
+	Id           int64
+	URL          string
+	ThumbnailURL string
+	// Key and ThumbnailKey identify files of the image and its thumbnail in blob storage
+	Key          string `json:"-"`
+	ThumbnailKey string `json:"-"`
+} // @name Image
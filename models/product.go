@@ -3,23 +3,105 @@ package models
 type Product struct {
 	InputProduct
 	Id int64
+	// Status is one of "draft", "published" and "archived", it is changed with status transitions
+	Status string
+	Schedule
+	Images []*Image
 } // @name Product
 
 func NewProduct(SKU string, Name string, Type string, Cost uint, id int64) *Product {
-	return &Product{InputProduct{SKU, Name, Type, Cost}, id}
+	return &Product{InputProduct: InputProduct{SKU: SKU, Name: Name, Type: Type, Cost: Cost}, Id: id}
 }
 
 func EmptyProduct() *Product {
-	return &Product{*EmptyInputProduct(), 0}
+	return &Product{InputProduct: *EmptyInputProduct()}
 }
 
+// InputProduct is a product data sent by client.
+// If ParentSKU is specified the product is a variant of the parent product
+// and its Name and Type are inherited from the parent one.
 type InputProduct struct {
-	SKU  string
-	Name string
-	Type string
-	Cost uint
+	SKU         string
+	Name        string
+	Type        string
+	Cost        uint
+	Description string
+	ParentSKU   string
+	Attributes  map[string]string
 } // @name InputProduct
 
+// ReservedSKUs are paths of routes next to products by SKU, such products could not be read by SKU
+var ReservedSKUs = []string{"search", "suggestions", "scheduled"}
+
+// ReservedSKU reports if the SKU is used by a route
+func ReservedSKU(SKU string) bool {
+	for _, reserved := range ReservedSKUs {
+		if SKU == reserved {
+			return true
+		}
+	}
+	return false
+}
+
 func EmptyInputProduct() *InputProduct {
-	return &InputProduct{"", "", "", 0}
+	return &InputProduct{}
+}
+
+// ProductFilter contains conditions for selecting products from catalog
+type ProductFilter struct {
+	// Statuses contains statuses of selected products, products of any status are selected if it is empty
+	Statuses []string
+	Type     string
+	// Category is a name of category, which products must be assigned to
+	Category             string
+	IncludeSubcategories bool
+	// Tags contains tags, which products must be tagged with all of or, if AnyTag is true, with any of
+	Tags   []string
+	AnyTag bool
+	// Attributes contains values, which product attributes must be equal to
+	Attributes map[string]string
+	// AttributesMin and AttributesMax contain bounds of numeric product attributes
+	AttributesMin map[string]float64
+	AttributesMax map[string]float64
+}
+
+// ChangedFields returns names of the product fields, which are different in the input product.
+// Name and Type of variants are not compared, because they are inherited from the parent.
+func ChangedFields(prod *Product, input InputProduct) []string {
+	var fields []string
+	if prod.SKU != input.SKU {
+		fields = append(fields, "SKU")
+	}
+	if input.ParentSKU == "" && prod.Name != input.Name {
+		fields = append(fields, "Name")
+	}
+	if input.ParentSKU == "" && prod.Type != input.Type {
+		fields = append(fields, "Type")
+	}
+	if prod.Cost != input.Cost {
+		fields = append(fields, "Cost")
+	}
+	if prod.Description != input.Description {
+		fields = append(fields, "Description")
+	}
+	if prod.ParentSKU != input.ParentSKU {
+		fields = append(fields, "ParentSKU")
+	}
+	if !EqualAttributes(prod.Attributes, input.Attributes) {
+		fields = append(fields, "Attributes")
+	}
+	return fields
+}
+
+// EqualAttributes reports whether both products have the same attributes
+func EqualAttributes(a map[string]string, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, ok := b[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,56 @@
+package models
+
+// Permissions of operations, they are granted to clients by roles
+const (
+	// ReadProductsPermission allows reading products of any status and scheduled changes
+	ReadProductsPermission   = "products:read"
+	CreateProductsPermission = "products:create"
+	// UpdateProductsPermission allows changing any fields of products, UpdateCostPermission allows changing only Cost
+	UpdateProductsPermission = "products:update"
+	UpdateCostPermission     = "products:update-cost"
+	DeleteProductsPermission = "products:delete"
+	// EditCatalogPermission allows changing statuses, schedules, categories, tags, translations, images and schemas
+	EditCatalogPermission   = "catalog:edit"
+	ManageAPIKeysPermission = "apikeys:manage"
+)
+
+// Roles of clients
+const (
+	ViewerRole        = "viewer"
+	PricingEditorRole = "pricing-editor"
+	EditorRole        = "editor"
+	AdminRole         = "admin"
+)
+
+var rolePermissions = map[string][]string{
+	ViewerRole:        {ReadProductsPermission},
+	PricingEditorRole: {ReadProductsPermission, UpdateCostPermission},
+	EditorRole: {ReadProductsPermission, CreateProductsPermission, UpdateProductsPermission, UpdateCostPermission,
+		EditCatalogPermission},
+	AdminRole: {ReadProductsPermission, CreateProductsPermission, UpdateProductsPermission, UpdateCostPermission,
+		EditCatalogPermission, DeleteProductsPermission, ManageAPIKeysPermission},
+}
+
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RolePermissions returns the set of permissions granted by the roles, unknown roles grant nothing
+func RolePermissions(roles []string) map[string]bool {
+	permissions := make(map[string]bool)
+	for _, role := range roles {
+		for _, permission := range rolePermissions[role] {
+			permissions[permission] = true
+		}
+	}
+	return permissions
+}
+
+// FieldPermission returns the permission required to change the field of a product
+func FieldPermission(field string) string {
+	if field == "Cost" {
+		return UpdateCostPermission
+	}
+	return UpdateProductsPermission
+}
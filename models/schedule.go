@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Schedule contains times of automatic publishing and unpublishing of a product,
+// nil time means that the change is not scheduled
+type Schedule struct {
+	PublishAt   *time.Time
+	UnpublishAt *time.Time
+} // @name Schedule
+
+// ScheduledChange is an upcoming automatic change of a product status
+type ScheduledChange struct {
+	SKU    string
+	Status string
+	At     time.Time
+} // @name ScheduledChange
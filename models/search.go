@@ -0,0 +1,9 @@
+package models
+
+// SearchResult is a found product with highlighted matches in its name and description,
+// they are HTML with escaped text and matches enclosed in <mark></mark>
+type SearchResult struct {
+	Product
+	HighlightedName        string
+	HighlightedDescription string
+} // @name SearchResult
@@ -0,0 +1,36 @@
+package models
+
+// Statuses of the product lifecycle, only published products are shown in the catalog by default
+const (
+	DraftStatus     = "draft"
+	PublishedStatus = "published"
+	ArchivedStatus  = "archived"
+)
+
+// statusTransitions contains statuses, to which a product with the status can be moved.
+// Archived product has to be returned to draft before publishing again.
+var statusTransitions = map[string][]string{
+	DraftStatus:     {PublishedStatus, ArchivedStatus},
+	PublishedStatus: {DraftStatus, ArchivedStatus},
+	ArchivedStatus:  {DraftStatus},
+}
+
+// StatusTransition is a request to move a product to the status
+type StatusTransition struct {
+	Status string
+} // @name StatusTransition
+
+func ValidStatus(status string) bool {
+	_, ok := statusTransitions[status]
+	return ok
+}
+
+// CanTransition reports if a product can be moved from one status to another
+func CanTransition(from string, to string) bool {
+	for _, status := range statusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
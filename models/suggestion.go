@@ -0,0 +1,7 @@
+package models
+
+// Suggestion is a minimal description of a product suggested for typed prefix
+type Suggestion struct {
+	SKU  string
+	Name string
+} // @name Suggestion
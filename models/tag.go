@@ -0,0 +1,15 @@
+package models
+
+import "strings"
+
+// TagCount contains a tag and the number of products tagged with it
+type TagCount struct {
+	Tag   string
+	Count int
+} // @name TagCount
+
+// NormalizeTag returns the form of the tag in which it is stored,
+// so tags differing only in case and surrounding spaces are the same
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
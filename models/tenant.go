@@ -0,0 +1,13 @@
+package models
+
+import "regexp"
+
+// DefaultTenant owns the catalog of requests without a tenant and data created before tenants were introduced
+const DefaultTenant = "default"
+
+var tenantRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidTenant reports if the tenant id consists of up to 63 lowercase latin letters, digits, hyphens and underscores
+func ValidTenant(tenant string) bool {
+	return tenantRegexp.MatchString(tenant)
+}
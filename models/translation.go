@@ -0,0 +1,57 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Translation is a name and description of a product in a locale
+type Translation struct {
+	InputTranslation
+	Locale string
+} // @name Translation
+
+// InputTranslation is a translation data sent by client.
+// Empty description of a translation falls back to the next locale.
+type InputTranslation struct {
+	Name        string
+	Description string
+} // @name InputTranslation
+
+var localeRegexp = regexp.MustCompile(`^[a-z]{1,8}(-[a-z0-9]{1,8})*$`)
+
+func EmptyInputTranslation() *InputTranslation {
+	return &InputTranslation{}
+}
+
+// NormalizeLocale returns the form of the locale in which it is stored,
+// so "pt_BR", "pt-br" and "PT-BR" are the same locale
+func NormalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+// ValidLocale reports if the normalized locale is a language tag like "en" or "pt-br"
+func ValidLocale(locale string) bool {
+	return localeRegexp.MatchString(locale)
+}
+
+// LocaleFallbacks returns the chain of locales in which a translation is looked for:
+// every locale is followed by its less specific forms, e.g. "pt-br" is followed by "pt"
+func LocaleFallbacks(locales []string) []string {
+	unique := make(map[string]bool)
+	chain := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		for locale != "" {
+			if !unique[locale] {
+				unique[locale] = true
+				chain = append(chain, locale)
+			}
+			if i := strings.LastIndex(locale, "-"); i >= 0 {
+				locale = locale[:i]
+			} else {
+				locale = ""
+			}
+		}
+	}
+	return chain
+}
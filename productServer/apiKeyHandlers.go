@@ -0,0 +1,79 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// addAPIKey godoc
+// @Summary create API key
+// @Description The secret key is returned only once, it must be sent in X-API-Key header.
+// @Description Scopes grant roles: "read" grants viewer role, "write" grants editor role and "admin" grants admin role.
+// @Accept json
+// @Produces json
+// @Param key body models.InputAPIKey true "name and scopes of the key"
+// @Success 201 {object} models.NewAPIKey
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /apikeys [post]
+func (srv *ProductServer) addAPIKey(ctx *gin.Context) {
+	var input models.InputAPIKey
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.String(http.StatusBadRequest, "json format error: "+err.Error())
+		return
+	}
+	if key, err := tenantDB(ctx).AddAPIKey(ctx.Request.Context(), input); err == nil {
+		ctx.JSON(http.StatusCreated, key)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getAPIKeys godoc
+// @Summary get descriptions of all API keys including revoked ones
+// @Produces json
+// @Success 200 {array} models.APIKey
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /apikeys [get]
+func (srv *ProductServer) getAPIKeys(ctx *gin.Context) {
+	keys, err := tenantDB(ctx).GetAllAPIKeys(ctx.Request.Context())
+	if err == nil {
+		ctx.JSON(http.StatusOK, keys)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// revokeAPIKey godoc
+// @Summary revoke API key with specific id
+// @Param id path int true "Id of the key"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "not revoked key with such id does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /apikeys/{id} [delete]
+func (srv *ProductServer) revokeAPIKey(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := tenantDB(ctx).RevokeAPIKey(ctx.Request.Context(), id); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
@@ -0,0 +1,122 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+var anonymousClient = &http.Client{Transport: http.DefaultTransport}
+
+// apiKeyTransport adds API key header to all requests
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(apiKeyHeader, t.key)
+	return t.base.RoundTrip(req)
+}
+
+func TestAPIKeys(t *testing.T) {
+	product := models.InputProduct{SKU: "SECURED", Name: "Secured", Type: "Game", Cost: 10}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+
+	var readKey models.NewAPIKey
+	code, body := sendRequest(http.MethodPost, apiKeysUrl, models.InputAPIKey{Name: "reader", Scopes: []string{models.ReadScope}})
+	if code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &readKey); err != nil {
+		t.Fatal(err)
+	} else if readKey.Key == "" || readKey.Name != "reader" {
+		t.Errorf("Wrong created key: %v", readKey)
+	}
+	for _, input := range []models.InputAPIKey{{Name: "", Scopes: []string{models.ReadScope}}, {Name: "wrong", Scopes: []string{"root"}}} {
+		if code, _ := sendRequest(http.MethodPost, apiKeysUrl, input); code != http.StatusBadRequest {
+			t.Errorf("not 400 code for wrong key %v: %d", input, code)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		key    string
+		method string
+		url    string
+		body   interface{}
+		code   int
+	}{
+		{"anonymous read", "", http.MethodGet, baseUrl + "/SECURED", nil, http.StatusOK},
+		{"anonymous write", "", http.MethodPut, baseUrl + "/SECURED", product, http.StatusUnauthorized},
+		{"anonymous drafts", "", http.MethodGet, baseUrl + "?status=all", nil, http.StatusUnauthorized},
+		{"anonymous keys", "", http.MethodGet, apiKeysUrl, nil, http.StatusUnauthorized},
+		{"wrong key", "wrong", http.MethodGet, baseUrl + "/SECURED", nil, http.StatusUnauthorized},
+		{"reader drafts", readKey.Key, http.MethodGet, baseUrl + "?status=all", nil, http.StatusOK},
+		{"reader write", readKey.Key, http.MethodPut, baseUrl + "/SECURED", product, http.StatusForbidden},
+		{"reader keys", readKey.Key, http.MethodGet, apiKeysUrl, nil, http.StatusForbidden},
+	}
+	for _, c := range cases {
+		if code, body := sendRequestWithKey(c.key, c.method, c.url, c.body); code != c.code {
+			t.Errorf("%s: expected %d code, got %d\nResponse body: %s", c.name, c.code, code, body)
+		}
+	}
+
+	var keys []models.APIKey
+	if code, body := sendRequest(http.MethodGet, apiKeysUrl, nil); code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &keys); err != nil {
+		t.Error(err)
+	} else if len(keys) < 2 || keys[len(keys)-1].Id != readKey.Id || keys[len(keys)-1].RevokedAt != nil {
+		t.Errorf("Wrong keys: %v", keys)
+	}
+
+	keyUrl := apiKeysUrl + "/" + strconv.FormatInt(readKey.Id, 10)
+	if code, body := sendRequest(http.MethodDelete, keyUrl, nil); code != http.StatusNoContent {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	if code, _ := sendRequest(http.MethodDelete, keyUrl, nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for revoking revoked key: %d", code)
+	}
+	if code, _ := sendRequestWithKey(readKey.Key, http.MethodGet, baseUrl+"/SECURED", nil); code != http.StatusUnauthorized {
+		t.Errorf("not 401 code for revoked key: %d", code)
+	}
+}
+
+// sendRequestWithKey sends request with the API key or without any key if it is empty
+func sendRequestWithKey(key string, method string, url string, body interface{}) (int, string) {
+	return sendRequestWithHeaders(map[string]string{apiKeyHeader: key}, method, url, body)
+}
+
+// sendRequestWithHeaders sends request without the API key of tests, headers with empty values are not sent
+func sendRequestWithHeaders(headers map[string]string, method string, url string, body interface{}) (int, string) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		bodyReader = bytes.NewBuffer(jsonBody)
+	}
+	request, err := http.NewRequest(method, url, bodyReader)
+	if err != nil {
+		return 0, err.Error()
+	}
+	for header, value := range headers {
+		if value != "" {
+			request.Header.Set(header, value)
+		}
+	}
+	resp, err := anonymousClient.Do(request)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	bodyData, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(bodyData)
+}
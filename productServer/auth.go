@@ -0,0 +1,229 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/models"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiKeyHeader is a request header containing API key of the client
+const apiKeyHeader = "X-API-Key"
+
+// principalContextKey is a key of the authenticated *principal in gin context
+const principalContextKey = "principal"
+
+// principal is an authenticated client of the request
+type principal struct {
+	// Subject identifies the client for auditing, it is sub claim of a token or "apikey:" and name of an API key
+	Subject string
+	// Tenant is the only tenant, which catalog the client can access
+	Tenant string
+	// Roles are roles of a token or roles granted by scopes of an API key
+	Roles []string
+	// Client identifies the client for rate limiting and idempotency keys, it is "key:" and id of an API key or "sub:", tenant and subject of a token
+	Client      string
+	permissions map[string]bool
+}
+
+func newPrincipal(subject string, tenant string, roles []string, client string) *principal {
+	return &principal{Subject: subject, Tenant: tenant, Roles: roles, Client: client, permissions: models.RolePermissions(roles)}
+}
+
+// Can reports if the client has the permission
+func (client *principal) Can(permission string) bool {
+	return client.permissions[permission]
+}
+
+// authenticate finds the API key or the bearer token of the request, requests without them are anonymous
+func (srv *ProductServer) authenticate(ctx *gin.Context) {
+	if key := ctx.GetHeader(apiKeyHeader); key != "" {
+		apiKey, err := srv.db.GetAPIKey(ctx.Request.Context(), key)
+		if err == DB.APIKeyNotFoundError {
+			srv.rejectCredentials(ctx, "invalid or revoked API key")
+			return
+		} else if err != nil {
+			ctx.String(getHttpCodeFromError(err), err.Error())
+			ctx.Abort()
+			return
+		}
+		roles := make([]string, 0, len(apiKey.Scopes))
+		for _, scope := range apiKey.Scopes {
+			roles = append(roles, models.ScopeRoles[scope])
+		}
+		ctx.Set(principalContextKey, newPrincipal("apikey:"+apiKey.Name, apiKey.Tenant, roles, "key:"+strconv.FormatInt(apiKey.Id, 10)))
+	} else if token := bearerToken(ctx); token != "" && srv.tokens != nil {
+		claims, err := srv.tokens.Verify(token, time.Now())
+		if err != nil {
+			ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			srv.rejectCredentials(ctx, err.Error())
+			return
+		}
+		// Names of API key scopes are also accepted as roles
+		var roles []string
+		for _, role := range claims.Roles {
+			if models.ValidRole(role) {
+				roles = append(roles, role)
+			} else if models.ValidScope(role) {
+				roles = append(roles, models.ScopeRoles[role])
+			}
+		}
+		ctx.Set(principalContextKey, newPrincipal(claims.Subject, claims.Tenant, roles, "sub:"+claims.Tenant+"/"+claims.Subject))
+	}
+}
+
+// rejectCredentials responds with 401 status code to a request with invalid credentials. Such requests are counted
+// by rate limits of the IP address, so guessing keys and tokens is limited like anonymous requests.
+func (srv *ProductServer) rejectCredentials(ctx *gin.Context, message string) {
+	if srv.allowRequest(ctx, "ip:"+ctx.ClientIP()) {
+		ctx.String(http.StatusUnauthorized, message)
+	}
+	ctx.Abort()
+}
+
+// bearerToken returns the token of Authorization header with Bearer scheme
+func bearerToken(ctx *gin.Context) string {
+	authorization := ctx.GetHeader("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+// getPrincipal returns the authenticated client of the request or nil for anonymous requests
+func getPrincipal(ctx *gin.Context) *principal {
+	if value, ok := ctx.Get(principalContextKey); ok {
+		return value.(*principal)
+	}
+	return nil
+}
+
+// clientOf returns the identifier of the authenticated client or "ip:" and IP address of an anonymous one
+func clientOf(ctx *gin.Context) string {
+	if principal := getPrincipal(ctx); principal != nil {
+		return principal.Client
+	}
+	return "ip:" + ctx.ClientIP()
+}
+
+// requirePermission returns middleware rejecting requests of clients without the permission
+func requirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !authorize(ctx, permission) {
+			ctx.Abort()
+		}
+	}
+}
+
+// authorize responds with an error and returns false if the client does not have the permission
+func authorize(ctx *gin.Context, permission string) bool {
+	if code, err := checkPermission(ctx, permission); err != nil {
+		ctx.String(code, err.Error())
+		return false
+	}
+	return true
+}
+
+// checkPermission returns 401 code for anonymous requests and 403 code for clients without the permission
+func checkPermission(ctx *gin.Context, permission string) (int, error) {
+	client := getPrincipal(ctx)
+	if client == nil {
+		return http.StatusUnauthorized, errors.New("API key or bearer token must be sent to get " + permission + " permission")
+	}
+	if !client.Can(permission) {
+		return http.StatusForbidden, missingPermissionError(client, permission, "")
+	}
+	return http.StatusOK, nil
+}
+
+// authorizeUpdate checks permissions required to change fields of the product, which are different in the input.
+// If the product is not found, any update permission is enough for the error to be reported by the update.
+func authorizeUpdate(ctx *gin.Context, prod *models.Product, err error, input models.InputProduct) bool {
+	fields := []string{"Cost"}
+	if err == nil {
+		if changed := models.ChangedFields(prod, input); len(changed) != 0 {
+			fields = changed
+		}
+	}
+	client := getPrincipal(ctx)
+	if client == nil {
+		return authorize(ctx, models.FieldPermission(fields[0]))
+	}
+	var deniedFields []string
+	missingPermission := models.UpdateCostPermission
+	for _, field := range fields {
+		if permission := models.FieldPermission(field); !client.Can(permission) {
+			deniedFields = append(deniedFields, field)
+			if permission != models.UpdateCostPermission {
+				missingPermission = permission
+			}
+		}
+	}
+	if len(deniedFields) != 0 {
+		reason := " to change " + strings.Join(deniedFields, ", ")
+		ctx.String(http.StatusForbidden, missingPermissionError(client, missingPermission, reason).Error())
+		return false
+	}
+	return true
+}
+
+func missingPermissionError(client *principal, permission string, reason string) error {
+	roles := strings.Join(client.Roles, ", ")
+	if roles == "" {
+		roles = "no roles"
+	}
+	return errors.New("permission " + permission + " is required" + reason + ", " + client.Subject + " has " + roles)
+}
+
+// canReadUnpublished reports if the client can read products of any status
+func canReadUnpublished(ctx *gin.Context) bool {
+	client := getPrincipal(ctx)
+	return client != nil && client.Can(models.ReadProductsPermission)
+}
+
+// checkProductVisible reports products, which are not published, as not found to clients without products:read
+// permission, so products scheduled for publishing can not be read before that
+func checkProductVisible(ctx *gin.Context, product *models.Product) error {
+	if product.Status != models.PublishedStatus && !canReadUnpublished(ctx) {
+		return DB.ProductNotFoundError
+	}
+	return nil
+}
+
+// checkSKUVisible checks the product with the SKU like checkProductVisible before reading its data
+func checkSKUVisible(ctx *gin.Context, SKU string) error {
+	if canReadUnpublished(ctx) {
+		return nil
+	}
+	product, err := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), SKU)
+	if err != nil {
+		return err
+	}
+	return checkProductVisible(ctx, product)
+}
+
+// visibleProducts returns published products to clients without products:read permission
+func visibleProducts(ctx *gin.Context, products []*models.Product) []*models.Product {
+	if canReadUnpublished(ctx) {
+		return products
+	}
+	visible := make([]*models.Product, 0, len(products))
+	for _, product := range products {
+		if product.Status == models.PublishedStatus {
+			visible = append(visible, product)
+		}
+	}
+	return visible
+}
+
+// checkStatusesAccess allows anonymous clients to select only published products
+func checkStatusesAccess(ctx *gin.Context, filter models.ProductFilter) (int, error) {
+	if len(filter.Statuses) == 1 && filter.Statuses[0] == models.PublishedStatus {
+		return http.StatusOK, nil
+	}
+	return checkPermission(ctx, models.ReadProductsPermission)
+}
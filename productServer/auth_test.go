@@ -0,0 +1,268 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/jwtAuth"
+	"XsollaSchoolBE/models"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testJWKSFile = "testJWKS.json"
+
+// Keys of the test identity provider, public keys of rsaKey and ecKey are in JWKS file
+var rsaKey *rsa.PrivateKey
+var ecKey *ecdsa.PrivateKey
+var testSecret = []byte("test secret")
+
+// initTestTokens creates keys of the test identity provider and returns the config of its tokens verification
+func initTestTokens() (jwtAuth.Config, error) {
+	var err error
+	if rsaKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+		return jwtAuth.Config{}, err
+	}
+	if ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+		return jwtAuth.Config{}, err
+	}
+	jwks := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": encodeSegment(rsaKey.N.Bytes()),
+			"e": encodeSegment(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": encodeSegment(ecKey.X.Bytes()), "y": encodeSegment(ecKey.Y.Bytes())},
+	}}
+	data, _ := json.Marshal(jwks)
+	if err := ioutil.WriteFile(testJWKSFile, data, 0644); err != nil {
+		return jwtAuth.Config{}, err
+	}
+	// Tokens of tests are issued without tenant claim for the default tenant
+	return jwtAuth.Config{Issuer: "https://id.test", Audience: "products", JWKS: testJWKSFile, DefaultTenant: models.DefaultTenant}, nil
+}
+
+func TestBearerTokens(t *testing.T) {
+	product := models.InputProduct{SKU: "TOKENED", Name: "Tokened", Type: "Game", Cost: 10}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+
+	now := time.Now().Unix()
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		result := map[string]interface{}{
+			"iss": "https://id.test", "aud": []string{"account", "products"}, "sub": "user1",
+			"exp": now + 60, "roles": []string{"write"},
+		}
+		for name, value := range changes {
+			result[name] = value
+		}
+		return result
+	}
+	cases := []struct {
+		name  string
+		token string
+		code  int
+	}{
+		{"RS256", signToken("RS256", "rsa", claims(nil)), http.StatusOK},
+		{"ES256", signToken("ES256", "ec", claims(nil)), http.StatusOK},
+		{"PS384", signToken("PS384", "rsa", claims(nil)), http.StatusOK},
+		{"string audience", signToken("RS256", "rsa", claims(map[string]interface{}{"aud": "products"})), http.StatusOK},
+		{"reader", signToken("RS256", "rsa", claims(map[string]interface{}{"roles": []string{"read"}})), http.StatusForbidden},
+		{"no roles", signToken("RS256", "rsa", claims(map[string]interface{}{"roles": nil})), http.StatusForbidden},
+		{"expired", signToken("RS256", "rsa", claims(map[string]interface{}{"exp": now - 120})), http.StatusUnauthorized},
+		{"not valid yet", signToken("RS256", "rsa", claims(map[string]interface{}{"nbf": now + 120})), http.StatusUnauthorized},
+		{"no expiry", signToken("RS256", "rsa", claims(map[string]interface{}{"exp": nil})), http.StatusUnauthorized},
+		{"wrong issuer", signToken("RS256", "rsa", claims(map[string]interface{}{"iss": "https://other.test"})), http.StatusUnauthorized},
+		{"wrong audience", signToken("RS256", "rsa", claims(map[string]interface{}{"aud": "other"})), http.StatusUnauthorized},
+		{"no subject", signToken("RS256", "rsa", claims(map[string]interface{}{"sub": nil})), http.StatusUnauthorized},
+		{"unknown key", signToken("RS256", "other", claims(nil)), http.StatusUnauthorized},
+		{"algorithm of other key", signToken("ES256", "rsa", claims(nil)), http.StatusUnauthorized},
+		{"untrusted secret", signToken("HS256", "", claims(nil)), http.StatusUnauthorized},
+		{"none algorithm", signToken("none", "", claims(nil)), http.StatusUnauthorized},
+		{"tenant", signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": models.DefaultTenant})), http.StatusOK},
+		{"tenant array", signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": []string{models.DefaultTenant}})), http.StatusOK},
+		{"several tenants", signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": []string{"acme", "globex"}})), http.StatusUnauthorized},
+		{"space separated tenants", signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": "acme globex"})), http.StatusUnauthorized},
+		{"numeric tenant", signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": 42})), http.StatusUnauthorized},
+		{"empty tenant", signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": ""})), http.StatusUnauthorized},
+		{"malformed", "not.a.token", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		code, body := sendRequestWithToken(c.token, http.MethodPut, baseUrl+"/TOKENED", product)
+		if code != c.code {
+			t.Errorf("%s: expected %d code, got %d\nResponse body: %s", c.name, c.code, code, body)
+		}
+	}
+
+	// Tokens without tenant claim are rejected unless the default tenant is configured
+	verifier, err := jwtAuth.NewVerifier(jwtAuth.Config{Issuer: "https://id.test", Audience: "products", JWKS: testJWKSFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifier.Verify(signToken("RS256", "rsa", claims(nil)), time.Now()); !errors.Is(err, jwtAuth.InvalidTokenError) {
+		t.Errorf("Token without tenant is accepted: %v", err)
+	}
+	if verified, err := verifier.Verify(signToken("RS256", "rsa", claims(map[string]interface{}{"tenant": "acme"})), time.Now()); err != nil {
+		t.Error(err)
+	} else if verified.Tenant != "acme" {
+		t.Errorf("Wrong tenant of token: %q", verified.Tenant)
+	}
+
+	reader := signToken("RS256", "rsa", claims(map[string]interface{}{"roles": []string{"read"}}))
+	if code, body := sendRequestWithToken(reader, http.MethodGet, baseUrl+"?status=all", nil); code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	product := models.InputProduct{SKU: "GUARDED", Name: "Guarded", Type: "Game", Cost: 10, Description: "Guarded game"}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+	token := func(role string) string {
+		return signToken("RS256", "rsa", map[string]interface{}{
+			"iss": "https://id.test", "aud": "products", "sub": role + "1", "exp": time.Now().Unix() + 60, "roles": []string{role},
+		})
+	}
+	newCost, newName := product, product
+	newCost.Cost = 20
+	newName.Name = "Renamed"
+	newName.Cost = 20
+	created := models.InputProduct{SKU: "GUARDED2", Name: "Guarded2", Type: "Game", Cost: 5}
+
+	cases := []struct {
+		name    string
+		role    string
+		method  string
+		url     string
+		body    interface{}
+		code    int
+		message string
+	}{
+		{"viewer changes cost", "viewer", http.MethodPut, baseUrl + "/GUARDED", newCost, http.StatusForbidden, "permission products:update-cost is required to change Cost"},
+		{"pricing editor changes cost", "pricing-editor", http.MethodPut, baseUrl + "/GUARDED", newCost, http.StatusOK, ""},
+		{"pricing editor changes name", "pricing-editor", http.MethodPut, baseUrl + "?sku=GUARDED", newName, http.StatusForbidden, "permission products:update is required to change Name, pricing-editor1 has pricing-editor"},
+		{"pricing editor creates", "pricing-editor", http.MethodPost, baseUrl, created, http.StatusForbidden, "permission products:create is required"},
+		{"pricing editor changes status", "pricing-editor", http.MethodPut, baseUrl + "/GUARDED/status", models.StatusTransition{Status: models.DraftStatus}, http.StatusForbidden, "permission catalog:edit is required"},
+		{"editor changes name", "editor", http.MethodPut, baseUrl + "/GUARDED", newName, http.StatusOK, ""},
+		{"editor creates", "editor", http.MethodPost, baseUrl, created, http.StatusCreated, ""},
+		{"editor deletes", "editor", http.MethodDelete, baseUrl + "/GUARDED2", nil, http.StatusForbidden, "permission products:delete is required"},
+		{"admin deletes", "admin", http.MethodDelete, baseUrl + "?sku=GUARDED2", nil, http.StatusNoContent, ""},
+		{"unknown role", "owner", http.MethodPut, baseUrl + "/GUARDED", newCost, http.StatusForbidden, "owner1 has no roles"},
+		{"not existing product", "pricing-editor", http.MethodPut, baseUrl + "/UNKNOWN", newCost, http.StatusNotFound, ""},
+	}
+	for _, c := range cases {
+		code, body := sendRequestWithToken(token(c.role), c.method, c.url, c.body)
+		if code != c.code || !strings.Contains(body, c.message) {
+			t.Errorf("%s: expected %d code and message %q, got %d\nResponse body: %s", c.name, c.code, c.message, code, body)
+		}
+	}
+	if code, _ := sendRequestWithKey("", http.MethodPut, baseUrl+"/GUARDED", newCost); code != http.StatusUnauthorized {
+		t.Errorf("not 401 code for anonymous update: %d", code)
+	}
+}
+
+func TestUpdateOfChangedProduct(t *testing.T) {
+	product := models.InputProduct{SKU: "RACED", Name: "Raced", Type: "Game", Cost: 10, Attributes: map[string]string{"genre": "rpg"}}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+	ctx, db := context.Background(), testServer.db.WithTenant(models.DefaultTenant)
+	// A pricing editor is authorized to change the cost of the product read before an editor renames it
+	authorized, err := db.GetProductBySKU(ctx, product.SKU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamed := product
+	renamed.Name = "Renamed"
+	if code, body := sendRequest(http.MethodPut, baseUrl+"/RACED", renamed); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	newCost := product
+	newCost.Cost = 20
+	if _, err = db.UpdateProductBySKU(ctx, product.SKU, newCost, authorized); !errors.Is(err, DB.ProductChangedError) {
+		t.Fatalf("Update of the renamed product: %v", err)
+	}
+	// Attributes are compared too
+	retagged := renamed
+	retagged.Attributes = map[string]string{"genre": "action"}
+	if code, body := sendRequest(http.MethodPut, baseUrl+"/RACED", retagged); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	newCost.Name = renamed.Name
+	if authorized, err = db.GetProductBySKU(ctx, product.SKU); err != nil {
+		t.Fatal(err)
+	}
+	authorized.Attributes = product.Attributes
+	if _, err = db.UpdateProductBySKU(ctx, product.SKU, newCost, authorized); !errors.Is(err, DB.ProductChangedError) {
+		t.Fatalf("Update of the product with changed attributes: %v", err)
+	}
+	if prod, err := db.GetProductBySKU(ctx, product.SKU); err != nil {
+		t.Fatal(err)
+	} else if prod.Name != renamed.Name || prod.Cost != product.Cost || prod.Attributes["genre"] != "action" {
+		t.Errorf("Changes of the editor are reverted: %+v", prod)
+	}
+	// The update is applied to the product, which is not changed since reading
+	newCost.Attributes = retagged.Attributes
+	if prod, err := db.UpdateProductBySKU(ctx, product.SKU, newCost, nil); err != nil || prod.Cost != newCost.Cost {
+		t.Errorf("Update of the unchanged product: %v", err)
+	}
+}
+
+// signToken makes a token with the algorithm and key id, signed by the key of the test provider
+func signToken(alg string, kid string, claims map[string]interface{}) string {
+	head, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := encodeSegment(head) + "." + encodeSegment(payload)
+	digest := hashOf(alg, []byte(signed))
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest)
+	case "PS384":
+		signature, _ = rsa.SignPSS(rand.Reader, rsaKey, crypto.SHA384, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES256":
+		r, s, _ := ecdsa.Sign(rand.Reader, ecKey, digest)
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case "HS256":
+		mac := hmac.New(sha256.New, testSecret)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+	return signed + "." + encodeSegment(signature)
+}
+
+func hashOf(alg string, data []byte) []byte {
+	hash := crypto.SHA256
+	if alg == "PS384" {
+		hash = crypto.SHA384
+	}
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func encodeSegment(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sendRequestWithToken sends request with the bearer token instead of the API key of tests
+func sendRequestWithToken(token string, method string, url string, body interface{}) (int, string) {
+	return sendRequestWithHeaders(map[string]string{"Authorization": "Bearer " + token}, method, url, body)
+}
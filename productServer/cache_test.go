@@ -0,0 +1,141 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/models"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProductCache(t *testing.T) {
+	cache, ok := testServer.db.(DB.CacheStatistics)
+	if !ok {
+		t.Fatal("Products are not cached")
+	}
+	product := models.InputProduct{SKU: "CACHED1", Name: "Cached", Type: "Game", Cost: 10}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+	productUrl := baseUrl + "/" + product.SKU
+	pageUrl := baseUrl + "?tag=cached&groupSize=10&groupNum=1"
+
+	// Repeated lookups are served from the cache
+	before := cache.CacheStats()
+	for i := 0; i < 2; i++ {
+		if _, err, _ := getProductFromURL(productUrl); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := getProductsFromURL(pageUrl); err != nil {
+			t.Fatal(err)
+		}
+	}
+	after := cache.CacheStats()
+	for _, operation := range []string{"GetProductBySKU", "GetGroupOfProducts"} {
+		if after.Hits[operation] == before.Hits[operation] || after.Misses[operation] == before.Misses[operation] {
+			t.Errorf("Lookups of %s are not counted: %+v", operation, after)
+		}
+	}
+
+	// Changes of products are visible immediately
+	product.Cost = 20
+	if code, body := sendRequest(http.MethodPut, productUrl, product); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	if prod, err, _ := getProductFromURL(productUrl); err != nil {
+		t.Fatal(err)
+	} else if prod.Cost != 20 {
+		t.Errorf("Updated product is not returned: %v", prod)
+	}
+	if code, body := sendRequest(http.MethodPut, productUrl+"/tags/cached", nil); code != http.StatusNoContent {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	if products, err := getProductsFromURL(pageUrl); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 1 || products[0].SKU != product.SKU {
+		t.Errorf("Tagged product is not found: %v", products)
+	}
+
+	// Localization of a cached product does not change the cached one
+	translation := models.InputTranslation{Name: "Zwischengespeichert"}
+	if code, body := sendRequest(http.MethodPut, productUrl+"/translations/de", translation); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	for _, acceptLanguage := range []string{"de", ""} {
+		expected := map[string]string{"de": translation.Name, "": product.Name}[acceptLanguage]
+		if products, err := getLocalizedProducts(productUrl, acceptLanguage); err != nil {
+			t.Fatal(err)
+		} else if len(products) != 1 || products[0].Name != expected {
+			t.Errorf("Wrong product for Accept-Language %q: %v", acceptLanguage, products)
+		}
+	}
+
+	// Scheduled changes drop results only of tenants of changed products
+	const productLookup = "GetProductBySKU"
+	other := testServer.db.WithTenant("cache-other")
+	otherProduct := models.InputProduct{SKU: "CACHED2", Name: "Cached elsewhere", Type: "Game", Cost: 10}
+	if _, err := other.AddProduct(context.Background(), otherProduct); err != nil {
+		t.Fatal(err)
+	}
+	defer other.DeleteProductBySKU(context.Background(), otherProduct.SKU)
+	unpublishAt := time.Now().Add(time.Hour)
+	if code, body := sendRequest(http.MethodPut, productUrl+"/schedule", models.Schedule{UnpublishAt: &unpublishAt}); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	lookUp := func() *models.Product {
+		if _, err := other.GetProductBySKU(context.Background(), otherProduct.SKU); err != nil {
+			t.Fatal(err)
+		}
+		prod, err, _ := getProductFromURL(productUrl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return prod
+	}
+	lookUp()
+	if _, _, err := testServer.db.ApplyScheduledChanges(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	before = cache.CacheStats()
+	lookUp()
+	if after = cache.CacheStats(); after.Hits[productLookup] != before.Hits[productLookup]+2 {
+		t.Errorf("Results are dropped without applied changes: %+v", after)
+	}
+	_, tenants, err := testServer.db.ApplyScheduledChanges(context.Background(), unpublishAt.Add(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	} else if len(tenants) != 1 || tenants[0] != models.DefaultTenant {
+		t.Errorf("Wrong tenants of applied changes: %v", tenants)
+	}
+	before = cache.CacheStats()
+	if prod := lookUp(); prod.Status != models.DraftStatus {
+		t.Errorf("Unpublished product is returned from the cache: %v", prod)
+	}
+	if after = cache.CacheStats(); after.Hits[productLookup] != before.Hits[productLookup]+1 || after.Misses[productLookup] != before.Misses[productLookup]+1 {
+		t.Errorf("Wrong results are dropped after applied changes: %+v", after)
+	}
+
+	deleteProduct(product.SKU)
+	if _, _, code := getProductFromURL(productUrl); code != http.StatusNotFound {
+		t.Errorf("Deleted product is returned with status code %d", code)
+	}
+	if products, err := getProductsFromURL(pageUrl); err != nil {
+		t.Fatal(err)
+	} else if len(products) != 0 {
+		t.Errorf("Deleted product is found: %v", products)
+	}
+
+	_, body := sendRequest(http.MethodGet, serverUrl+"/metrics", nil)
+	for _, sample := range []string{
+		`db_cache_requests_total{operation="GetProductBySKU",result="hit"} `,
+		`db_cache_requests_total{operation="GetGroupOfProducts",result="miss"} `,
+		`db_cache_entries `,
+	} {
+		if !strings.Contains(body, sample) {
+			t.Errorf("Metrics do not contain %s", sample)
+		}
+	}
+}
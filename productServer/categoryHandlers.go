@@ -0,0 +1,227 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+// addCategory godoc
+// @Summary add new category
+// @Accept json
+// @Produces json
+// @Param category body models.InputCategory true "adding category, ParentId is 0 for root categories"
+// @Param Idempotency-Key header string false "unique key of the operation, retries with the same key get the first response"
+// @Success 201 {object} models.Category "Category has been created"
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 409 {object} string
+// @Failure 422 {object} string "idempotency key is used for another request"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /categories [post]
+func (srv *ProductServer) addCategory(ctx *gin.Context) {
+	newCategory, err := getInputCategoryFromBody(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if category, err := tenantDB(ctx).AddCategory(ctx.Request.Context(), *newCategory); err == nil {
+		ctx.Header("Location", "/categories/"+strconv.FormatInt(category.Id, 10))
+		ctx.JSON(http.StatusCreated, category)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getCategories godoc
+// @Summary get all categories
+// @Produces json
+// @Success 200 {array} models.Category
+// @Failure 500 {object} string
+// @Router /categories [get]
+func (srv *ProductServer) getCategories(ctx *gin.Context) {
+	categories, err := tenantDB(ctx).GetAllCategories(ctx.Request.Context())
+	if err == nil {
+		ctx.JSON(http.StatusOK, categories)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getCategory godoc
+// @Summary get category with specific id
+// @Produces json
+// @Param id path int true "Id of searching category"
+// @Success 200 {object} models.Category
+// @Failure 400 {object} string
+// @Failure 404 {object} string "category with such id does not exist"
+// @Failure 500 {object} string
+// @Router /categories/{id} [get]
+func (srv *ProductServer) getCategory(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if category, err := tenantDB(ctx).GetCategoryById(ctx.Request.Context(), id); err == nil {
+		ctx.JSON(http.StatusOK, category)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// updateCategory godoc
+// @Summary rename category with specific id or move it to another parent category
+// @Accept json
+// @Produces json
+// @Param id path int true "Id of updating category"
+// @Param category body models.InputCategory true "new category data, ParentId is 0 for root categories"
+// @Success 200 {object} models.Category "Category has been updated"
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string
+// @Failure 409 {object} string
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /categories/{id} [put]
+func (srv *ProductServer) updateCategory(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	newCategory, err := getInputCategoryFromBody(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if category, err := tenantDB(ctx).UpdateCategory(ctx.Request.Context(), id, *newCategory); err == nil {
+		ctx.JSON(http.StatusOK, category)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// deleteCategory godoc
+// @Summary delete category with specific id
+// @Description Subcategories of the deleting category are moved to its parent category
+// @Param id path int true "Id of deleting category"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "category with such id does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /categories/{id} [delete]
+func (srv *ProductServer) deleteCategory(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := tenantDB(ctx).DeleteCategory(ctx.Request.Context(), id); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getProductCategories godoc
+// @Summary get categories which the product with specific SKU is assigned to
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Success 200 {array} models.Category
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Router /products/{SKU}/categories [get]
+func (srv *ProductServer) getProductCategories(ctx *gin.Context) {
+	var categories []*models.Category
+	err := checkSKUVisible(ctx, ctx.Param("SKU"))
+	if err == nil {
+		categories, err = tenantDB(ctx).GetProductCategories(ctx.Request.Context(), ctx.Param("SKU"))
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, categories)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// addProductToCategory godoc
+// @Summary assign the product with specific SKU to the category
+// @Param SKU path string true "SKU of the product"
+// @Param id path int true "Id of the category"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product or category does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/categories/{id} [put]
+func (srv *ProductServer) addProductToCategory(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := tenantDB(ctx).AddProductToCategory(ctx.Request.Context(), ctx.Param("SKU"), id); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// removeProductFromCategory godoc
+// @Summary remove the product with specific SKU from the category
+// @Param SKU path string true "SKU of the product"
+// @Param id path int true "Id of the category"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product or category does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/categories/{id} [delete]
+func (srv *ProductServer) removeProductFromCategory(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := tenantDB(ctx).RemoveProductFromCategory(ctx.Request.Context(), ctx.Param("SKU"), id); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+func getInputCategoryFromBody(ctx *gin.Context) (*models.InputCategory, error) {
+	category := models.EmptyInputCategory()
+	if err := ctx.ShouldBindJSON(category); err != nil {
+		return nil, errors.New("json format error: " + err.Error())
+	} else if category.Name == "" {
+		return nil, errors.New("category name must be specified")
+	}
+	return category, nil
+}
+
+func getIdFromPath(ctx *gin.Context, param string) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Param(param), 10, 64)
+	if err != nil {
+		return 0, errors.New(param + " parameter type error:" + err.Error())
+	}
+	return id, nil
+}
@@ -0,0 +1,97 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestCategories(t *testing.T) {
+	games := addTestCategory(t, models.InputCategory{Name: "games"})
+	indie := addTestCategory(t, models.InputCategory{Name: "indie", ParentId: games.Id})
+	puzzle := addTestCategory(t, models.InputCategory{Name: "puzzle", ParentId: indie.Id})
+	for _, category := range []*models.Category{games, indie, puzzle} {
+		defer sendRequest(http.MethodDelete, categoryUrl(category.Id), nil)
+	}
+
+	if code, _ := sendRequest(http.MethodPost, categoriesUrl, models.InputCategory{Name: "games"}); code != http.StatusConflict {
+		t.Errorf("not 409 code for existing category: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodPost, categoriesUrl, models.InputCategory{Name: "rpg", ParentId: 999999}); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for nonexistent parent category: %d", code)
+	}
+
+	products := []models.InputProduct{
+		{SKU: "CATEGORY1", Name: "Indie game", Type: "Game", Cost: 10},
+		{SKU: "CATEGORY2", Name: "Game", Type: "Game", Cost: 60},
+	}
+	for i, prod := range products {
+		if code, body := postProduct(prod); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+		defer deleteProduct(prod.SKU)
+		categoryId := []int64{indie.Id, games.Id}[i]
+		url := baseUrl + "/" + prod.SKU + "/categories/" + strconv.FormatInt(categoryId, 10)
+		if code, body := sendRequest(http.MethodPut, url, nil); code != http.StatusNoContent {
+			t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+	}
+
+	for url, expectedCount := range map[string]int{
+		baseUrl + "?category=games":                            1,
+		baseUrl + "?category=games&includeSubcategories=true":  2,
+		baseUrl + "?category=puzzle&includeSubcategories=true": 0,
+		baseUrl + "?category=indie&includeSubcategories=false": 1,
+	} {
+		if filtered, err := getProductsFromURL(url); err != nil {
+			t.Error(err)
+		} else if len(filtered) != expectedCount {
+			t.Errorf("Wrong number of products for %s: %d", url, len(filtered))
+		}
+	}
+
+	moved := models.InputCategory{Name: "games", ParentId: puzzle.Id}
+	if code, _ := sendRequest(http.MethodPut, categoryUrl(games.Id), moved); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for moving category into its subcategory: %d", code)
+	}
+	renamed := models.InputCategory{Name: "independent", ParentId: games.Id}
+	if code, body := sendRequest(http.MethodPut, categoryUrl(indie.Id), renamed); code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+
+	if code, _ := sendRequest(http.MethodDelete, categoryUrl(indie.Id), nil); code != http.StatusNoContent {
+		t.Errorf("Bad status code: %d", code)
+	}
+	code, body := sendRequest(http.MethodGet, categoryUrl(puzzle.Id), nil)
+	var receivedPuzzle models.Category
+	if code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &receivedPuzzle); err != nil {
+		t.Error(err)
+	} else if receivedPuzzle.ParentId != games.Id {
+		t.Errorf("subcategory of deleted category has not been moved to its parent: %v", receivedPuzzle)
+	}
+	if filtered, err := getProductsFromURL(baseUrl + "?category=games&includeSubcategories=true"); err != nil {
+		t.Error(err)
+	} else if len(filtered) != 1 {
+		t.Errorf("product of deleted category is still assigned to it: %v", filtered)
+	}
+}
+
+func addTestCategory(t *testing.T, category models.InputCategory) *models.Category {
+	code, body := sendRequest(http.MethodPost, categoriesUrl, category)
+	if code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	var created models.Category
+	if err := json.Unmarshal([]byte(body), &created); err != nil {
+		t.Fatal(err)
+	}
+	return &created
+}
+
+func categoryUrl(id int64) string {
+	return categoriesUrl + "/" + strconv.FormatInt(id, 10)
+}
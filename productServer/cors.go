@@ -0,0 +1,42 @@
+package productServer
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// exposedHeaders are response headers available to scripts of other origins
+var exposedHeaders = []string{"Location", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", idempotentReplayedHeader, requestIDHeader}
+
+// allowCORS adds CORS headers to responses to requests from allowed origins and responds to preflight requests.
+// Preflight requests are answered before authentication, because browsers send them without credentials.
+func (srv *ProductServer) allowCORS(ctx *gin.Context) {
+	origin := ctx.GetHeader("Origin")
+	if origin == "" || len(srv.cors.AllowedOrigins) == 0 {
+		return
+	}
+	header := ctx.Writer.Header()
+	header.Add("Vary", "Origin")
+	if !srv.originAllowed(origin) {
+		return
+	}
+	header.Set("Access-Control-Allow-Origin", origin)
+	header.Set("Access-Control-Expose-Headers", strings.Join(exposedHeaders, ", "))
+	if ctx.Request.Method == http.MethodOptions && ctx.GetHeader("Access-Control-Request-Method") != "" {
+		header.Set("Access-Control-Allow-Methods", strings.Join(srv.cors.AllowedMethods, ", "))
+		header.Set("Access-Control-Allow-Headers", strings.Join(srv.cors.AllowedHeaders, ", "))
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(srv.cors.MaxAge.Seconds())))
+		ctx.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func (srv *ProductServer) originAllowed(origin string) bool {
+	for _, allowed := range srv.cors.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package productServer
+
+import (
+	"net/http"
+	"testing"
+)
+
+// testOrigin is the only origin allowed to send cross-origin requests to the test server
+const testOrigin = "https://shop.test"
+
+func TestCORS(t *testing.T) {
+	// Preflight requests are answered without credentials
+	request, _ := http.NewRequest(http.MethodOptions, baseUrl, nil)
+	request.Header.Set("Origin", testOrigin)
+	request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	request.Header.Set("Access-Control-Request-Headers", "X-API-Key, Content-Type")
+	resp, err := anonymousClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Preflight request: bad status code %d", resp.StatusCode)
+	}
+	if resp.Header.Get("Access-Control-Allow-Origin") != testOrigin || resp.Header.Get("Access-Control-Allow-Methods") == "" ||
+		resp.Header.Get("Access-Control-Allow-Headers") == "" || resp.Header.Get("Access-Control-Max-Age") != "600" {
+		t.Fatalf("Preflight request: bad headers %v", resp.Header)
+	}
+
+	for origin, allowed := range map[string]bool{testOrigin: true, "https://evil.test": false} {
+		request, _ = http.NewRequest(http.MethodGet, baseUrl, nil)
+		request.Header.Set("Origin", origin)
+		if resp, err = anonymousClient.Do(request); err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Request from %s: bad status code %d", origin, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Access-Control-Allow-Origin"); allowed && got != origin || !allowed && got != "" {
+			t.Fatalf("Request from %s: bad Access-Control-Allow-Origin %q", origin, got)
+		}
+		if allowed && resp.Header.Get("Access-Control-Expose-Headers") == "" {
+			t.Fatalf("Request from %s: exposed headers are missing", origin)
+		}
+	}
+}
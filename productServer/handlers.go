@@ -3,18 +3,51 @@ package productServer
 import (
 	"XsollaSchoolBE/DB"
 	"XsollaSchoolBE/models"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 var errorsToHttpStatusCode = map[error]int{
-	DB.ProductNotFoundError:      http.StatusNotFound,
-	DB.ProductAlreadyExistsError: http.StatusConflict,
+	DB.ProductNotFoundError:             http.StatusNotFound,
+	DB.ProductAlreadyExistsError:        http.StatusConflict,
+	DB.ProductChangedError:              http.StatusConflict,
+	DB.ParentProductNotFoundError:       http.StatusBadRequest,
+	DB.ReservedSKUError:                 http.StatusBadRequest,
+	DB.NestedVariantError:               http.StatusBadRequest,
+	DB.InvalidAttributesError:           http.StatusBadRequest,
+	DB.AttributeSchemaNotFoundError:     http.StatusNotFound,
+	DB.InvalidAttributeSchemaError:      http.StatusBadRequest,
+	DB.CategoryNotFoundError:            http.StatusNotFound,
+	DB.CategoryAlreadyExistsError:       http.StatusConflict,
+	DB.ParentCategoryNotFoundError:      http.StatusBadRequest,
+	DB.CategoryCycleError:               http.StatusBadRequest,
+	DB.TagNotFoundError:                 http.StatusNotFound,
+	DB.InvalidTagError:                  http.StatusBadRequest,
+	DB.TranslationNotFoundError:         http.StatusNotFound,
+	DB.InvalidLocaleError:               http.StatusBadRequest,
+	DB.InvalidTranslationError:          http.StatusBadRequest,
+	DB.ImageNotFoundError:               http.StatusNotFound,
+	DB.InvalidImageOrderError:           http.StatusBadRequest,
+	DB.InvalidStatusError:               http.StatusBadRequest,
+	DB.StatusTransitionError:            http.StatusConflict,
+	DB.InvalidScheduleError:             http.StatusBadRequest,
+	DB.APIKeyNotFoundError:              http.StatusNotFound,
+	DB.InvalidAPIKeyError:               http.StatusBadRequest,
+	DB.IdempotencyKeyReusedError:        http.StatusUnprocessableEntity,
+	DB.IdempotentRequestInProgressError: http.StatusConflict,
+	// Database operations are cancelled after the request deadline or on shutdown
+	context.DeadlineExceeded: http.StatusServiceUnavailable,
+	context.Canceled:         http.StatusServiceUnavailable,
 }
 
+const maxSuggestionsLimit = 50
+
 // TODO: Check if request body signature is matches with models.InputProduct
 
 // addProduct godoc
@@ -22,10 +55,16 @@ var errorsToHttpStatusCode = map[error]int{
 // @Accept json
 // @Produces json
 // @Param product body models.InputProduct true "adding product"
+// @Param Idempotency-Key header string false "unique key of the operation, retries with the same key get the first response"
 // @Success 201 {object} models.Product "Product has been created"
 // @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
 // @Failure 409 {object} string
+// @Failure 422 {object} string "idempotency key is used for another request"
 // @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products [post]
 func (srv *ProductServer) addProduct(ctx *gin.Context) {
 	// TODO: More informative message about unmarshal error
@@ -36,14 +75,14 @@ func (srv *ProductServer) addProduct(ctx *gin.Context) {
 		return
 	}
 
-	if product, err := srv.db.AddProduct(*newProduct); err == nil {
+	if product, err := tenantDB(ctx).AddProduct(ctx.Request.Context(), *newProduct); err == nil {
 		ctx.Header("Location", "/products?id="+strconv.FormatInt(product.Id, 10))
 		ctx.JSON(http.StatusCreated, product)
 	} else if err == DB.ProductAlreadyExistsError {
 		data, _ := json.Marshal(product)
 		ctx.String(http.StatusConflict, err.Error()+": "+string(data))
 	} else {
-		ctx.String(http.StatusInternalServerError, err.Error())
+		ctx.String(getHttpCodeFromError(err), err.Error())
 	}
 }
 
@@ -51,15 +90,54 @@ func (srv *ProductServer) addProduct(ctx *gin.Context) {
 // @Summary get product with specific SKU with SKU in URL path
 // @Produces json
 // @Param SKU path string true "SKU of searching product"
+// @Param locale query string false "Locale of name and description, overrides Accept-Language header"
+// @Param Accept-Language header string false "Preferred locales of name and description"
 // @Success 200 {array} models.Product
+// @Failure 400 {object} string
 // @Failure 404 {object} string "product with such SKU does not exist"
 // @Failure 500 {object} string
 // @Router /products/{SKU} [get]
 func (srv *ProductServer) getProductWithURL(ctx *gin.Context) {
 	SKU := ctx.Param("SKU")
-	foundProduct, err := srv.db.GetProductBySKU(SKU)
+	foundProduct, err := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), SKU)
+	products := []*models.Product{foundProduct}
+	if err == nil {
+		err = checkProductVisible(ctx, foundProduct)
+	}
+	if err == nil {
+		err = srv.localizeProducts(ctx, products)
+	}
 	if err == nil {
-		ctx.JSON(http.StatusOK, []*models.Product{foundProduct})
+		ctx.JSON(http.StatusOK, products)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getVariants godoc
+// @Summary get variants of the product with specific SKU
+// @Produces json
+// @Param SKU path string true "SKU of the parent product"
+// @Param locale query string false "Locale of names and descriptions, overrides Accept-Language header"
+// @Param Accept-Language header string false "Preferred locales of names and descriptions"
+// @Success 200 {array} models.Product
+// @Failure 400 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Router /products/{SKU}/variants [get]
+func (srv *ProductServer) getVariants(ctx *gin.Context) {
+	SKU := ctx.Param("SKU")
+	var variants []*models.Product
+	err := checkSKUVisible(ctx, SKU)
+	if err == nil {
+		variants, err = tenantDB(ctx).GetVariants(ctx.Request.Context(), SKU)
+	}
+	if err == nil {
+		variants = visibleProducts(ctx, variants)
+		err = srv.localizeProducts(ctx, variants)
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, variants)
 	} else {
 		ctx.String(getHttpCodeFromError(err), err.Error())
 	}
@@ -68,12 +146,25 @@ func (srv *ProductServer) getProductWithURL(ctx *gin.Context) {
 // getProductWithParam godoc
 // @Summary get product with specific SKU or Id with it in URL params or all of the products, or part of them
 // @Description Method return product with specific SKU, if related parameter is specified else similarly with Id.
-// @Description If both of parameters aren't specified return all products or group of them, if groupSize and groupNum params are specified
+// @Description If both of parameters aren't specified return all products or group of them, if groupSize and groupNum params are specified.
+// @Description Products of catalog can be filtered by type and by attributes with parameters like attributes[size]=XL,
+// @Description numeric attributes can be bounded with parameters like attributesMin[ageRating]=12 and attributesMax[ageRating]=18
 // @Produces json
 // @Param sku query string false "SKU of searching product"
 // @Param id query int false "Id of searching product"
 // @Param groupSize query int false "Size of requesting products group"
 // @Param groupNum query int false "Number of requesting products group"
+// @Param status query []string false "Statuses of catalog products, all for products of any status, other than published require products:read permission" collectionFormat(multi) default(published)
+// @Param type query string false "Type of catalog products"
+// @Param category query string false "Name of category of catalog products"
+// @Param includeSubcategories query bool false "Include products of subcategories of the category"
+// @Param tag query []string false "Tags of catalog products" collectionFormat(multi)
+// @Param tagMode query string false "Products must be tagged with all (and) or any (or) of the tags" Enums(and, or) default(and)
+// @Param attributes[name] query string false "Required value of product attribute with specified name"
+// @Param attributesMin[name] query number false "Minimal value of numeric product attribute with specified name"
+// @Param attributesMax[name] query number false "Maximal value of numeric product attribute with specified name"
+// @Param locale query string false "Locale of names and descriptions, overrides Accept-Language header"
+// @Param Accept-Language header string false "Preferred locales of names and descriptions"
 // @Success 200 {array} models.Product
 // @Failure 404 {object} string "Product with specified SKU or Id not found"
 // @Failure 400 {object} string
@@ -81,6 +172,11 @@ func (srv *ProductServer) getProductWithURL(ctx *gin.Context) {
 // @Router /products [get]
 func (srv *ProductServer) getProductWithParam(ctx *gin.Context) {
 	code, products, err := srv.getProductsFromDBWithParam(ctx)
+	if err == nil {
+		if err = srv.localizeProducts(ctx, products); err != nil {
+			code = getHttpCodeFromError(err)
+		}
+	}
 	if err == nil {
 		ctx.JSON(code, products)
 	} else {
@@ -88,6 +184,103 @@ func (srv *ProductServer) getProductWithParam(ctx *gin.Context) {
 	}
 }
 
+// searchProducts godoc
+// @Summary search products by words in their names and descriptions
+// @Description Method returns products containing words starting with every word of the query ordered by relevance.
+// @Description Found words are enclosed in <mark></mark> in highlighted name and description of the product, their text is HTML-escaped.
+// @Description Results can be requested by parts and filtered the same way as the catalog.
+// @Description Names and descriptions of found products are localized, highlighted ones are not.
+// @Produces json
+// @Param q query string true "Search query"
+// @Param groupSize query int false "Size of requesting results group"
+// @Param groupNum query int false "Number of requesting results group"
+// @Param status query []string false "Statuses of found products, all for products of any status, other than published require products:read permission" collectionFormat(multi) default(published)
+// @Param type query string false "Type of found products"
+// @Param category query string false "Name of category of found products"
+// @Param includeSubcategories query bool false "Include products of subcategories of the category"
+// @Param tag query []string false "Tags of found products" collectionFormat(multi)
+// @Param tagMode query string false "Products must be tagged with all (and) or any (or) of the tags" Enums(and, or) default(and)
+// @Param attributes[name] query string false "Required value of product attribute with specified name"
+// @Param attributesMin[name] query number false "Minimal value of numeric product attribute with specified name"
+// @Param attributesMax[name] query number false "Maximal value of numeric product attribute with specified name"
+// @Param locale query string false "Locale of names and descriptions, overrides Accept-Language header"
+// @Param Accept-Language header string false "Preferred locales of names and descriptions"
+// @Success 200 {array} models.SearchResult
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /products/search [get]
+func (srv *ProductServer) searchProducts(ctx *gin.Context) {
+	query := strings.TrimSpace(ctx.Query("q"))
+	if query == "" {
+		ctx.String(http.StatusBadRequest, "q parameter must be specified")
+		return
+	}
+	filter, err := getProductFilterFromUrl(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if code, err := checkStatusesAccess(ctx, filter); err != nil {
+		ctx.String(code, err.Error())
+		return
+	}
+	groupSize, groupNum, err := getGroupFromUrl(ctx)
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	results, err := tenantDB(ctx).SearchProducts(ctx.Request.Context(), query, groupSize, groupNum, filter)
+	if err == nil {
+		products := make([]*models.Product, len(results))
+		for i := range results {
+			products[i] = &results[i].Product
+		}
+		err = srv.localizeProducts(ctx, products)
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, results)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// suggestProducts godoc
+// @Summary get SKUs and names of products with SKU or name starting with the prefix
+// @Description Method is intended for autocompletion, products with matching SKU go first.
+// @Description Names are translated to the requested locale and the prefix is matched against translated names.
+// @Produces json
+// @Param prefix query string true "Typed prefix of SKU or name"
+// @Param limit query int false "Maximal number of suggestions" default(10) maximum(50)
+// @Param locale query string false "Locale of names, overrides Accept-Language header"
+// @Param Accept-Language header string false "Preferred locales of names"
+// @Success 200 {array} models.Suggestion
+// @Failure 400 {object} string
+// @Failure 500 {object} string
+// @Router /products/suggestions [get]
+func (srv *ProductServer) suggestProducts(ctx *gin.Context) {
+	prefix := ctx.Query("prefix")
+	if prefix == "" {
+		ctx.String(http.StatusBadRequest, "prefix parameter must be specified")
+		return
+	}
+	limit, err := strconv.ParseUint(ctx.DefaultQuery("limit", "10"), 10, 32)
+	if err != nil || limit == 0 || limit > maxSuggestionsLimit {
+		ctx.String(http.StatusBadRequest, "limit parameter must be a positive integer not greater than "+strconv.Itoa(maxSuggestionsLimit))
+		return
+	}
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+	locales, err := getLocalesFromRequest(ctx)
+	var suggestions []*models.Suggestion
+	if err == nil {
+		suggestions, err = tenantDB(ctx).SuggestProducts(ctx.Request.Context(), prefix, uint(limit), locales)
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, suggestions)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
 // headProductsWithURL godoc
 // @Summary return headers as a similar get request
 // @Param SKU path string true "SKU of searching product"
@@ -97,7 +290,10 @@ func (srv *ProductServer) getProductWithParam(ctx *gin.Context) {
 // @Router /products/{SKU} [head]
 func (srv *ProductServer) headProductsWithURL(ctx *gin.Context) {
 	SKU := ctx.Param("SKU")
-	_, err := srv.db.GetProductBySKU(SKU)
+	foundProduct, err := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), SKU)
+	if err == nil {
+		err = checkProductVisible(ctx, foundProduct)
+	}
 	if err == nil {
 		ctx.JSON(http.StatusOK, "")
 	} else {
@@ -111,6 +307,15 @@ func (srv *ProductServer) headProductsWithURL(ctx *gin.Context) {
 // @Param id query int false "Id of searching product"
 // @Param groupSize query int false "Size of requesting products group"
 // @Param groupNum query int false "Number of requesting products group"
+// @Param status query []string false "Statuses of catalog products, all for products of any status, other than published require products:read permission" collectionFormat(multi) default(published)
+// @Param type query string false "Type of catalog products"
+// @Param category query string false "Name of category of catalog products"
+// @Param includeSubcategories query bool false "Include products of subcategories of the category"
+// @Param tag query []string false "Tags of catalog products" collectionFormat(multi)
+// @Param tagMode query string false "Products must be tagged with all (and) or any (or) of the tags" Enums(and, or) default(and)
+// @Param attributes[name] query string false "Required value of product attribute with specified name"
+// @Param attributesMin[name] query number false "Minimal value of numeric product attribute with specified name"
+// @Param attributesMax[name] query number false "Maximal value of numeric product attribute with specified name"
 // @Success 200
 // @Failure 404
 // @Failure 400
@@ -127,15 +332,26 @@ func (srv *ProductServer) headProductsWithParam(ctx *gin.Context) {
 
 // deleteProductWithURL godoc
 // @Summary delete product with specific SKU with SKU in URL path
+// @Description Images of the product and its variants are deleted with it.
 // @Produces json
 // @Param SKU path string true "SKU of deleting product"
 // @Success 204
+// @Failure 401 {object} string
+// @Failure 403 {object} string
 // @Failure 404 {object} string "product with such SKU does not exist"
 // @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products/{SKU} [delete]
 func (srv *ProductServer) deleteProductWithURL(ctx *gin.Context) {
+	if !authorize(ctx, models.DeleteProductsPermission) {
+		return
+	}
 	SKU := ctx.Param("SKU")
-	if err := srv.db.DeleteProductBySKU(SKU); err == nil {
+	prod, _ := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), SKU)
+	images := productImages(ctx.Request.Context(), tenantDB(ctx), prod)
+	if err := tenantDB(ctx).DeleteProductBySKU(ctx.Request.Context(), SKU); err == nil {
+		srv.deleteImageFiles(ctx, images)
 		ctx.JSON(http.StatusNoContent, gin.H{})
 	} else {
 		ctx.String(getHttpCodeFromError(err), err.Error())
@@ -145,14 +361,22 @@ func (srv *ProductServer) deleteProductWithURL(ctx *gin.Context) {
 // deleteProductBySKU godoc
 // @Summary delete product with specific SKU or Id with it in URL params
 // @Description Method delete product with specific SKU, if related parameter is specified else similarly with Id.
+// @Description Images of the product and its variants are deleted with it.
 // @Param sku query string false "SKU of deleting product"
 // @Param id query int false "Id of deleting product"
 // @Success 204
 // @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
 // @Failure 404 {object} string "Product with specified SKU or Id not found"
 // @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products [delete]
 func (srv *ProductServer) deleteProductWithParam(ctx *gin.Context) {
+	if !authorize(ctx, models.DeleteProductsPermission) {
+		return
+	}
 	var errMsg string
 	code := http.StatusNoContent
 	prSKU, prId, err := getSKUAndIDFromUrl(ctx)
@@ -160,14 +384,22 @@ func (srv *ProductServer) deleteProductWithParam(ctx *gin.Context) {
 		errMsg = err.Error()
 		code = http.StatusBadRequest
 	} else if prSKU != "" {
-		if err := srv.db.DeleteProductBySKU(prSKU); err != nil {
+		prod, _ := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), prSKU)
+		images := productImages(ctx.Request.Context(), tenantDB(ctx), prod)
+		if err := tenantDB(ctx).DeleteProductBySKU(ctx.Request.Context(), prSKU); err != nil {
 			errMsg = err.Error()
 			code = getHttpCodeFromError(err)
+		} else {
+			srv.deleteImageFiles(ctx, images)
 		}
 	} else if prId != 0 {
-		if err := srv.db.DeleteProductById(prId); err != nil {
+		prod, _ := tenantDB(ctx).GetProductById(ctx.Request.Context(), prId)
+		images := productImages(ctx.Request.Context(), tenantDB(ctx), prod)
+		if err := tenantDB(ctx).DeleteProductById(ctx.Request.Context(), prId); err != nil {
 			errMsg = err.Error()
 			code = getHttpCodeFromError(err)
+		} else {
+			srv.deleteImageFiles(ctx, images)
 		}
 	} else {
 		errMsg = "Id or SKU of deleting product must be specified"
@@ -189,9 +421,13 @@ func (srv *ProductServer) deleteProductWithParam(ctx *gin.Context) {
 // @Param SKU path string true "SKU of updating product"
 // @Success 200 {object} models.Product "Product has been updated"
 // @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
 // @Failure 404 {object} string
 // @Failure 409 {object} string
 // @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products/{SKU} [PUT]
 func (srv *ProductServer) updateProductWithURL(ctx *gin.Context) {
 	// TODO: More informative message about unmarshal error
@@ -202,11 +438,16 @@ func (srv *ProductServer) updateProductWithURL(ctx *gin.Context) {
 		ctx.String(http.StatusBadRequest, "json format error: "+err.Error(), *models.EmptyProduct())
 		return
 	}
-	product, err := srv.db.UpdateProductBySKU(SKU, *newProduct)
+	oldProduct, err := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), SKU)
+	if !authorizeUpdate(ctx, oldProduct, err, *newProduct) {
+		return
+	}
+	// The product is updated only if it is not changed since the update is authorized
+	product, err := tenantDB(ctx).UpdateProductBySKU(ctx.Request.Context(), SKU, *newProduct, oldProduct)
 	code := getHttpCodeFromError(err)
 	if code == http.StatusOK {
 		ctx.JSON(code, *product)
-	} else if code == http.StatusConflict {
+	} else if err == DB.ProductAlreadyExistsError {
 		data, _ := json.Marshal(product)
 		ctx.String(code, err.Error()+": "+string(data))
 	} else {
@@ -223,9 +464,13 @@ func (srv *ProductServer) updateProductWithURL(ctx *gin.Context) {
 // @Param id query int false "Id of updating product"
 // @Success 200 {object} models.Product "Product has been updated"
 // @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
 // @Failure 404 {object} string
 // @Failure 409 {object} string
 // @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /products [put]
 func (srv *ProductServer) updateProductWithParam(ctx *gin.Context) {
 	// TODO: More informative message about unmarshal error
@@ -244,12 +489,20 @@ func (srv *ProductServer) updateProductWithParam(ctx *gin.Context) {
 		errMsg = err.Error()
 		code = http.StatusBadRequest
 	} else if prSKU != "" {
-		if prod, err = srv.db.UpdateProductBySKU(prSKU, *newProduct); err != nil {
+		oldProduct, getErr := tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), prSKU)
+		if !authorizeUpdate(ctx, oldProduct, getErr, *newProduct) {
+			return
+		}
+		if prod, err = tenantDB(ctx).UpdateProductBySKU(ctx.Request.Context(), prSKU, *newProduct, oldProduct); err != nil {
 			errMsg = err.Error()
 			code = getHttpCodeFromError(err)
 		}
 	} else if prId != 0 {
-		if prod, err = srv.db.UpdateProductById(prId, *newProduct); err != nil {
+		oldProduct, getErr := tenantDB(ctx).GetProductById(ctx.Request.Context(), prId)
+		if !authorizeUpdate(ctx, oldProduct, getErr, *newProduct) {
+			return
+		}
+		if prod, err = tenantDB(ctx).UpdateProductById(ctx.Request.Context(), prId, *newProduct, oldProduct); err != nil {
 			errMsg = err.Error()
 			code = getHttpCodeFromError(err)
 		}
@@ -260,7 +513,7 @@ func (srv *ProductServer) updateProductWithParam(ctx *gin.Context) {
 
 	if code == http.StatusOK {
 		ctx.JSON(code, *prod)
-	} else if code == http.StatusConflict {
+	} else if err == DB.ProductAlreadyExistsError {
 		data, _ := json.Marshal(prod)
 		ctx.String(http.StatusBadRequest, errMsg+": "+string(data))
 	} else {
@@ -275,40 +528,108 @@ func (srv *ProductServer) getProductsFromDBWithParam(ctx *gin.Context) (code int
 		code = http.StatusBadRequest
 	} else if prSKU != "" {
 		var foundProduct *models.Product
-		if foundProduct, err = srv.db.GetProductBySKU(prSKU); err == nil {
+		if foundProduct, err = tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), prSKU); err == nil {
+			err = checkProductVisible(ctx, foundProduct)
+		}
+		if err == nil {
 			products = []*models.Product{foundProduct}
 		} else {
 			code = getHttpCodeFromError(err)
 		}
 	} else if prId != 0 {
-		if foundProduct, err := srv.db.GetProductById(prId); err == nil {
+		var foundProduct *models.Product
+		if foundProduct, err = tenantDB(ctx).GetProductById(ctx.Request.Context(), prId); err == nil {
+			err = checkProductVisible(ctx, foundProduct)
+		}
+		if err == nil {
 			products = []*models.Product{foundProduct}
 		} else {
 			code = getHttpCodeFromError(err)
 		}
+	} else if filter, filterErr := getProductFilterFromUrl(ctx); filterErr != nil {
+		code, err = http.StatusBadRequest, filterErr
+	} else if accessCode, accessErr := checkStatusesAccess(ctx, filter); accessErr != nil {
+		code, err = accessCode, accessErr
+	} else if groupSize, groupNum, groupErr := getGroupFromUrl(ctx); groupErr != nil {
+		code, err = http.StatusBadRequest, groupErr
 	} else {
-		groupSizeStr, okSize := ctx.GetQuery("groupSize")
-		groupNumStr, okNum := ctx.GetQuery("groupNum")
-		if okSize && okNum {
-			var groupSize uint64
-			var groupNum uint64
-			if groupSize, err = strconv.ParseUint(groupSizeStr, 10, 32); err != nil {
-				err = errors.New("groupSize parameter must be an 32-bit unsigned integer")
-			} else if groupNum, err = strconv.ParseUint(groupNumStr, 10, 32); err != nil {
-				err = errors.New("groupNum parameter must be an 32-bit unsigned integer")
-			} else {
-				products, err = srv.db.GetGroupOfProducts(uint(groupSize), uint(groupNum))
-			}
+		if groupSize > 0 {
+			products, err = tenantDB(ctx).GetGroupOfProducts(ctx.Request.Context(), groupSize, groupNum, filter)
 		} else {
-			products, err = srv.db.GetAllProducts()
+			products, err = tenantDB(ctx).GetAllProducts(ctx.Request.Context(), filter)
 		}
 		if err != nil {
-			code = http.StatusInternalServerError
+			code = getHttpCodeFromError(err)
 		}
 	}
 	return
 }
 
+// getGroupFromUrl returns size and number of requested group of products or zeros if all products are requested
+func getGroupFromUrl(ctx *gin.Context) (uint, uint, error) {
+	groupSizeStr, okSize := ctx.GetQuery("groupSize")
+	groupNumStr, okNum := ctx.GetQuery("groupNum")
+	if !okSize || !okNum {
+		return 0, 0, nil
+	}
+	if groupSize, err := strconv.ParseUint(groupSizeStr, 10, 32); err != nil || groupSize == 0 {
+		return 0, 0, errors.New("groupSize parameter must be an 32-bit positive integer")
+	} else if groupNum, err := strconv.ParseUint(groupNumStr, 10, 32); err != nil || groupNum == 0 {
+		return 0, 0, errors.New("groupNum parameter must be an 32-bit positive integer")
+	} else {
+		return uint(groupSize), uint(groupNum), nil
+	}
+}
+
+func getProductFilterFromUrl(ctx *gin.Context) (models.ProductFilter, error) {
+	filter := models.ProductFilter{
+		Statuses:      ctx.QueryArray("status"),
+		Type:          ctx.Query("type"),
+		Category:      ctx.Query("category"),
+		Tags:          ctx.QueryArray("tag"),
+		Attributes:    ctx.QueryMap("attributes"),
+		AttributesMin: make(map[string]float64),
+		AttributesMax: make(map[string]float64),
+	}
+	if len(filter.Statuses) == 0 {
+		filter.Statuses = []string{models.PublishedStatus}
+	} else if len(filter.Statuses) == 1 && filter.Statuses[0] == "all" {
+		filter.Statuses = nil
+	}
+	for _, status := range filter.Statuses {
+		if !models.ValidStatus(status) {
+			return filter, errors.New("status parameter must be draft, published, archived or all")
+		}
+	}
+	if includeStr, ok := ctx.GetQuery("includeSubcategories"); ok {
+		include, err := strconv.ParseBool(includeStr)
+		if err != nil {
+			return filter, errors.New("includeSubcategories parameter must be a boolean")
+		}
+		filter.IncludeSubcategories = include
+	}
+	switch tagMode := ctx.DefaultQuery("tagMode", "and"); tagMode {
+	case "and":
+	case "or":
+		filter.AnyTag = true
+	default:
+		return filter, errors.New("tagMode parameter must be \"and\" or \"or\"")
+	}
+	for param, bounds := range map[string]map[string]float64{
+		"attributesMin": filter.AttributesMin,
+		"attributesMax": filter.AttributesMax,
+	} {
+		for name, valueStr := range ctx.QueryMap(param) {
+			value, err := strconv.ParseFloat(valueStr, 64)
+			if err != nil {
+				return filter, fmt.Errorf("%s[%s] parameter must be a number", param, name)
+			}
+			bounds[name] = value
+		}
+	}
+	return filter, nil
+}
+
 func getSKUAndIDFromUrl(ctx *gin.Context) (string, int64, error) {
 	if prSKU, ok := ctx.GetQuery("sku"); ok {
 		return prSKU, 0, nil
@@ -328,7 +649,12 @@ func getHttpCodeFromError(err error) int {
 		return http.StatusOK
 	} else if code, ok := errorsToHttpStatusCode[err]; ok {
 		return code
-	} else {
-		return http.StatusInternalServerError
 	}
+	// Errors with details are wrapped by DB implementation
+	for knownErr, code := range errorsToHttpStatusCode {
+		if errors.Is(err, knownErr) {
+			return code
+		}
+	}
+	return http.StatusInternalServerError
 }
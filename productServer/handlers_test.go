@@ -1,6 +1,7 @@
 package productServer
 
 import (
+	"XsollaSchoolBE/config"
 	"XsollaSchoolBE/models"
 	"bytes"
 	"context"
@@ -11,30 +12,58 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"reflect"
 	"strconv"
 	"testing"
 )
 
-const baseUrl = "http://localhost:8080/api/v1/products"
+// URLs of the test server, it listens on a free port
+var serverUrl, baseUrl, typesUrl, categoriesUrl, apiKeysUrl string
+
+// testServer is the server started for tests
+var testServer *ProductServer
 
 var testProducts = []models.InputProduct{
-	{"TEST1231", "Prod1", "Type1", 10},
-	{"TEST1232", "Prod2", "Type3", 12},
-	{"TEST1233", "Prod3", "Type1", 3},
-	{"TEST1234", "Prod4", "Type2", 312},
-	{"TEST1235", "Prod5", "Type4", 222},
-	{"TEST1236", "Prod6", "Type1", 13},
-	{"TEST1237", "Prod7", "Type5", 35},
-	{"TEST1238", "Prod8", "Type5", 345},
-	{"TEST1239", "Prod9", "Type1", 353},
-	{"TEST12310", "Prod10", "Type2", 1},
+	{SKU: "TEST1231", Name: "Prod1", Type: "Type1", Cost: 10},
+	{SKU: "TEST1232", Name: "Prod2", Type: "Type3", Cost: 12},
+	{SKU: "TEST1233", Name: "Prod3", Type: "Type1", Cost: 3},
+	{SKU: "TEST1234", Name: "Prod4", Type: "Type2", Cost: 312},
+	{SKU: "TEST1235", Name: "Prod5", Type: "Type4", Cost: 222},
+	{SKU: "TEST1236", Name: "Prod6", Type: "Type1", Cost: 13},
+	{SKU: "TEST1237", Name: "Prod7", Type: "Type5", Cost: 35},
+	{SKU: "TEST1238", Name: "Prod8", Type: "Type5", Cost: 345},
+	{SKU: "TEST1239", Name: "Prod9", Type: "Type1", Cost: 353},
+	{SKU: "TEST12310", Name: "Prod10", Type: "Type2", Cost: 1},
 }
 
 func TestMain(m *testing.M) {
-	srv, err := Run(":8080", "testDB.db")
+	jwtConfig, err := initTestTokens()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg := config.Default()
+	cfg.Server.Address = "localhost:0"
+	cfg.Server.MediaDir = "testMedia"
+	cfg.DB.DSN = "testDB.db"
+	cfg.JWT = jwtConfig
+	cfg.CORS.AllowedOrigins = []string{testOrigin}
+	cfg.Log.Level = config.WarnLevel
+	srv, err := Run(cfg)
+	testServer = srv
 	if err != nil {
 		log.Fatal(err)
 	}
+	serverUrl = "http://" + srv.Addr
+	baseUrl = serverUrl + "/api/v1/products"
+	typesUrl = serverUrl + "/api/v1/types"
+	categoriesUrl = serverUrl + "/api/v1/categories"
+	apiKeysUrl = serverUrl + "/api/v1/apikeys"
+	// Requests of tests are sent with admin key, anonymousClient sends requests without it
+	key, err := srv.db.AddAPIKey(context.Background(), models.InputAPIKey{Name: "tests", Scopes: []string{models.AdminScope}})
+	if err != nil {
+		log.Fatal(err)
+	}
+	http.DefaultTransport = apiKeyTransport{key: key.Key, base: anonymousClient.Transport}
 	m.Run()
 	if err := srv.Shutdown(context.Background()); err != nil {
 		log.Fatal("Server Shutdown:", err)
@@ -43,10 +72,16 @@ func TestMain(m *testing.M) {
 	if err := os.Remove("testDB.db"); err != nil {
 		log.Println("Warning: ", err.Error())
 	}
+	if err := os.Remove(testJWKSFile); err != nil {
+		log.Println("Warning: ", err.Error())
+	}
+	if err := os.RemoveAll("testMedia"); err != nil {
+		log.Println("Warning: ", err.Error())
+	}
 }
 
 func TestCorrectPost(t *testing.T) {
-	resp, err := http.Get("http://localhost:8080")
+	resp, err := http.Get(serverUrl)
 	if err != nil {
 		t.Fatal(err)
 	} else if resp.StatusCode != http.StatusOK {
@@ -104,7 +139,8 @@ func TestIncorrectPost(t *testing.T) {
 }
 
 func TestGetAll(t *testing.T) {
-	resp, err := http.Get(baseUrl)
+	// Products added by TestCorrectPost are drafts
+	resp, err := http.Get(baseUrl + "?status=all")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -121,7 +157,7 @@ func TestGetAll(t *testing.T) {
 		t.Fatal("Wrong length of received products")
 	} else {
 		for i, prod := range receivedProducts {
-			if testProducts[i] != prod.InputProduct {
+			if !reflect.DeepEqual(testProducts[i], prod.InputProduct) {
 				t.Fatalf("SKU mismatch:\n%v,\n%v", prod.InputProduct, testProducts[i])
 			}
 		}
@@ -129,7 +165,7 @@ func TestGetAll(t *testing.T) {
 }
 
 func TestGetGroupOfProducts(t *testing.T) {
-	resp, err := http.Get(baseUrl + "?groupSize=3&groupNum=2")
+	resp, err := http.Get(baseUrl + "?groupSize=3&groupNum=2&status=draft")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -146,7 +182,7 @@ func TestGetGroupOfProducts(t *testing.T) {
 		t.Fatal("Wrong length of received products: ", len(testProducts))
 	} else {
 		for i, prod := range receivedProducts {
-			if testProducts[3+i] != prod.InputProduct {
+			if !reflect.DeepEqual(testProducts[3+i], prod.InputProduct) {
 				t.Fatalf("SKU mismatch:\n%v,\n%v", prod.InputProduct, testProducts[3+i])
 			}
 		}
@@ -162,7 +198,7 @@ func TestCorrectGet(t *testing.T) {
 		prodPtr, err, _ := getProductFromURL(url)
 		if err != nil {
 			t.Error(err)
-		} else if prodPtr.InputProduct != testProducts[0] {
+		} else if !reflect.DeepEqual(prodPtr.InputProduct, testProducts[0]) {
 			t.Errorf("received product is not equal the sent one:\nreceived product: %v\nsent product: %v", prodPtr, testProducts[0])
 		}
 	}
@@ -275,7 +311,7 @@ func TestCorrectUpdate(t *testing.T) {
 			continue
 		}
 
-		newProduct := models.InputProduct{"NewSKU" + strconv.Itoa(i), "NewName", "NewType", uint(100 + i)}
+		newProduct := models.InputProduct{SKU: "NewSKU" + strconv.Itoa(i), Name: "NewName", Type: "NewType", Cost: uint(100 + i)}
 		jsonProduct, _ := json.Marshal(newProduct)
 		request, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonProduct))
 		if err != nil {
@@ -295,7 +331,7 @@ func TestCorrectUpdate(t *testing.T) {
 			t.Error(err)
 		} else if code != http.StatusOK {
 			t.Error("Bad status code: ", code)
-		} else if prod.InputProduct != newProduct {
+		} else if !reflect.DeepEqual(prod.InputProduct, newProduct) {
 			t.Errorf("Updated product mismatch:\nSend product: %v\nReceived product: %v", newProduct, prod.InputProduct)
 		}
 	}
@@ -309,7 +345,7 @@ func TestIncorrectUpdate(t *testing.T) {
 	}
 	client := &http.Client{}
 	for i, url := range requestingURLs {
-		newProduct := models.InputProduct{"NewSKU" + strconv.Itoa(i), "NewName", "NewType", uint(100 + i)}
+		newProduct := models.InputProduct{SKU: "NewSKU" + strconv.Itoa(i), Name: "NewName", Type: "NewType", Cost: uint(100 + i)}
 		jsonProduct, _ := json.Marshal(newProduct)
 		request, err := http.NewRequest(http.MethodPut, url, bytes.NewBuffer(jsonProduct))
 		if err != nil {
@@ -351,6 +387,238 @@ func TestIncorrectUpdate(t *testing.T) {
 	}
 }
 
+func TestVariants(t *testing.T) {
+	parent := models.InputProduct{SKU: "SHIRT", Name: "T-shirt", Type: "Merch", Cost: 20}
+	variants := []models.InputProduct{
+		{SKU: "SHIRT-M", Cost: 20, ParentSKU: "SHIRT", Attributes: map[string]string{"size": "M", "color": "red"}},
+		{SKU: "SHIRT-XL", Cost: 25, ParentSKU: "SHIRT", Attributes: map[string]string{"size": "XL", "color": "red"}},
+	}
+	for _, prod := range append([]models.InputProduct{parent}, variants...) {
+		if code, body := postProduct(prod); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+	}
+	defer deleteProduct(parent.SKU)
+
+	prod, err, _ := getProductFromURL(baseUrl + "/" + variants[1].SKU)
+	if err != nil {
+		t.Fatal(err)
+	} else if prod.Name != parent.Name || prod.Type != parent.Type || prod.ParentSKU != parent.SKU {
+		t.Errorf("variant doesn't inherit parent fields: %v", prod.InputProduct)
+	} else if !reflect.DeepEqual(prod.Attributes, variants[1].Attributes) {
+		t.Errorf("attributes mismatch:\nsent: %v\nreceived: %v", variants[1].Attributes, prod.Attributes)
+	}
+
+	receivedVariants, err := getProductsFromURL(baseUrl + "/" + parent.SKU + "/variants")
+	if err != nil {
+		t.Error(err)
+	} else if len(receivedVariants) != len(variants) {
+		t.Errorf("Wrong length of received variants: %d", len(receivedVariants))
+	}
+
+	filtered, err := getProductsFromURL(baseUrl + "?attributes[color]=red&attributes[size]=XL")
+	if err != nil {
+		t.Error(err)
+	} else if len(filtered) != 1 || filtered[0].SKU != variants[1].SKU {
+		t.Errorf("Wrong products filtered by attributes: %v", filtered)
+	}
+
+	for _, prod := range []models.InputProduct{
+		{SKU: "SHIRT-M-RED", Cost: 20, ParentSKU: variants[0].SKU},
+		{SKU: "SHIRT-S", Cost: 20, ParentSKU: "WRONG"},
+	} {
+		if code, _ := postProduct(prod); code != http.StatusBadRequest {
+			t.Errorf("not 400 code for incorrect parent %s: %d", prod.ParentSKU, code)
+		}
+	}
+
+	deleteProduct(parent.SKU)
+	if _, _, code := getProductFromURL(baseUrl + "/" + variants[0].SKU); code != http.StatusNotFound {
+		t.Error("variant of deleted product has been found")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	products := []models.InputProduct{
+		{SKU: "SEARCH1", Name: "Dark Souls", Type: "Game", Cost: 40, Description: "Hard action RPG"},
+		{SKU: "SEARCH2", Name: "Stardew Valley", Type: "Game", Cost: 15, Description: "Farming in the dark of winter"},
+		{SKU: "SEARCH3", Name: "Darkest Dungeon", Type: "Game", Cost: 25, Description: "Gothic roguelike"},
+	}
+	for _, prod := range products {
+		if code, body := postProduct(prod); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+		defer deleteProduct(prod.SKU)
+	}
+
+	results, err := getSearchResultsFromURL(baseUrl + "/search?q=dark")
+	if err != nil {
+		t.Error(err)
+	} else if len(results) != 3 {
+		t.Errorf("Wrong number of found products: %d", len(results))
+	} else if results[2].SKU != products[1].SKU {
+		t.Errorf("product with match in description is ranked higher than ones with match in name: %v", results)
+	} else if results[2].HighlightedDescription != "Farming in the <mark>dark</mark> of winter" {
+		t.Errorf("Wrong highlighted description: %s", results[2].HighlightedDescription)
+	}
+
+	results, err = getSearchResultsFromURL(baseUrl + "/search?q=dark+sou")
+	if err != nil {
+		t.Error(err)
+	} else if len(results) != 1 || results[0].SKU != products[0].SKU {
+		t.Errorf("Wrong found products: %v", results)
+	} else if results[0].HighlightedName != "<mark>Dark</mark> <mark>Souls</mark>" {
+		t.Errorf("Wrong highlighted name: %s", results[0].HighlightedName)
+	}
+
+	results, err = getSearchResultsFromURL(baseUrl + "/search?q=dark&groupSize=2&groupNum=2")
+	if err != nil {
+		t.Error(err)
+	} else if len(results) != 1 || results[0].SKU != products[1].SKU {
+		t.Errorf("Wrong group of found products: %v", results)
+	}
+
+	// Product data is escaped, so highlighted results can be rendered as HTML
+	unsafe := models.InputProduct{SKU: "SEARCH4", Name: "<script>alert(1)</script> Exploit", Type: "Game", Cost: 1,
+		Description: "Tom & Jerry exploit"}
+	if code, body := postProduct(unsafe); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(unsafe.SKU)
+	results, err = getSearchResultsFromURL(baseUrl + "/search?q=exploit")
+	if err != nil {
+		t.Error(err)
+	} else if len(results) != 1 || results[0].HighlightedName != "&lt;script&gt;alert(1)&lt;/script&gt; <mark>Exploit</mark>" ||
+		results[0].HighlightedDescription != "Tom &amp; Jerry <mark>exploit</mark>" {
+		t.Errorf("Wrong highlighted product data: %v", results)
+	}
+
+	if code, _ := sendRequest(http.MethodGet, baseUrl+"/search?q=+", nil); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for empty search query: %d", code)
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	products := []models.InputProduct{
+		{SKU: "HALO-1", Name: "Halo: Combat Evolved", Type: "Game", Cost: 20},
+		{SKU: "HAL-9000", Name: "Space Odyssey", Type: "Game", Cost: 30},
+		{SKU: "SUGGEST1", Name: "Half-Life", Type: "Game", Cost: 10},
+	}
+	for _, prod := range products {
+		if code, body := postProduct(prod); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+		defer deleteProduct(prod.SKU)
+	}
+
+	suggestions, err := getSuggestionsFromURL(baseUrl + "/suggestions?prefix=hal")
+	if err != nil {
+		t.Error(err)
+	} else if len(suggestions) != 3 || suggestions[2].SKU != products[2].SKU {
+		t.Errorf("Wrong suggestions, products with matching SKU must go first: %v", suggestions)
+	}
+
+	suggestions, err = getSuggestionsFromURL(baseUrl + "/suggestions?prefix=hal&limit=1")
+	if err != nil {
+		t.Error(err)
+	} else if len(suggestions) != 1 {
+		t.Errorf("Limit is not applied: %v", suggestions)
+	}
+
+	deleteProduct(products[0].SKU)
+	suggestions, err = getSuggestionsFromURL(baseUrl + "/suggestions?prefix=hal")
+	if err != nil {
+		t.Error(err)
+	} else if len(suggestions) != 2 {
+		t.Errorf("Deleted product is suggested: %v", suggestions)
+	}
+
+	for _, query := range []string{"", "?prefix=", "?prefix=hal&limit=0", "?prefix=hal&limit=51", "?prefix=hal&limit=a"} {
+		if code, _ := sendRequest(http.MethodGet, baseUrl+"/suggestions"+query, nil); code != http.StatusBadRequest {
+			t.Errorf("not 400 code for suggestions query %q: %d", query, code)
+		}
+	}
+}
+
+// postProduct adds the product and publishes it, so it is shown in the catalog
+func postProduct(product models.InputProduct) (int, string) {
+	jsonProduct, _ := json.Marshal(product)
+	resp, err := http.Post(baseUrl, "application/json", bytes.NewBuffer(jsonProduct))
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	bodyData, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusCreated {
+		transition := models.StatusTransition{Status: models.PublishedStatus}
+		if code, body := sendRequest(http.MethodPut, baseUrl+"/"+product.SKU+"/status", transition); code != http.StatusOK {
+			return code, body
+		}
+	}
+	return resp.StatusCode, string(bodyData)
+}
+
+func sendRequest(method string, url string, body interface{}) (int, string) {
+	var bodyReader io.Reader
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		bodyReader = bytes.NewBuffer(jsonBody)
+	}
+	request, err := http.NewRequest(method, url, bodyReader)
+	if err != nil {
+		return 0, err.Error()
+	}
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	bodyData, _ := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, string(bodyData)
+}
+
+func deleteProduct(SKU string) {
+	request, _ := http.NewRequest(http.MethodDelete, baseUrl+"/"+SKU, nil)
+	if resp, err := http.DefaultClient.Do(request); err == nil {
+		resp.Body.Close()
+	}
+}
+
+func getProductsFromURL(url string) ([]*models.Product, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		bodyData, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("\nBad status code: %d\nResponse body: %s\n", resp.StatusCode, bodyData)
+	}
+	var products []*models.Product
+	err = json.NewDecoder(resp.Body).Decode(&products)
+	return products, err
+}
+
+func getSearchResultsFromURL(url string) ([]*models.SearchResult, error) {
+	code, body := sendRequest(http.MethodGet, url, nil)
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	var results []*models.SearchResult
+	err := json.Unmarshal([]byte(body), &results)
+	return results, err
+}
+
+func getSuggestionsFromURL(url string) ([]*models.Suggestion, error) {
+	code, body := sendRequest(http.MethodGet, url, nil)
+	if code != http.StatusOK {
+		return nil, fmt.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	var suggestions []*models.Suggestion
+	err := json.Unmarshal([]byte(body), &suggestions)
+	return suggestions, err
+}
+
 func getProductFromReader(reader io.Reader) (*models.Product, error) {
 	prods := []*models.Product{}
 	err := json.NewDecoder(reader).Decode(&prods)
@@ -379,3 +647,22 @@ func getProductFromURL(url string) (product *models.Product, err error, code int
 	}
 	return
 }
+
+func TestReservedSKUs(t *testing.T) {
+	product := models.InputProduct{SKU: "RESERVED", Name: "Reserved", Type: "Game", Cost: 10}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+	for _, SKU := range models.ReservedSKUs {
+		reserved := product
+		reserved.SKU = SKU
+		if code, body := sendRequest(http.MethodPost, baseUrl, reserved); code != http.StatusBadRequest {
+			t.Errorf("Product with SKU %s is created: %d\nResponse body: %s", SKU, code, body)
+			deleteProduct(SKU)
+		}
+		if code, body := sendRequest(http.MethodPut, baseUrl+"/"+product.SKU, reserved); code != http.StatusBadRequest {
+			t.Errorf("SKU is changed to %s: %d\nResponse body: %s", SKU, code, body)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// getLiveness reports that the process handles requests. Dependencies are not checked,
+// so the service is not restarted while the database is unavailable.
+func (srv *ProductServer) getLiveness(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, models.Health{Status: models.UpStatus})
+}
+
+// getReadiness reports statuses of components required to handle requests, the status code is 503
+// if any of them is down. The server is not ready since the beginning of Shutdown, so load balancers
+// stop sending requests to it.
+func (srv *ProductServer) getReadiness(ctx *gin.Context) {
+	components := map[string]models.ComponentHealth{
+		"server":     {Status: models.UpStatus},
+		"database":   {Status: models.UpStatus},
+		"migrations": {Status: models.UpStatus},
+	}
+	if atomic.LoadInt32(&srv.shuttingDown) != 0 {
+		components["server"] = models.ComponentHealth{Status: models.DownStatus, Error: "server is shutting down"}
+	}
+	if err := srv.db.Ping(ctx.Request.Context()); err != nil {
+		components["database"] = models.ComponentHealth{Status: models.DownStatus, Error: err.Error()}
+	}
+	if pending, err := srv.db.PendingMigrations(ctx.Request.Context()); err != nil {
+		components["migrations"] = models.ComponentHealth{Status: models.DownStatus, Error: err.Error()}
+	} else if pending > 0 {
+		components["migrations"] = models.ComponentHealth{Status: models.DownStatus, Error: strconv.Itoa(pending) + " migrations are not applied"}
+	} else if pending < 0 {
+		components["migrations"] = models.ComponentHealth{Status: models.DownStatus, Error: "database is migrated by a newer version of the service"}
+	}
+	health := models.Health{Status: models.UpStatus, Components: components}
+	code := http.StatusOK
+	for _, component := range components {
+		if component.Status != models.UpStatus {
+			health.Status = models.DownStatus
+			code = http.StatusServiceUnavailable
+		}
+	}
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(code, health)
+}
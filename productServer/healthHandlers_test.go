@@ -0,0 +1,38 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHealthChecks(t *testing.T) {
+	// Probes do not need credentials
+	code, body := sendRequestWithHeaders(nil, http.MethodGet, serverUrl+"/healthz", nil)
+	if code != http.StatusOK {
+		t.Fatalf("Liveness: bad status code %d\nResponse body: %s", code, body)
+	}
+	checkReadiness := func(expectedCode int, expectedStatuses map[string]string) {
+		code, body := sendRequestWithHeaders(nil, http.MethodGet, serverUrl+"/readyz", nil)
+		if code != expectedCode {
+			t.Fatalf("Readiness: bad status code %d, expected %d\nResponse body: %s", code, expectedCode, body)
+		}
+		var health models.Health
+		if err := json.Unmarshal([]byte(body), &health); err != nil {
+			t.Fatal(err)
+		}
+		for component, status := range expectedStatuses {
+			if health.Components[component].Status != status {
+				t.Fatalf("Readiness: bad status of %s\nResponse body: %s", component, body)
+			}
+		}
+	}
+	checkReadiness(http.StatusOK, map[string]string{"server": models.UpStatus, "database": models.UpStatus, "migrations": models.UpStatus})
+
+	// Shutdown makes the server not ready before it stops accepting connections
+	atomic.StoreInt32(&testServer.shuttingDown, 1)
+	defer atomic.StoreInt32(&testServer.shuttingDown, 0)
+	checkReadiness(http.StatusServiceUnavailable, map[string]string{"server": models.DownStatus, "database": models.UpStatus})
+}
@@ -0,0 +1,130 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// idempotencyKeyHeader is a request header with a key unique for each operation of the client, retries of the request have the same key
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotentReplayedHeader is set to "true" in responses replayed for retries
+const idempotentReplayedHeader = "Idempotent-Replayed"
+
+// idempotencyKeyTTL is how long responses to requests with idempotency keys are stored
+const idempotencyKeyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLength = 255
+
+// idempotencyLeaseMargin is added to the timeouts of the request to get the time, after which the request in progress
+// is considered lost, for example with a crashed process, so retries can take its key
+const idempotencyLeaseMargin = time.Minute
+
+// idempotencyStoreTimeout limits saving responses and releasing keys, they do not use the context of the request,
+// because a request cancelled by its deadline would leave the key taken and retries would get conflicts
+const idempotencyStoreTimeout = 5 * time.Second
+
+// maxIdempotentBodySize is the maximal size of request bodies hashed for comparison of retries, images are the largest ones
+const maxIdempotentBodySize = maxImageRequestSize
+
+// idempotent stores the first response to the request with Idempotency-Key header and replays it for retries
+// of the client with the same key, so the operation is performed once. The key can not be reused for another
+// request (method, URL or body) within idempotencyKeyTTL. Responses with 5xx status codes are not stored,
+// so failed requests can be retried. Keys of requests lost with a crashed process are taken again by retries after
+// the lease of the request.
+func (srv *ProductServer) idempotent(ctx *gin.Context) {
+	key := ctx.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		return
+	} else if len(key) > maxIdempotencyKeyLength {
+		ctx.String(http.StatusBadRequest, idempotencyKeyHeader+" must not be longer than "+strconv.Itoa(maxIdempotencyKeyLength)+" characters")
+		ctx.Abort()
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(ctx.Request.Body, maxIdempotentBodySize+1))
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		ctx.Abort()
+		return
+	} else if len(body) > maxIdempotentBodySize {
+		ctx.String(http.StatusRequestEntityTooLarge, "request body must not be larger than "+strconv.Itoa(maxIdempotentBodySize)+" bytes")
+		ctx.Abort()
+		return
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	hash := sha256.New()
+	io.WriteString(hash, ctx.Request.Method+" "+ctx.Request.URL.RequestURI()+"\n")
+	hash.Write(body)
+	requestHash := hex.EncodeToString(hash.Sum(nil))
+
+	db, client := tenantDB(ctx), clientOf(ctx)
+	now := time.Now()
+	lease := srv.requestTimeout + idempotencyStoreTimeout + idempotencyLeaseMargin
+	stored, err := db.StartIdempotentRequest(ctx.Request.Context(), client, key, requestHash, now.Add(-lease), now.Add(-idempotencyKeyTTL))
+	if err != nil {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+		ctx.Abort()
+		return
+	} else if stored != nil {
+		ctx.Header(idempotentReplayedHeader, "true")
+		if stored.Location != "" {
+			ctx.Header("Location", stored.Location)
+		}
+		ctx.Data(stored.StatusCode, stored.ContentType, stored.Body)
+		ctx.Abort()
+		return
+	}
+	recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+	saved := false
+	storeCtx, cancel := context.WithTimeout(context.Background(), idempotencyStoreTimeout)
+	defer cancel()
+	// The key is released if the handler panics
+	defer func() {
+		if !saved {
+			if err := db.DeleteIdempotencyKey(storeCtx, client, key); err != nil {
+				srv.requestLogger(ctx).Error("idempotency key is not released", "key", key, "error", err)
+			}
+		}
+	}()
+	ctx.Next()
+	if recorder.Status() >= http.StatusInternalServerError {
+		return
+	}
+	response := models.IdempotentResponse{
+		StatusCode:  recorder.Status(),
+		ContentType: recorder.Header().Get("Content-Type"),
+		Location:    recorder.Header().Get("Location"),
+		Body:        recorder.body.Bytes(),
+	}
+	if err := db.SaveIdempotentResponse(storeCtx, client, key, response); err != nil {
+		srv.requestLogger(ctx).Error("idempotent response is not saved", "key", key, "error", err)
+		return
+	}
+	saved = true
+}
+
+// responseRecorder copies the response body written by handlers
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	recorder.body.Write(data)
+	return recorder.ResponseWriter.Write(data)
+}
+
+func (recorder *responseRecorder) WriteString(s string) (int, error) {
+	recorder.body.WriteString(s)
+	return recorder.ResponseWriter.WriteString(s)
+}
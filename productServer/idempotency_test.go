@@ -0,0 +1,173 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/logging"
+	"XsollaSchoolBE/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	product := models.InputProduct{SKU: "IDEMPOTENT", Name: "Retried game", Type: "Game", Cost: 15}
+	defer deleteProduct(product.SKU)
+
+	first, firstBody := sendIdempotentRequest(t, "", "create-product", baseUrl, product)
+	if first.StatusCode != http.StatusCreated {
+		t.Fatalf("First request: bad status code %d\nResponse body: %s", first.StatusCode, firstBody)
+	}
+	// Retry gets the original response instead of conflict
+	retry, retryBody := sendIdempotentRequest(t, "", "create-product", baseUrl, product)
+	if retry.StatusCode != http.StatusCreated || retryBody != firstBody {
+		t.Fatalf("Retry: bad response %d %s, expected %d %s", retry.StatusCode, retryBody, first.StatusCode, firstBody)
+	}
+	if retry.Header.Get(idempotentReplayedHeader) != "true" || retry.Header.Get("Location") != first.Header.Get("Location") {
+		t.Fatalf("Retry: bad headers %v", retry.Header)
+	}
+	if resp, body := sendIdempotentRequest(t, "", "", baseUrl, product); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("Request without key: bad status code %d\nResponse body: %s", resp.StatusCode, body)
+	}
+
+	// The key can not be reused for another request
+	changed := product
+	changed.Cost = 20
+	if resp, body := sendIdempotentRequest(t, "", "create-product", baseUrl, changed); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Reused key: bad status code %d\nResponse body: %s", resp.StatusCode, body)
+	}
+	if resp, body := sendIdempotentRequest(t, "", "create-product", serverUrl+"/api/v1/categories", models.InputCategory{Name: "Retried"}); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("Reused key for other URL: bad status code %d\nResponse body: %s", resp.StatusCode, body)
+	}
+
+	// Keys of different clients are independent
+	key, err := testServer.db.AddAPIKey(context.Background(), models.InputAPIKey{Name: "other client", Scopes: []string{models.WriteScope}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, body := sendIdempotentRequest(t, key.Key, "create-product", baseUrl, product); resp.StatusCode != http.StatusConflict || resp.Header.Get(idempotentReplayedHeader) != "" {
+		t.Fatalf("Key of other client: bad status code %d\nResponse body: %s", resp.StatusCode, body)
+	}
+
+	// Requests without permissions are rejected before taking the key
+	jsonProduct, _ := json.Marshal(product)
+	for i := 0; i < 2; i++ {
+		request, _ := http.NewRequest(http.MethodPost, baseUrl, bytes.NewBuffer(jsonProduct))
+		request.Header.Set(idempotencyKeyHeader, "create-product")
+		resp, err := anonymousClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get(idempotentReplayedHeader) != "" {
+			t.Fatalf("Anonymous request %d: bad status code %d or replayed rejection", i+1, resp.StatusCode)
+		}
+	}
+
+	// Error responses are replayed too
+	invalid := models.InputProduct{SKU: "IDEMPOTENT-VARIANT", ParentSKU: "MISSING", Cost: 1}
+	for i := 0; i < 2; i++ {
+		if resp, body := sendIdempotentRequest(t, "", "create-variant", baseUrl, invalid); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Request %d of invalid product: bad status code %d\nResponse body: %s", i+1, resp.StatusCode, body)
+		} else if replayed := resp.Header.Get(idempotentReplayedHeader) == "true"; replayed != (i == 1) {
+			t.Fatalf("Request %d of invalid product: bad %s header", i+1, idempotentReplayedHeader)
+		}
+	}
+}
+
+func TestIdempotencyKeyOfCancelledRequest(t *testing.T) {
+	product := models.InputProduct{SKU: "IDEMPOTENT-TIMEOUT", Name: "Slow game", Type: "Game", Cost: 15}
+	defer deleteProduct(product.SKU)
+	db, timeout := testServer.db, testServer.requestTimeout
+	testServer.db, testServer.requestTimeout = stalledDB{db}, 50*time.Millisecond
+	resp, body := sendIdempotentRequest(t, "", "create-slow-product", baseUrl, product)
+	testServer.db, testServer.requestTimeout = db, timeout
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Request over deadline: bad status code %d\nResponse body: %s", resp.StatusCode, body)
+	}
+
+	// The key is released, so the retry is performed
+	if resp, body := sendIdempotentRequest(t, "", "create-slow-product", baseUrl, product); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("Retry: bad status code %d\nResponse body: %s", resp.StatusCode, body)
+	} else if resp.Header.Get(idempotentReplayedHeader) != "" {
+		t.Fatal("Retry: failed response is replayed")
+	}
+}
+
+func TestIdempotencyKeyOfLostRequest(t *testing.T) {
+	ctx, db := context.Background(), testServer.db
+	client, key, hash := "key:lost", "lost-request", "hash"
+	defer db.DeleteIdempotencyKey(ctx, client, key)
+	start := func(abandonedBefore time.Time) error {
+		_, err := db.StartIdempotentRequest(ctx, client, key, hash, abandonedBefore, time.Now().Add(-idempotencyKeyTTL))
+		return err
+	}
+	// The request is lost without saving the response or releasing the key
+	if err := start(time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := start(time.Now().Add(-time.Hour)); !errors.Is(err, DB.IdempotentRequestInProgressError) {
+		t.Fatalf("Retry within the lease: %v", err)
+	}
+	// The retry after the lease takes the key, other retries wait for it
+	if err := start(time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Retry after the lease: %v", err)
+	}
+	if err := start(time.Now().Add(-time.Hour)); !errors.Is(err, DB.IdempotentRequestInProgressError) {
+		t.Fatalf("Retry of taken key: %v", err)
+	}
+}
+
+// stalledDB adds and lists products after contexts of requests are done, so they fail with the error of the context
+type stalledDB struct {
+	DB.DB
+}
+
+func (db stalledDB) WithTenant(tenant string) DB.DB {
+	return stalledDB{db.DB.WithTenant(tenant)}
+}
+
+func (db stalledDB) WithLogger(logger *logging.Logger) DB.DB {
+	return stalledDB{db.DB.WithLogger(logger)}
+}
+
+func (db stalledDB) AddProduct(ctx context.Context, product models.InputProduct) (*models.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (db stalledDB) GetAllProducts(ctx context.Context, filter models.ProductFilter) ([]*models.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (db stalledDB) GetGroupOfProducts(ctx context.Context, groupSize uint, groupNum uint, filter models.ProductFilter) ([]*models.Product, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// sendIdempotentRequest sends POST request with the idempotency key, it is sent with admin key if key is empty
+func sendIdempotentRequest(t *testing.T, key string, idempotencyKey string, url string, body interface{}) (*http.Response, string) {
+	jsonBody, _ := json.Marshal(body)
+	request, _ := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonBody))
+	request.Header.Set("Content-Type", "application/json")
+	if idempotencyKey != "" {
+		request.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+	client := http.DefaultClient
+	if key != "" {
+		request.Header.Set(apiKeyHeader, key)
+		client = anonymousClient
+	}
+	resp, err := client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp, string(data)
+}
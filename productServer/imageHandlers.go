@@ -0,0 +1,246 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/models"
+	"XsollaSchoolBE/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"image"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+)
+
+// maxImageSize is the maximal size of uploaded image file in bytes
+const maxImageSize = 10 << 20
+
+// maxImageRequestSize is the maximal size of image upload requests, the form may contain other small fields
+const maxImageRequestSize = maxImageSize + 1<<20
+
+// getProductImages godoc
+// @Summary get images of the product with specific SKU
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Success 200 {array} models.Image
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Router /products/{SKU}/images [get]
+func (srv *ProductServer) getProductImages(ctx *gin.Context) {
+	var images []*models.Image
+	err := checkSKUVisible(ctx, ctx.Param("SKU"))
+	if err == nil {
+		images, err = tenantDB(ctx).GetProductImages(ctx.Request.Context(), ctx.Param("SKU"))
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, images)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// addProductImage godoc
+// @Summary upload image of the product with specific SKU
+// @Description Image in JPEG, PNG or GIF format is added after other images of the product.
+// @Description Its thumbnail fits into 200x200 square.
+// @Accept multipart/form-data
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Param image formData file true "image file up to 10 MB"
+// @Param Idempotency-Key header string false "unique key of the operation, retries with the same key get the first response"
+// @Success 201 {object} models.Image
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 413 {object} string
+// @Failure 409 {object} string "request with the idempotency key is still processed"
+// @Failure 422 {object} string "idempotency key is used for another request"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/images [post]
+func (srv *ProductServer) addProductImage(ctx *gin.Context) {
+	SKU := ctx.Param("SKU")
+	// The form is parsed only up to the limit, larger bodies are not read entirely
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImageRequestSize)
+	fileHeader, err := ctx.FormFile("image")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		ctx.String(http.StatusRequestEntityTooLarge, "request body must not be larger than "+strconv.Itoa(maxImageRequestSize)+" bytes")
+		return
+	} else if err != nil {
+		ctx.String(http.StatusBadRequest, "image file must be sent in image field of multipart form: "+err.Error())
+		return
+	} else if fileHeader.Size > maxImageSize {
+		ctx.String(http.StatusRequestEntityTooLarge, "image file must not be larger than "+strconv.Itoa(maxImageSize)+" bytes")
+		return
+	}
+	data, err := readFormFile(fileHeader)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if _, supported := imageExtensions[format]; err != nil || !supported {
+		ctx.String(http.StatusBadRequest, "image must be in JPEG, PNG or GIF format")
+		return
+	}
+	// Blobs are not saved for not existing products
+	if _, err = tenantDB(ctx).GetProductBySKU(ctx.Request.Context(), SKU); err != nil {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+		return
+	}
+
+	thumbnail, thumbnailExtension, err := encodeThumbnail(img, format)
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	name, err := randomBlobName()
+	if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	newImage := models.Image{
+		Key:          "images/" + name + imageExtensions[format],
+		ThumbnailKey: "images/" + name + "_thumbnail" + thumbnailExtension,
+	}
+	newImage.URL = srv.blobs.URL(newImage.Key)
+	newImage.ThumbnailURL = srv.blobs.URL(newImage.ThumbnailKey)
+	if err = srv.blobs.Save(newImage.Key, bytes.NewReader(data)); err == nil {
+		err = srv.blobs.Save(newImage.ThumbnailKey, bytes.NewReader(thumbnail))
+	}
+	var addedImage *models.Image
+	if err == nil {
+		addedImage, err = tenantDB(ctx).AddProductImage(ctx.Request.Context(), SKU, newImage)
+	}
+	if err == nil {
+		ctx.JSON(http.StatusCreated, addedImage)
+	} else {
+		srv.deleteImageFiles(ctx, []*models.Image{&newImage})
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// reorderProductImages godoc
+// @Summary change order of images of the product with specific SKU
+// @Accept json
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Param ids body []int64 true "ids of all images of the product in the new order"
+// @Success 200 {array} models.Image
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/images [put]
+func (srv *ProductServer) reorderProductImages(ctx *gin.Context) {
+	var ids []int64
+	if err := ctx.ShouldBindJSON(&ids); err != nil {
+		ctx.String(http.StatusBadRequest, "json format error: "+err.Error())
+		return
+	}
+	if images, err := tenantDB(ctx).ReorderProductImages(ctx.Request.Context(), ctx.Param("SKU"), ids); err == nil {
+		ctx.JSON(http.StatusOK, images)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// deleteProductImage godoc
+// @Summary delete image of the product with specific SKU
+// @Param SKU path string true "SKU of the product"
+// @Param id path int true "Id of the image"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product does not exist or has no such image"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/images/{id} [delete]
+func (srv *ProductServer) deleteProductImage(ctx *gin.Context) {
+	id, err := getIdFromPath(ctx, "id")
+	if err != nil {
+		ctx.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if image, err := tenantDB(ctx).DeleteProductImage(ctx.Request.Context(), ctx.Param("SKU"), id); err == nil {
+		srv.deleteImageFiles(ctx, []*models.Image{image})
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getMedia returns a file from blob storage, URLs of images stored in local file storage lead here
+func (srv *ProductServer) getMedia(ctx *gin.Context) {
+	key := ctx.Param("key")[1:]
+	blob, err := srv.blobs.Open(key)
+	if err == storage.BlobNotFoundError {
+		ctx.String(http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		ctx.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer blob.Close()
+	ctx.Header("Content-Type", mime.TypeByExtension(path.Ext(key)))
+	ctx.Status(http.StatusOK)
+	io.Copy(ctx.Writer, blob)
+}
+
+// productImages returns images of the product and its variants, which are deleted with it
+func productImages(ctx context.Context, db DB.DB, prod *models.Product) []*models.Image {
+	if prod == nil {
+		return nil
+	}
+	images := prod.Images
+	if variants, err := db.GetVariants(ctx, prod.SKU); err == nil {
+		for _, variant := range variants {
+			images = append(images, variant.Images...)
+		}
+	}
+	return images
+}
+
+// deleteImageFiles removes files of the images and their thumbnails from blob storage
+func (srv *ProductServer) deleteImageFiles(ctx *gin.Context, images []*models.Image) {
+	for _, image := range images {
+		for _, key := range []string{image.Key, image.ThumbnailKey} {
+			if err := srv.blobs.Delete(key); err != nil && err != storage.BlobNotFoundError {
+				srv.requestLogger(ctx).Warn("image file is not deleted", "key", key, "error", err)
+			}
+		}
+	}
+}
+
+func readFormFile(fileHeader *multipart.FileHeader) ([]byte, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ioutil.ReadAll(io.LimitReader(file, maxImageSize))
+}
+
+func randomBlobName() (string, error) {
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(name), nil
+}
@@ -0,0 +1,126 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestImages(t *testing.T) {
+	product := models.InputProduct{SKU: "PICTURED", Name: "Pictured", Type: "Game", Cost: 10}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+
+	var pngData, jpegData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 400, 100)))
+	jpeg.Encode(&jpegData, image.NewRGBA(image.Rect(0, 0, 50, 80)), nil)
+	var uploaded []*models.Image
+	for _, data := range [][]byte{pngData.Bytes(), jpegData.Bytes()} {
+		code, body := uploadImage(baseUrl+"/PICTURED/images", data)
+		var img models.Image
+		if code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		} else if err := json.Unmarshal([]byte(body), &img); err != nil {
+			t.Fatal(err)
+		}
+		uploaded = append(uploaded, &img)
+	}
+
+	for i, expectedSize := range []image.Point{{200, 50}, {50, 80}} {
+		resp, err := http.Get(serverUrl + uploaded[i].ThumbnailURL)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		thumbnail, _, err := image.Decode(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Error(err)
+		} else if thumbnail.Bounds().Size() != expectedSize {
+			t.Errorf("Wrong thumbnail size: %v", thumbnail.Bounds().Size())
+		}
+	}
+
+	ids := []int64{uploaded[1].Id, uploaded[0].Id}
+	if code, body := sendRequest(http.MethodPut, baseUrl+"/PICTURED/images", ids); code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	prod, err, _ := getProductFromURL(baseUrl + "/PICTURED")
+	if err != nil {
+		t.Error(err)
+	} else if len(prod.Images) != 2 || prod.Images[0].Id != ids[0] || prod.Images[0].URL != uploaded[1].URL {
+		t.Errorf("Wrong product images: %v", prod.Images)
+	}
+	// Pages of the catalog load images of all their products at once
+	if products, err := getProductsFromURL(baseUrl + "?type=Game&groupSize=100&groupNum=1"); err != nil {
+		t.Error(err)
+	} else {
+		for _, listed := range products {
+			if listed.SKU == product.SKU && (len(listed.Images) != 2 || listed.Images[0].Id != ids[0]) {
+				t.Errorf("Wrong images of listed product: %v", listed.Images)
+			} else if listed.SKU != product.SKU && len(listed.Images) != 0 {
+				t.Errorf("Images of another product are listed with %s: %v", listed.SKU, listed.Images)
+			}
+		}
+	}
+	for _, wrongIds := range [][]int64{{ids[0]}, {ids[0], ids[0]}, {ids[0], ids[1], 0}} {
+		if code, _ := sendRequest(http.MethodPut, baseUrl+"/PICTURED/images", wrongIds); code != http.StatusBadRequest {
+			t.Errorf("not 400 code for wrong image order %v: %d", wrongIds, code)
+		}
+	}
+
+	if code, body := sendRequest(http.MethodDelete, baseUrl+"/PICTURED/images/"+strconv.FormatInt(ids[0], 10), nil); code != http.StatusNoContent {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	if code, _ := sendRequest(http.MethodGet, serverUrl+uploaded[1].URL, nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for file of deleted image: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodDelete, baseUrl+"/PICTURED/images/"+strconv.FormatInt(ids[0], 10), nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for deleting not existing image: %d", code)
+	}
+
+	if code, _ := uploadImage(baseUrl+"/PICTURED/images", []byte("not an image")); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for uploading not an image: %d", code)
+	}
+	for _, size := range []int{maxImageSize + 1, maxImageRequestSize + 1} {
+		if code, body := uploadImage(baseUrl+"/PICTURED/images", make([]byte, size)); code != http.StatusRequestEntityTooLarge {
+			t.Errorf("not 413 code for uploading %d bytes: %d %s", size, code, body)
+		}
+	}
+	if code, _ := uploadImage(baseUrl+"/UNKNOWN/images", pngData.Bytes()); code != http.StatusNotFound {
+		t.Errorf("not 404 code for uploading image of not existing product: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodGet, serverUrl+"/media/../testDB.db", nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for media outside of storage: %d", code)
+	}
+
+	deleteProduct(product.SKU)
+	if code, _ := sendRequest(http.MethodGet, serverUrl+uploaded[0].URL, nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for image file of deleted product: %d", code)
+	}
+}
+
+func uploadImage(url string, data []byte) (int, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("image", "image")
+	part.Write(data)
+	writer.Close()
+	resp, err := http.Post(url, writer.FormDataContentType(), &body)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	var respBody bytes.Buffer
+	respBody.ReadFrom(resp.Body)
+	return resp.StatusCode, respBody.String()
+}
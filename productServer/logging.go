@@ -0,0 +1,92 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/logging"
+	"XsollaSchoolBE/tracing"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+)
+
+// requestIDHeader is a header with id of the request, it is propagated from the request or generated
+const requestIDHeader = "X-Request-ID"
+
+// validRequestID limits propagated ids, so clients can not inject arbitrary text into logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// logRequests assigns an id to the request, returns it in X-Request-ID header and writes a line about the handled
+// request. Handlers and the database get the logger adding the request id to every line through the request context.
+func (srv *ProductServer) logRequests(ctx *gin.Context) {
+	start := time.Now()
+	requestID := ctx.GetHeader(requestIDHeader)
+	if !validRequestID.MatchString(requestID) {
+		requestID = newRequestID()
+	}
+	ctx.Header(requestIDHeader, requestID)
+	logger := srv.logger.With("request_id", requestID)
+	if span := tracing.SpanFromContext(ctx.Request.Context()); span != nil {
+		logger = logger.With("trace_id", span.SpanContext().TraceID)
+		span.SetAttributes("request_id", requestID)
+	}
+	ctx.Request = ctx.Request.WithContext(logging.NewContext(ctx.Request.Context(), logger))
+	ctx.Next()
+	status := ctx.Writer.Status()
+	level := logging.InfoLevel
+	if status >= http.StatusInternalServerError {
+		level = logging.ErrorLevel
+	}
+	if !logger.Enabled(level) {
+		return
+	}
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	fields := []interface{}{
+		"method", ctx.Request.Method,
+		"path", ctx.Request.URL.Path,
+		"route", route,
+		"status", status,
+		"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+		"bytes", ctx.Writer.Size(),
+		"ip", ctx.ClientIP(),
+	}
+	if client := getPrincipal(ctx); client != nil {
+		fields = append(fields, "subject", client.Subject, "tenant", client.Tenant)
+	}
+	if len(ctx.Errors) > 0 {
+		fields = append(fields, "error", ctx.Errors.String())
+	}
+	logger.Log(level, "request", fields...)
+}
+
+// recoverPanic responds with 500 status code to requests, which handlers panic, and logs the stack
+func (srv *ProductServer) recoverPanic() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(ioutil.Discard, func(ctx *gin.Context, err interface{}) {
+		srv.requestLogger(ctx).Error("panic", "error", fmt.Sprint(err), "stack", string(debug.Stack()))
+		ctx.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// requestLogger returns the logger adding id of the request to lines
+func (srv *ProductServer) requestLogger(ctx *gin.Context) *logging.Logger {
+	if logger := logging.FromContext(ctx.Request.Context()); logger != nil {
+		return logger
+	}
+	return srv.logger
+}
+
+// newRequestID generates 128 random bits in hex
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
+}
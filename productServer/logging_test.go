@@ -0,0 +1,89 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/logging"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer collects lines written by the server and handlers running in other goroutines
+type syncBuffer struct {
+	mutex sync.Mutex
+	buf   bytes.Buffer
+}
+
+func (b *syncBuffer) Write(data []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buf.Write(data)
+}
+
+func (b *syncBuffer) lines() []map[string]interface{} {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	lines := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		var fields map[string]interface{}
+		if json.Unmarshal([]byte(line), &fields) == nil {
+			lines = append(lines, fields)
+		}
+	}
+	return lines
+}
+
+func TestRequestLogging(t *testing.T) {
+	var out syncBuffer
+	logger := testServer.logger
+	testServer.logger = logging.New(&out, logging.DebugLevel)
+	defer func() { testServer.logger = logger }()
+
+	sendWithID := func(requestID string) string {
+		request, _ := http.NewRequest(http.MethodGet, baseUrl+"/TEST1231", nil)
+		if requestID != "" {
+			request.Header.Set(requestIDHeader, requestID)
+		}
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.Header.Get(requestIDHeader)
+	}
+	// Valid ids are propagated, others are replaced with generated ones
+	if id := sendWithID("test-request.1"); id != "test-request.1" {
+		t.Fatalf("Request id is not propagated: %q", id)
+	}
+	generated := sendWithID("")
+	if len(generated) != 32 {
+		t.Fatalf("Request id is not generated: %q", generated)
+	}
+	if id := sendWithID("bad id\"}"); id == "bad id\"}" || len(id) != 32 || id == generated {
+		t.Fatalf("Invalid request id is not replaced: %q", id)
+	}
+
+	var requestLine, dbLine map[string]interface{}
+	for _, line := range out.lines() {
+		if line["request_id"] != "test-request.1" {
+			continue
+		}
+		if line["msg"] == "request" {
+			requestLine = line
+		} else if line["operation"] == "GetProductBySKU" {
+			dbLine = line
+		}
+	}
+	if requestLine == nil || dbLine == nil {
+		t.Fatalf("Request and database operation are not logged with the request id\nLog: %s", out.buf.String())
+	}
+	if requestLine["level"] != "info" || requestLine["route"] != "/api/v1/products/:SKU" || requestLine["status"] == nil ||
+		requestLine["latency_ms"] == nil || requestLine["tenant"] != "default" {
+		t.Fatalf("Bad request line: %v", requestLine)
+	}
+	if dbLine["level"] != "debug" || dbLine["latency_ms"] == nil {
+		t.Fatalf("Bad database operation line: %v", dbLine)
+	}
+}
@@ -0,0 +1,106 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/metrics"
+	"github.com/gin-gonic/gin"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels requests not matching any route, so unknown paths do not create new series
+const unmatchedRoute = "unmatched"
+
+// serverMetrics are metrics of requests and database operations exposed on /metrics
+type serverMetrics struct {
+	registry        *metrics.Registry
+	requests        *metrics.Counter
+	requestDuration *metrics.Histogram
+	dbDuration      *metrics.Histogram
+	dbErrors        *metrics.Counter
+}
+
+func newServerMetrics() *serverMetrics {
+	registry := metrics.NewRegistry()
+	return &serverMetrics{
+		registry: registry,
+		requests: registry.NewCounter("http_requests_total",
+			"Number of handled requests by route and status code.", "method", "route", "code"),
+		requestDuration: registry.NewHistogram("http_request_duration_seconds",
+			"Duration of handling requests by route.", metrics.DefaultBuckets, "method", "route"),
+		dbDuration: registry.NewHistogram("db_operation_duration_seconds",
+			"Duration of database operations.", metrics.DefaultBuckets, "operation"),
+		dbErrors: registry.NewCounter("db_operation_errors_total",
+			"Number of database operations returning errors including not found and validation errors.", "operation"),
+	}
+}
+
+// observeDB is DB.Observer of the instrumented database
+func (m *serverMetrics) observeDB(operation string, duration time.Duration, err error) {
+	m.dbDuration.Observe(duration.Seconds(), operation)
+	if err != nil {
+		m.dbErrors.Inc(operation)
+	}
+}
+
+// registerPoolStats exposes statistics of the database connection pool
+func (m *serverMetrics) registerPoolStats(pool DB.PoolStats) {
+	m.registry.NewFunc("db_connections", "Number of database connections by state.", "gauge", []string{"state"},
+		func(set func(float64, ...string)) {
+			stats := pool.Stats()
+			set(float64(stats.InUse), "in_use")
+			set(float64(stats.Idle), "idle")
+		})
+	m.registry.NewFunc("db_connections_max_open", "Maximum number of open database connections, 0 is unlimited.", "gauge", nil,
+		func(set func(float64, ...string)) {
+			set(float64(pool.Stats().MaxOpenConnections))
+		})
+	m.registry.NewFunc("db_connection_waits_total", "Number of waits for a free database connection.", "counter", nil,
+		func(set func(float64, ...string)) {
+			set(float64(pool.Stats().WaitCount))
+		})
+	m.registry.NewFunc("db_connection_wait_seconds_total", "Total time of waiting for free database connections.", "counter", nil,
+		func(set func(float64, ...string)) {
+			set(pool.Stats().WaitDuration.Seconds())
+		})
+}
+
+// registerCacheStats exposes statistics of the product cache
+func (m *serverMetrics) registerCacheStats(cache DB.CacheStatistics) {
+	m.registry.NewFunc("db_cache_requests_total", "Number of lookups of the product cache by operation and result.", "counter",
+		[]string{"operation", "result"},
+		func(set func(float64, ...string)) {
+			stats := cache.CacheStats()
+			operations := make([]string, 0, len(stats.Hits))
+			for operation := range stats.Hits {
+				operations = append(operations, operation)
+			}
+			sort.Strings(operations)
+			for _, operation := range operations {
+				set(float64(stats.Hits[operation]), operation, "hit")
+				set(float64(stats.Misses[operation]), operation, "miss")
+			}
+		})
+	m.registry.NewFunc("db_cache_evictions_total", "Number of results evicted from the product cache by the size limit.", "counter", nil,
+		func(set func(float64, ...string)) {
+			set(float64(cache.CacheStats().Evictions))
+		})
+	m.registry.NewFunc("db_cache_entries", "Number of results in the product cache.", "gauge", nil,
+		func(set func(float64, ...string)) {
+			set(float64(cache.CacheStats().Entries))
+		})
+}
+
+// measureRequest counts requests and observes their durations by route pattern
+func (srv *ProductServer) measureRequest(ctx *gin.Context) {
+	start := time.Now()
+	ctx.Next()
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	method := ctx.Request.Method
+	srv.metrics.requests.Inc(method, route, strconv.Itoa(ctx.Writer.Status()))
+	srv.metrics.requestDuration.Observe(time.Since(start).Seconds(), method, route)
+}
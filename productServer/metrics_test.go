@@ -0,0 +1,41 @@
+package productServer
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var sampleLine = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*(\{([a-zA-Z_][a-zA-Z0-9_]*="([^"\\]|\\.)*",?)*\})? \S+$`)
+
+func TestMetrics(t *testing.T) {
+	for _, url := range []string{baseUrl + "/TEST1231", serverUrl + "/no/such/path"} {
+		sendRequest(http.MethodGet, url, nil)
+	}
+	code, body := sendRequest(http.MethodGet, serverUrl+"/metrics", nil)
+	if code != http.StatusOK {
+		t.Fatalf("Bad status code: %d\nResponse body: %s", code, body)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if !strings.HasPrefix(line, "# HELP ") && !strings.HasPrefix(line, "# TYPE ") && !sampleLine.MatchString(line) {
+			t.Fatalf("Line is not in Prometheus text format: %s", line)
+		}
+	}
+	expected := []string{
+		`# TYPE http_requests_total counter`,
+		`http_requests_total{method="GET",route="/api/v1/products/:SKU",code="`,
+		`http_requests_total{method="GET",route="unmatched",code="404"} `,
+		`http_request_duration_seconds_bucket{method="GET",route="/api/v1/products/:SKU",le="+Inf"} `,
+		`# TYPE http_request_duration_seconds histogram`,
+		`db_operation_duration_seconds_count{operation="GetAPIKey"} `,
+		`db_operation_duration_seconds_sum{operation="GetProductBySKU"} `,
+		`db_connections{state="idle"} `,
+		`# TYPE db_connection_waits_total counter`,
+	}
+	for _, sample := range expected {
+		if !strings.Contains(body, sample) {
+			t.Errorf("Metrics do not contain %s", sample)
+		}
+	}
+}
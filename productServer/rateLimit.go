@@ -0,0 +1,50 @@
+package productServer
+
+import (
+	"github.com/gin-gonic/gin"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// limitRate rejects requests of clients exceeding their limits with 429 status code.
+// Clients are identified by clientOf.
+// Responses contain RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers
+// of the most restrictive applied limit, rejected ones also contain Retry-After header.
+func (srv *ProductServer) limitRate(ctx *gin.Context) {
+	if !srv.allowRequest(ctx, clientOf(ctx)) {
+		ctx.Abort()
+	}
+}
+
+// allowRequest counts the request of the client and responds with 429 status code if it is over the limits
+func (srv *ProductServer) allowRequest(ctx *gin.Context, client string) bool {
+	if srv.limiter == nil {
+		return true
+	}
+	result, applied, err := srv.limiter.Allow(client, ctx.Request.Method+" "+ctx.FullPath())
+	if err != nil {
+		// Unavailable store of limits must not make the service unavailable
+		srv.requestLogger(ctx).Error("rate limiter failed", "error", err)
+		return true
+	} else if !applied {
+		return true
+	}
+	header := ctx.Writer.Header()
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit.Requests))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", seconds(result.Reset))
+	header.Set("RateLimit-Policy", strconv.Itoa(result.Limit.Requests)+";w="+seconds(result.Limit.Period))
+	if !result.Allowed {
+		header.Set("Retry-After", seconds(result.RetryAfter))
+		ctx.String(http.StatusTooManyRequests, "rate limit "+result.Limit.String()+" is exceeded")
+		return false
+	}
+	return true
+}
+
+// seconds formats the duration as a number of seconds rounded up
+func seconds(duration time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(duration.Seconds())))
+}
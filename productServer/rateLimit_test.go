@@ -0,0 +1,123 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"XsollaSchoolBE/rateLimit"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	var keys []*models.NewAPIKey
+	for _, name := range []string{"quota", "routes"} {
+		key, err := testServer.db.AddAPIKey(context.Background(), models.InputAPIKey{Name: name, Scopes: []string{models.ReadScope}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	quotaKey, routesKey := keys[0], keys[1]
+	testServer.limiter = rateLimit.NewLimiter(rateLimit.Config{
+		Default: rateLimit.Limit{Requests: 3, Period: time.Hour},
+		Routes:  map[string]rateLimit.Limit{"GET /api/v1/products/suggestions": {Requests: 1, Period: time.Hour}},
+		Clients: map[string]rateLimit.Limit{"key:" + strconv.FormatInt(quotaKey.Id, 10): {Requests: 5, Period: time.Hour}},
+	}, rateLimit.NewMemoryStore())
+	defer func() { testServer.limiter = nil }()
+
+	// Anonymous client is limited by default limit, API key with quota has its own bucket
+	clients := []struct {
+		key      string
+		requests int
+	}{{"", 3}, {quotaKey.Key, 5}}
+	for _, client := range clients {
+		for i := 1; i <= client.requests+1; i++ {
+			resp := sendLimitedRequest(t, client.key, serverUrl+"/")
+			if i <= client.requests {
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("Request %d of %d allowed: bad status code %d", i, client.requests, resp.StatusCode)
+				}
+				if remaining := resp.Header.Get("RateLimit-Remaining"); remaining != strconv.Itoa(client.requests-i) {
+					t.Fatalf("Request %d of %d allowed: bad RateLimit-Remaining %q", i, client.requests, remaining)
+				}
+				continue
+			}
+			if resp.StatusCode != http.StatusTooManyRequests {
+				t.Fatalf("Request over limit %d: bad status code %d", client.requests, resp.StatusCode)
+			}
+			// Token is returned every 12 minutes with 5 requests per hour, every 20 minutes with 3 ones
+			retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			if expected := 3600 / client.requests; retryAfter < expected-1 || retryAfter > expected {
+				t.Fatalf("Request over limit %d: bad Retry-After %q", client.requests, resp.Header.Get("Retry-After"))
+			}
+			if resp.Header.Get("RateLimit-Limit") != strconv.Itoa(client.requests) || resp.Header.Get("RateLimit-Policy") != strconv.Itoa(client.requests)+";w=3600" {
+				t.Fatalf("Request over limit %d: bad headers %v", client.requests, resp.Header)
+			}
+		}
+	}
+
+	// Route limit is checked in addition to default one
+	if resp := sendLimitedRequest(t, routesKey.Key, baseUrl+"/suggestions?prefix=a"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("First suggestions request: bad status code %d", resp.StatusCode)
+	} else if resp.Header.Get("RateLimit-Limit") != "1" || resp.Header.Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("First suggestions request: bad headers %v", resp.Header)
+	}
+	if resp := sendLimitedRequest(t, routesKey.Key, baseUrl+"/suggestions?prefix=a"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Second suggestions request: bad status code %d", resp.StatusCode)
+	}
+	if resp := sendLimitedRequest(t, routesKey.Key, serverUrl+"/"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Request to other route: bad status code %d", resp.StatusCode)
+	} else if resp.Header.Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("Request to other route: bad RateLimit-Remaining %q", resp.Header.Get("RateLimit-Remaining"))
+	}
+}
+
+func sendLimitedRequest(t *testing.T, key string, url string) *http.Response {
+	request, _ := http.NewRequest(http.MethodGet, url, nil)
+	if key != "" {
+		request.Header.Set(apiKeyHeader, key)
+	}
+	resp, err := anonymousClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestRateLimitBypass(t *testing.T) {
+	testServer.limiter = rateLimit.NewLimiter(rateLimit.Config{
+		Default: rateLimit.Limit{Requests: 2, Period: time.Hour},
+	}, rateLimit.NewMemoryStore())
+	defer func() { testServer.limiter = nil }()
+
+	// X-Forwarded-For of clients is ignored without trusted proxies
+	for i, forwardedFor := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		request, _ := http.NewRequest(http.MethodGet, serverUrl+"/", nil)
+		request.Header.Set("X-Forwarded-For", forwardedFor)
+		resp, err := anonymousClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if expected := map[bool]int{true: http.StatusOK, false: http.StatusTooManyRequests}[i < 2]; resp.StatusCode != expected {
+			t.Fatalf("Request %d from %s: bad status code %d", i+1, forwardedFor, resp.StatusCode)
+		}
+	}
+
+	// Requests with invalid credentials are limited by the IP address
+	testServer.limiter = rateLimit.NewLimiter(rateLimit.Config{
+		Default: rateLimit.Limit{Requests: 2, Period: time.Hour},
+	}, rateLimit.NewMemoryStore())
+	for i := 1; i <= 3; i++ {
+		resp := sendLimitedRequest(t, "invalid key", serverUrl+"/")
+		if expected := map[bool]int{true: http.StatusUnauthorized, false: http.StatusTooManyRequests}[i <= 2]; resp.StatusCode != expected {
+			t.Fatalf("Request %d with invalid key: bad status code %d", i, resp.StatusCode)
+		}
+	}
+	if resp := sendLimitedRequest(t, "", serverUrl+"/"); resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Anonymous request after invalid keys: bad status code %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,58 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// setProductSchedule godoc
+// @Summary set times of automatic publishing and unpublishing of the product with specific SKU
+// @Description Only drafts can be scheduled for publishing, unpublishing moves a published product back to drafts.
+// @Description Null time cancels the change, times are stored with precision of seconds.
+// @Accept json
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Param schedule body models.Schedule true "times in RFC 3339 format"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 409 {object} string "product status does not allow the change"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/schedule [put]
+func (srv *ProductServer) setProductSchedule(ctx *gin.Context) {
+	var schedule models.Schedule
+	if err := ctx.ShouldBindJSON(&schedule); err != nil {
+		ctx.String(http.StatusBadRequest, "json format error: "+err.Error())
+		return
+	}
+	if product, err := tenantDB(ctx).SetProductSchedule(ctx.Request.Context(), ctx.Param("SKU"), schedule); err == nil {
+		srv.scheduler.reschedule()
+		ctx.JSON(http.StatusOK, product)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getScheduledChanges godoc
+// @Summary get upcoming scheduled changes of product statuses ordered by time
+// @Produces json
+// @Success 200 {array} models.ScheduledChange
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/scheduled [get]
+func (srv *ProductServer) getScheduledChanges(ctx *gin.Context) {
+	changes, err := tenantDB(ctx).GetScheduledChanges(ctx.Request.Context())
+	if err == nil {
+		ctx.JSON(http.StatusOK, changes)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
@@ -0,0 +1,79 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSchedules(t *testing.T) {
+	for _, SKU := range []string{"SCHEDULED1", "SCHEDULED2"} {
+		product := models.InputProduct{SKU: SKU, Name: SKU, Type: "Scheduled", Cost: 60}
+		if code, body := sendRequest(http.MethodPost, baseUrl, product); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+		defer deleteProduct(SKU)
+	}
+
+	now := time.Now()
+	past, publishAt, unpublishAt := now.Add(-time.Hour), now.Add(time.Second), now.Add(2*time.Second)
+	if code, body := sendRequest(http.MethodPut, baseUrl+"/SCHEDULED1/schedule", models.Schedule{PublishAt: &publishAt, UnpublishAt: &unpublishAt}); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	if code, body := sendRequest(http.MethodPut, baseUrl+"/SCHEDULED2/schedule", models.Schedule{PublishAt: &past}); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	waitForStatus(t, "SCHEDULED2", models.PublishedStatus, now)
+
+	code, body := sendRequest(http.MethodGet, baseUrl+"/scheduled", nil)
+	var changes []models.ScheduledChange
+	if code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &changes); err != nil {
+		t.Error(err)
+	} else if len(changes) != 2 || changes[0].Status != models.PublishedStatus || changes[0].At.Unix() != publishAt.Unix() ||
+		changes[1].Status != models.DraftStatus || changes[1].At.Unix() != unpublishAt.Unix() {
+		t.Errorf("Wrong scheduled changes: %v", changes)
+	}
+
+	waitForStatus(t, "SCHEDULED1", models.PublishedStatus, publishAt)
+	waitForStatus(t, "SCHEDULED1", models.DraftStatus, unpublishAt)
+	if prod, err, _ := getProductFromURL(baseUrl + "/SCHEDULED1"); err != nil {
+		t.Error(err)
+	} else if prod.PublishAt != nil || prod.UnpublishAt != nil {
+		t.Errorf("Applied changes are still scheduled: %v", prod.Schedule)
+	}
+
+	for _, testCase := range []struct {
+		SKU      string
+		schedule models.Schedule
+		code     int
+	}{
+		{"SCHEDULED1", models.Schedule{PublishAt: &unpublishAt, UnpublishAt: &publishAt}, http.StatusBadRequest},
+		{"SCHEDULED1", models.Schedule{UnpublishAt: &unpublishAt}, http.StatusConflict},
+		{"SCHEDULED2", models.Schedule{PublishAt: &publishAt}, http.StatusConflict},
+		{"UNKNOWN", models.Schedule{PublishAt: &publishAt}, http.StatusNotFound},
+	} {
+		if code, body := sendRequest(http.MethodPut, baseUrl+"/"+testCase.SKU+"/schedule", testCase.schedule); code != testCase.code {
+			t.Errorf("Wrong status code of schedule %v for %s: %d\nResponse body: %s", testCase.schedule, testCase.SKU, code, body)
+		}
+	}
+}
+
+// waitForStatus waits for the scheduled change of the product status, which must be applied soon after the time
+func waitForStatus(t *testing.T, SKU string, status string, at time.Time) {
+	deadline := at.Add(2 * time.Second)
+	for {
+		prod, err, _ := getProductFromURL(baseUrl + "/" + SKU)
+		if err != nil {
+			t.Fatal(err)
+		} else if prod.Status == status {
+			return
+		} else if time.Now().After(deadline) {
+			t.Fatalf("Status of %s is not changed to %s in time: %s", SKU, status, prod.Status)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,77 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/logging"
+	"context"
+	"time"
+)
+
+// maxSchedulerSleep limits waiting for the next scheduled change, so changes made by other instances are noticed
+const maxSchedulerSleep = time.Minute
+
+// schedulerRetryInterval is a delay before applying scheduled changes again after a failure
+const schedulerRetryInterval = 5 * time.Second
+
+// scheduler applies scheduled publishing and unpublishing of products.
+// Schedules are stored in the database, so overdue changes are applied after restart.
+type scheduler struct {
+	db     DB.DB
+	logger *logging.Logger
+	// wake is signaled when schedules are changed
+	wake chan struct{}
+	// ctx is cancelled by shutdown, so applying changes is aborted
+	ctx  context.Context
+	stop context.CancelFunc
+	done chan struct{}
+}
+
+func startScheduler(db DB.DB, logger *logging.Logger) *scheduler {
+	s := &scheduler{
+		db:     db.WithLogger(logger),
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	s.ctx, s.stop = context.WithCancel(context.Background())
+	go s.run()
+	return s
+}
+
+func (s *scheduler) run() {
+	defer close(s.done)
+	for {
+		sleep := maxSchedulerSleep
+		if next, _, err := s.db.ApplyScheduledChanges(s.ctx, time.Now()); s.ctx.Err() != nil {
+			return
+		} else if err != nil {
+			s.logger.Error("scheduled changes are not applied", "error", err)
+			sleep = schedulerRetryInterval
+		} else if !next.IsZero() && time.Until(next) < sleep {
+			sleep = time.Until(next)
+		}
+		timer := time.NewTimer(sleep)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// reschedule makes the scheduler check the next scheduled change again
+func (s *scheduler) reschedule() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// shutdown stops the scheduler and waits until it aborts applying changes
+func (s *scheduler) shutdown() {
+	s.stop()
+	<-s.done
+}
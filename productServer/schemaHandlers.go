@@ -0,0 +1,88 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// getAttributeSchemas godoc
+// @Summary get attribute schemas of all product types
+// @Produces json
+// @Success 200 {array} models.AttributeSchema
+// @Failure 500 {object} string
+// @Router /types [get]
+func (srv *ProductServer) getAttributeSchemas(ctx *gin.Context) {
+	schemas, err := tenantDB(ctx).GetAllAttributeSchemas(ctx.Request.Context())
+	if err == nil {
+		ctx.JSON(http.StatusOK, schemas)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getAttributeSchema godoc
+// @Summary get attribute schema of the product type
+// @Produces json
+// @Param type path string true "Product type"
+// @Success 200 {object} models.AttributeSchema
+// @Failure 404 {object} string "schema for such type does not exist"
+// @Failure 500 {object} string
+// @Router /types/{type}/schema [get]
+func (srv *ProductServer) getAttributeSchema(ctx *gin.Context) {
+	schema, err := tenantDB(ctx).GetAttributeSchema(ctx.Request.Context(), ctx.Param("type"))
+	if err == nil {
+		ctx.JSON(http.StatusOK, schema)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// setAttributeSchema godoc
+// @Summary create or replace attribute schema of the product type
+// @Description Attributes of added and updated products of the type are validated with the schema.
+// @Description The schema is rejected, if attributes of existing products of the type do not match it.
+// @Description Data type of an attribute is one of "string", "integer", "number" and "boolean".
+// @Accept json
+// @Produces json
+// @Param type path string true "Product type"
+// @Param attributes body []models.AttributeDefinition true "attributes of products of the type"
+// @Success 200 {object} models.AttributeSchema
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /types/{type}/schema [put]
+func (srv *ProductServer) setAttributeSchema(ctx *gin.Context) {
+	schema := models.AttributeSchema{Type: ctx.Param("type")}
+	if err := ctx.ShouldBindJSON(&schema.Attributes); err != nil {
+		ctx.String(http.StatusBadRequest, "json format error: "+err.Error())
+		return
+	}
+	if err := tenantDB(ctx).SetAttributeSchema(ctx.Request.Context(), schema); err == nil {
+		ctx.JSON(http.StatusOK, schema)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// deleteAttributeSchema godoc
+// @Summary delete attribute schema of the product type
+// @Param type path string true "Product type"
+// @Success 204
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "schema for such type does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /types/{type}/schema [delete]
+func (srv *ProductServer) deleteAttributeSchema(ctx *gin.Context) {
+	if err := tenantDB(ctx).DeleteAttributeSchema(ctx.Request.Context(), ctx.Param("type")); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
@@ -0,0 +1,100 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAttributeSchema(t *testing.T) {
+	attributes := []models.AttributeDefinition{
+		{Name: "platform", DataType: models.StringAttribute, Required: true, Enum: []string{"pc", "ps5"}},
+		{Name: "ageRating", DataType: models.IntegerAttribute},
+	}
+	if code, body := sendRequest(http.MethodPut, typesUrl+"/Game/schema", attributes); code != http.StatusOK {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer sendRequest(http.MethodDelete, typesUrl+"/Game/schema", nil)
+
+	code, body := sendRequest(http.MethodGet, typesUrl+"/Game/schema", nil)
+	var schema models.AttributeSchema
+	if code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &schema); err != nil {
+		t.Error(err)
+	} else if len(schema.Attributes) != len(attributes) || schema.Attributes[0].Name != attributes[0].Name {
+		t.Errorf("received schema mismatch: %v", schema)
+	}
+
+	invalidSchema := []models.AttributeDefinition{{Name: "size", DataType: "WRONG"}}
+	if code, _ := sendRequest(http.MethodPut, typesUrl+"/Merch/schema", invalidSchema); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for invalid schema: %d", code)
+	}
+
+	games := []models.InputProduct{
+		{SKU: "GAME1", Name: "Game1", Type: "Game", Cost: 60, Attributes: map[string]string{"platform": "pc", "ageRating": "12"}},
+		{SKU: "GAME2", Name: "Game2", Type: "Game", Cost: 70, Attributes: map[string]string{"platform": "ps5", "ageRating": "18"}},
+	}
+	for _, game := range games {
+		if code, body := postProduct(game); code != http.StatusCreated {
+			t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+		defer deleteProduct(game.SKU)
+	}
+	for _, game := range []models.InputProduct{
+		{SKU: "GAME3", Name: "Game3", Type: "Game", Cost: 10},
+		{SKU: "GAME3", Name: "Game3", Type: "Game", Cost: 10, Attributes: map[string]string{"platform": "xbox"}},
+		{SKU: "GAME3", Name: "Game3", Type: "Game", Cost: 10, Attributes: map[string]string{"platform": "pc", "ageRating": "adult"}},
+		{SKU: "GAME3", Name: "Game3", Type: "Game", Cost: 10, Attributes: map[string]string{"platform": "pc", "color": "red"}},
+	} {
+		if code, _ := postProduct(game); code != http.StatusBadRequest {
+			t.Errorf("not 400 code for invalid attributes %v: %d", game.Attributes, code)
+		}
+	}
+
+	filtered, err := getProductsFromURL(baseUrl + "?type=Game&attributesMin[ageRating]=16")
+	if err != nil {
+		t.Error(err)
+	} else if len(filtered) != 1 || filtered[0].SKU != games[1].SKU {
+		t.Errorf("Wrong products filtered by attributes: %v", filtered)
+	}
+	if _, err := getProductsFromURL(baseUrl + "?attributesMax[ageRating]=WRONG"); err == nil {
+		t.Error("non-numeric attribute bound has been accepted")
+	}
+
+	// Schema is not changed, if existing products do not match it
+	narrowed := []models.AttributeDefinition{{Name: "platform", DataType: models.StringAttribute, Required: true, Enum: []string{"pc"}}}
+	if code, body := sendRequest(http.MethodPut, typesUrl+"/Game/schema", narrowed); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for schema not matching product attributes: %d %s", code, body)
+	}
+	if code, body := sendRequest(http.MethodGet, typesUrl+"/Game/schema", nil); code != http.StatusOK || json.Unmarshal([]byte(body), &schema) != nil || len(schema.Attributes) != len(attributes) {
+		t.Errorf("rejected schema is saved: %s", body)
+	}
+
+	// Variants get the type of the parent product only if their attributes match its schema
+	bundle := models.InputProduct{SKU: "BUNDLE", Name: "Bundle", Type: "Bundle", Cost: 30}
+	variant := models.InputProduct{SKU: "BUNDLE-RED", Cost: 30, ParentSKU: bundle.SKU, Attributes: map[string]string{"color": "red"}}
+	for _, prod := range []models.InputProduct{bundle, variant} {
+		if code, body := postProduct(prod); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+	}
+	defer deleteProduct(bundle.SKU)
+	bundle.Type, bundle.Attributes = "Game", map[string]string{"platform": "pc"}
+	if code, body := sendRequest(http.MethodPut, baseUrl+"/BUNDLE", bundle); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for type not matching attributes of variants: %d %s", code, body)
+	}
+	if prod, err, _ := getProductFromURL(baseUrl + "/BUNDLE-RED"); err != nil {
+		t.Error(err)
+	} else if prod.Type != "Bundle" {
+		t.Errorf("variant type is changed: %s", prod.Type)
+	}
+
+	if code, _ := sendRequest(http.MethodDelete, typesUrl+"/Game/schema", nil); code != http.StatusNoContent {
+		t.Errorf("Bad status code: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodGet, typesUrl+"/Game/schema", nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for deleted schema: %d", code)
+	}
+}
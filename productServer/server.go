@@ -2,44 +2,199 @@ package productServer
 
 import (
 	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/config"
 	_ "XsollaSchoolBE/docs"
+	"XsollaSchoolBE/jwtAuth"
+	"XsollaSchoolBE/logging"
+	"XsollaSchoolBE/models"
+	"XsollaSchoolBE/rateLimit"
+	"XsollaSchoolBE/storage"
+	"XsollaSchoolBE/tracing"
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
-	"log"
+	"net"
 	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type ProductServer struct {
 	*http.Server
 	db DB.DB
+	// blobs stores images of products
+	blobs     storage.BlobStorage
+	scheduler *scheduler
+	// tokens verifies bearer tokens, they are not accepted if it is nil
+	tokens *jwtAuth.Verifier
+	// limiter limits requests of clients, they are not limited if it is nil
+	limiter *rateLimit.Limiter
+	cors    config.CORSConfig
+	metrics *serverMetrics
+	logger  *logging.Logger
+	// requestTimeout is a deadline of database operations of requests, there is no deadline if it is 0
+	requestTimeout time.Duration
+	// cancelRequests cancels contexts of active requests
+	cancelRequests context.CancelFunc
+	// tracer traces requests and database operations, they are not traced if it is nil
+	tracer *tracing.Tracer
+	// shuttingDown is set to 1 by Shutdown, the server is not ready after that
+	shuttingDown int32
+	// shutdownDelay is a time between the server becoming not ready and closing its listener
+	shutdownDelay time.Duration
+	active        activeRequests
+	// certificates provides TLS config of connections, it is nil without TLS
+	certificates *certificateReloader
+	// errs receives the error of serving, which stops the server before Shutdown
+	errs chan error
 }
 
-func Run(addr string, DBfilename string) (*ProductServer, error) {
-	var err error
-	db, err := DB.InitSqlite3DB(DBfilename)
+// Run starts the server with the config, bearer tokens are accepted if keys of tokens are configured,
+// requests are limited if rate limits are configured
+func Run(cfg config.Config) (*ProductServer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	level, err := logging.ParseLevel(cfg.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+	logger := logging.New(os.Stderr, level)
+	var tokens *jwtAuth.Verifier
+	if cfg.JWT.Enabled() {
+		var err error
+		if tokens, err = jwtAuth.NewVerifier(cfg.JWT); err != nil {
+			return nil, err
+		}
+	}
+	var limiter *rateLimit.Limiter
+	if limits, _ := cfg.RateLimit.Limits(); limits.Enabled() {
+		limiter = rateLimit.NewLimiter(limits, rateLimit.NewMemoryStore())
+	}
+	tracer := newTracer(cfg.Tracing, logger)
+	rawDB, err := DB.Open(cfg.DB.Driver, cfg.DB.DSN)
 	if err != nil {
+		tracer.Shutdown(context.Background())
 		return nil, err
 	}
-	srv := ProductServer{&http.Server{Addr: addr}, db}
-	srv.initHandlers()
+	serverMetrics := newServerMetrics()
+	if pool, ok := rawDB.(DB.PoolStats); ok {
+		serverMetrics.registerPoolStats(pool)
+	}
+	db := DB.Instrument(rawDB, serverMetrics.observeDB)
+	if cfg.Cache.Enabled() {
+		// Operations served from the cache are not measured as database operations
+		db = DB.Cache(db, cfg.Cache.Size, cfg.Cache.TTL)
+		serverMetrics.registerCacheStats(db.(DB.CacheStatistics))
+	}
+	db = db.WithLogger(logger)
+	var certificates *certificateReloader
+	// release stops the tracer, watching certificates and closes the database if the server is not started
+	release := func(err error) (*ProductServer, error) {
+		certificates.shutdown()
+		tracer.Shutdown(context.Background())
+		db.Close()
+		return nil, err
+	}
+	blobs, err := storage.InitFileStorage(cfg.Server.MediaDir, "/media")
+	if err != nil {
+		return release(err)
+	}
+	httpServer := &http.Server{
+		Addr:         cfg.Server.Address,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	if cfg.TLS.Enabled() {
+		if certificates, err = newCertificateReloader(cfg.TLS, logger); err != nil {
+			return release(err)
+		}
+		httpServer.TLSConfig = certificates.serverConfig()
+	}
+	// Contexts of requests are cancelled when shutdown does not wait for them anymore
+	requestsCtx, cancelRequests := context.WithCancel(context.Background())
+	httpServer.BaseContext = func(net.Listener) context.Context { return requestsCtx }
+	srv := ProductServer{Server: httpServer, db: db, blobs: blobs, tokens: tokens, limiter: limiter, cors: cfg.CORS,
+		metrics: serverMetrics, logger: logger, tracer: tracer, requestTimeout: cfg.Server.RequestTimeout, cancelRequests: cancelRequests,
+		shutdownDelay: cfg.Server.ShutdownDelay, certificates: certificates, errs: make(chan error, 1)}
+	srv.initHandlers(cfg.Server.TrustedProxies)
+	// Listen before returning, so the server accepts connections as soon as Run returns
+	listener, err := net.Listen("tcp", cfg.Server.Address)
+	if err != nil {
+		return release(err)
+	}
+	// The actual address is known after listening if the port is 0
+	srv.Addr = listener.Addr().String()
+	srv.scheduler = startScheduler(db, logger)
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatal(err)
+		var err error
+		if certificates != nil {
+			// Certificates are taken from TLSConfig, so they are reloaded without restarting the server
+			err = srv.ServeTLS(listener, "", "")
+		} else {
+			err = srv.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			srv.errs <- err
 		}
 	}()
 	return &srv, nil
 }
 
-func (srv *ProductServer) initHandlers() {
-	router := gin.Default()
+// Errors returns a channel receiving the error, which stops serving requests before Shutdown is called,
+// like a failure of accepting connections. Shutdown must still be called to release resources.
+func (srv *ProductServer) Errors() <-chan error {
+	return srv.errs
+}
+
+// initHandlers creates routes, requests are logged on debug and info log levels.
+// Addresses of clients are taken from X-Forwarded-For and X-Real-IP headers only if they are sent by trusted proxies.
+func (srv *ProductServer) initHandlers(trustedProxies []string) {
+	router := gin.New()
+	// Proxies are validated with the config
+	router.SetTrustedProxies(trustedProxies)
+	router.Use(srv.trackRequest, srv.traceRequest, srv.logRequests, srv.measureRequest, srv.recoverPanic(), srv.applyDeadline)
+	// Probes and scrapes are not authenticated and limited, they are registered before the middlewares
+	router.GET("/healthz", srv.getLiveness)
+	router.GET("/readyz", srv.getReadiness)
+	router.GET("/metrics", gin.WrapH(srv.metrics.registry))
+	router.Use(srv.allowCORS, srv.authenticate, srv.limitRate, srv.selectTenant)
+	// Handlers reading and changing products check permissions of clients themselves, other handlers are protected
+	// by middlewares
+	read, edit := requirePermission(models.ReadProductsPermission), requirePermission(models.EditCatalogPermission)
+	create := requirePermission(models.CreateProductsPermission)
+	// Creating requests are made idempotent with Idempotency-Key header after checking permissions,
+	// so requests of other clients are not stored
+	idempotent := srv.idempotent
 	router.GET("/", func(ctx *gin.Context) { ctx.JSON(200, gin.H{"Status": "It is working"}) })
 	v1ProductsGroup := router.Group("api/v1/products")
 	{
-		v1ProductsGroup.POST("", srv.addProduct)
+		v1ProductsGroup.POST("", create, idempotent, srv.addProduct)
 		v1ProductsGroup.GET("/:SKU", srv.getProductWithURL)
 		v1ProductsGroup.GET("", srv.getProductWithParam)
+		v1ProductsGroup.GET("/search", srv.searchProducts)
+		v1ProductsGroup.GET("/suggestions", srv.suggestProducts)
+		v1ProductsGroup.GET("/scheduled", read, srv.getScheduledChanges)
+		v1ProductsGroup.GET("/:SKU/variants", srv.getVariants)
+		v1ProductsGroup.PUT("/:SKU/status", edit, srv.setProductStatus)
+		v1ProductsGroup.PUT("/:SKU/schedule", edit, srv.setProductSchedule)
+		v1ProductsGroup.GET("/:SKU/categories", srv.getProductCategories)
+		v1ProductsGroup.PUT("/:SKU/categories/:id", edit, srv.addProductToCategory)
+		v1ProductsGroup.DELETE("/:SKU/categories/:id", edit, srv.removeProductFromCategory)
+		v1ProductsGroup.GET("/:SKU/tags", srv.getProductTags)
+		v1ProductsGroup.PUT("/:SKU/tags/:tag", edit, srv.addProductTag)
+		v1ProductsGroup.DELETE("/:SKU/tags/:tag", edit, srv.removeProductTag)
+		v1ProductsGroup.GET("/:SKU/translations", srv.getProductTranslations)
+		v1ProductsGroup.PUT("/:SKU/translations/:locale", edit, srv.setProductTranslation)
+		v1ProductsGroup.DELETE("/:SKU/translations/:locale", edit, srv.deleteProductTranslation)
+		v1ProductsGroup.GET("/:SKU/images", srv.getProductImages)
+		v1ProductsGroup.POST("/:SKU/images", edit, idempotent, srv.addProductImage)
+		v1ProductsGroup.PUT("/:SKU/images", edit, srv.reorderProductImages)
+		v1ProductsGroup.DELETE("/:SKU/images/:id", edit, srv.deleteProductImage)
 		v1ProductsGroup.HEAD("/:SKU", srv.headProductsWithURL)
 		v1ProductsGroup.HEAD("", srv.headProductsWithParam)
 		v1ProductsGroup.DELETE("/:SKU", srv.deleteProductWithURL)
@@ -47,12 +202,67 @@ func (srv *ProductServer) initHandlers() {
 		v1ProductsGroup.PUT("/:SKU", srv.updateProductWithURL)
 		v1ProductsGroup.PUT("", srv.updateProductWithParam)
 	}
+	v1TypesGroup := router.Group("api/v1/types")
+	{
+		v1TypesGroup.GET("", srv.getAttributeSchemas)
+		v1TypesGroup.GET("/:type/schema", srv.getAttributeSchema)
+		v1TypesGroup.PUT("/:type/schema", edit, srv.setAttributeSchema)
+		v1TypesGroup.DELETE("/:type/schema", edit, srv.deleteAttributeSchema)
+	}
+	v1CategoriesGroup := router.Group("api/v1/categories")
+	{
+		v1CategoriesGroup.POST("", edit, idempotent, srv.addCategory)
+		v1CategoriesGroup.GET("", srv.getCategories)
+		v1CategoriesGroup.GET("/:id", srv.getCategory)
+		v1CategoriesGroup.PUT("/:id", edit, srv.updateCategory)
+		v1CategoriesGroup.DELETE("/:id", edit, srv.deleteCategory)
+	}
+	v1APIKeysGroup := router.Group("api/v1/apikeys", requirePermission(models.ManageAPIKeysPermission))
+	{
+		v1APIKeysGroup.POST("", srv.addAPIKey)
+		v1APIKeysGroup.GET("", srv.getAPIKeys)
+		v1APIKeysGroup.DELETE("/:id", srv.revokeAPIKey)
+	}
+	router.GET("api/v1/tags", srv.getTags)
+	router.GET("/media/*key", srv.getMedia)
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	srv.Handler = router
 }
 
+// Logger returns the logger of the server
+func (srv *ProductServer) Logger() *logging.Logger {
+	return srv.logger
+}
+
+// Shutdown makes the server not ready and waits for the shutdown delay, so load balancers stop sending requests to it.
+// Then it stops accepting connections and waits for active requests until ctx is done. Operations of requests
+// still active after that are cancelled, the database is closed after all handlers return.
+// Spans of requests are exported within spanFlushTimeout after that. Shutdown can be called again.
 func (srv *ProductServer) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&srv.shuttingDown, 1)
+	if srv.shutdownDelay > 0 {
+		delay := time.NewTimer(srv.shutdownDelay)
+		select {
+		case <-delay.C:
+		case <-ctx.Done():
+			delay.Stop()
+		}
+	}
 	servErr := srv.Server.Shutdown(ctx)
+	if servErr != nil {
+		srv.logger.Warn("active requests are cancelled on shutdown", "error", servErr)
+		// Closing connections stops handlers waiting for slow clients
+		srv.Server.Close()
+	}
+	srv.cancelRequests()
+	srv.active.wait()
+	srv.scheduler.shutdown()
+	srv.certificates.shutdown()
+	flushCtx, cancel := context.WithTimeout(context.Background(), spanFlushTimeout)
+	defer cancel()
+	if err := srv.tracer.Shutdown(flushCtx); err != nil {
+		srv.logger.Error("spans are not exported on shutdown", "error", err)
+	}
 	DBErr := srv.db.Close()
 	if servErr != nil {
 		return servErr
@@ -60,3 +270,66 @@ func (srv *ProductServer) Shutdown(ctx context.Context) error {
 		return DBErr
 	}
 }
+
+// applyDeadline limits the duration of database operations of the request by the request timeout
+func (srv *ProductServer) applyDeadline(ctx *gin.Context) {
+	if srv.requestTimeout <= 0 {
+		return
+	}
+	deadlineCtx, cancel := context.WithTimeout(ctx.Request.Context(), srv.requestTimeout)
+	defer cancel()
+	ctx.Request = ctx.Request.WithContext(deadlineCtx)
+	ctx.Next()
+}
+
+// trackRequest counts the request as active until its handlers return
+func (srv *ProductServer) trackRequest(ctx *gin.Context) {
+	srv.active.start()
+	defer srv.active.done()
+	ctx.Next()
+}
+
+// activeRequests counts requests, which handlers are running, so resources are released after they return.
+// Unlike sync.WaitGroup it allows starting requests while waiting.
+type activeRequests struct {
+	mutex sync.Mutex
+	n     int
+	// idle is closed when n drops to zero, it is nil if no one waits
+	idle chan struct{}
+}
+
+func (active *activeRequests) start() {
+	active.mutex.Lock()
+	defer active.mutex.Unlock()
+	active.n++
+}
+
+func (active *activeRequests) done() {
+	active.mutex.Lock()
+	defer active.mutex.Unlock()
+	if active.n--; active.n == 0 && active.idle != nil {
+		close(active.idle)
+		active.idle = nil
+	}
+}
+
+func (active *activeRequests) count() int {
+	active.mutex.Lock()
+	defer active.mutex.Unlock()
+	return active.n
+}
+
+// wait returns when there are no active requests
+func (active *activeRequests) wait() {
+	active.mutex.Lock()
+	if active.n == 0 {
+		active.mutex.Unlock()
+		return
+	}
+	if active.idle == nil {
+		active.idle = make(chan struct{})
+	}
+	idle := active.idle
+	active.mutex.Unlock()
+	<-idle
+}
@@ -0,0 +1,159 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/config"
+	"XsollaSchoolBE/models"
+	"XsollaSchoolBE/tracing"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	timeout := testServer.requestTimeout
+	testServer.requestTimeout = time.Nanosecond
+	defer func() { testServer.requestTimeout = timeout }()
+	// Database operations are not started after the deadline of the request
+	for _, url := range []string{baseUrl, baseUrl + "/TEST1231", categoriesUrl} {
+		if code, body := sendRequest(http.MethodGet, url, nil); code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: bad status code %d, expected %d\nResponse body: %s", url, code, http.StatusServiceUnavailable, body)
+		}
+	}
+	// Listing products over the deadline fails the same way
+	db := testServer.db
+	testServer.db, testServer.requestTimeout = stalledDB{db}, 50*time.Millisecond
+	for _, url := range []string{baseUrl, baseUrl + "?groupSize=2&groupNum=1"} {
+		if code, body := sendRequest(http.MethodGet, url, nil); code != http.StatusServiceUnavailable {
+			t.Errorf("%s: bad status code %d over the deadline of listing, expected %d\nResponse body: %s", url, code, http.StatusServiceUnavailable, body)
+		}
+	}
+	testServer.db, testServer.requestTimeout = db, timeout
+	if code, body := sendRequest(http.MethodGet, categoriesUrl, nil); code != http.StatusOK {
+		t.Fatalf("Bad status code %d after restoring the timeout\nResponse body: %s", code, body)
+	}
+}
+
+// startShutdownTestServer starts a server with its own database, which tests can shut down
+func startShutdownTestServer(t *testing.T, DSN string, shutdownDelay time.Duration) (*ProductServer, string) {
+	cfg := config.Default()
+	cfg.Server.Address = "localhost:0"
+	cfg.Server.MediaDir = "testMedia"
+	cfg.Server.ShutdownDelay = shutdownDelay
+	cfg.DB.DSN = DSN
+	cfg.Log.Level = config.ErrorLevel
+	srv, err := Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := srv.db.AddAPIKey(context.Background(), models.InputAPIKey{Name: "shutdown", Scopes: []string{models.WriteScope}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv, key.Key
+}
+
+// startSlowPost starts adding a product with the body, which is sent after the returned writer is closed
+func startSlowPost(t *testing.T, srv *ProductServer, key string) (*io.PipeWriter, <-chan int) {
+	body, writer := io.Pipe()
+	request, _ := http.NewRequest(http.MethodPost, "http://"+srv.Addr+"/api/v1/products", body)
+	request.Header.Set(apiKeyHeader, key)
+	request.Header.Set("Content-Type", "application/json")
+	codes := make(chan int, 1)
+	go func() {
+		resp, err := anonymousClient.Do(request)
+		if err != nil {
+			codes <- 0
+			return
+		}
+		resp.Body.Close()
+		codes <- resp.StatusCode
+	}()
+	writer.Write([]byte(`{"SKU": "SLOW1", "Name": "Slow", `))
+	for deadline := time.Now().Add(time.Second); srv.active.count() == 0; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Request is not started")
+		}
+	}
+	return writer, codes
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	const DSN = "testShutdownDB.db"
+	os.Remove(DSN)
+	defer os.Remove(DSN)
+	srv, key := startShutdownTestServer(t, DSN, 200*time.Millisecond)
+	writer, codes := startSlowPost(t, srv, key)
+	shutdownErr := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdownErr <- srv.Shutdown(ctx)
+	}()
+	// The listener is open during the shutdown delay, but the server is not ready
+	time.Sleep(50 * time.Millisecond)
+	resp, err := anonymousClient.Get("http://" + srv.Addr + "/readyz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Readiness during shutdown delay: bad status code %d", resp.StatusCode)
+	}
+	// Shutdown waits for the active request, which can still use the database
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("Shutdown returned before the active request finished: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	writer.Write([]byte(`"Type": "Game", "Cost": 10}`))
+	writer.Close()
+	if code := <-codes; code != http.StatusCreated {
+		t.Fatalf("Active request: bad status code %d", code)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Fatal(err)
+	}
+
+	// Requests still active after the drain timeout are cancelled, the database is closed after their handlers return
+	srv, key = startShutdownTestServer(t, DSN, 0)
+	exporter := &flushExporter{errs: make(chan error, 1)}
+	srv.tracer = tracing.NewTracer(exporter, 1)
+	writer, codes = startSlowPost(t, srv, key)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Shutdown after the drain timeout returned %v", err)
+	}
+	if count := srv.active.count(); count != 0 {
+		t.Fatalf("Shutdown returned with %d active requests", count)
+	}
+	// Spans are flushed with its own timeout after the drain
+	if err := <-exporter.errs; err != nil {
+		t.Fatalf("Spans are flushed with the context of the drain: %v", err)
+	}
+	// Shutdown can be repeated, e.g. on the second signal
+	srv.Shutdown(context.Background())
+	// The client gets an error after it stops sending the body
+	writer.Close()
+	if code := <-codes; code != 0 {
+		t.Fatalf("Cancelled request: bad status code %d", code)
+	}
+}
+
+// flushExporter drops spans and sends the error of the context of its shutdown to errs
+type flushExporter struct {
+	errs chan error
+}
+
+func (exporter *flushExporter) Export(tracing.SpanData) {}
+
+func (exporter *flushExporter) Shutdown(ctx context.Context) error {
+	select {
+	case exporter.errs <- ctx.Err():
+	default:
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,38 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// setProductStatus godoc
+// @Summary move the product with specific SKU to another lifecycle status
+// @Description New products are drafts, only published products are shown in the catalog by default.
+// @Description Allowed transitions: draft to published or archived, published to draft or archived, archived to draft.
+// @Accept json
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Param transition body models.StatusTransition true "new status of the product"
+// @Success 200 {object} models.Product
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 409 {object} string "transition from the current status is not allowed"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/status [put]
+func (srv *ProductServer) setProductStatus(ctx *gin.Context) {
+	var transition models.StatusTransition
+	if err := ctx.ShouldBindJSON(&transition); err != nil {
+		ctx.String(http.StatusBadRequest, "json format error: "+err.Error())
+		return
+	}
+	if product, err := tenantDB(ctx).SetProductStatus(ctx.Request.Context(), ctx.Param("SKU"), transition.Status); err == nil {
+		ctx.JSON(http.StatusOK, product)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
@@ -0,0 +1,148 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestStatuses(t *testing.T) {
+	product := models.InputProduct{SKU: "LIFECYCLE", Name: "Lifecycle", Type: "Lifecycle", Cost: 5}
+	jsonProduct, _ := json.Marshal(product)
+	resp, err := http.Post(baseUrl, "application/json", bytes.NewBuffer(jsonProduct))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var created models.Product
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if created.Status != models.DraftStatus {
+		t.Errorf("New product is not a draft: %s", created.Status)
+	}
+	defer deleteProduct(product.SKU)
+
+	for _, transition := range []struct {
+		status string
+		code   int
+		shown  bool
+	}{
+		{models.PublishedStatus, http.StatusOK, true},
+		{models.PublishedStatus, http.StatusConflict, true},
+		{models.ArchivedStatus, http.StatusOK, false},
+		{models.PublishedStatus, http.StatusConflict, false},
+		{models.DraftStatus, http.StatusOK, false},
+		{"deleted", http.StatusBadRequest, false},
+	} {
+		code, body := sendRequest(http.MethodPut, baseUrl+"/LIFECYCLE/status", models.StatusTransition{Status: transition.status})
+		if code != transition.code {
+			t.Errorf("Wrong status code of transition to %s: %d\nResponse body: %s", transition.status, code, body)
+		}
+		if products, err := getProductsFromURL(baseUrl + "?type=Lifecycle"); err != nil {
+			t.Error(err)
+		} else if (len(products) == 1) != transition.shown {
+			t.Errorf("Wrong catalog after transition to %s: %v", transition.status, products)
+		}
+	}
+
+	for url, expectedCount := range map[string]int{
+		baseUrl + "?type=Lifecycle&status=draft":                  1,
+		baseUrl + "?type=Lifecycle&status=published&status=draft": 1,
+		baseUrl + "?type=Lifecycle&status=all":                    1,
+		baseUrl + "?type=Lifecycle&status=archived":               0,
+	} {
+		if products, err := getProductsFromURL(url); err != nil {
+			t.Error(err)
+		} else if len(products) != expectedCount {
+			t.Errorf("Wrong number of products for %s: %d", url, len(products))
+		}
+	}
+	if code, _ := sendRequest(http.MethodGet, baseUrl+"?status=hidden", nil); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for unknown status: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodPut, baseUrl+"/UNKNOWN/status", models.StatusTransition{Status: models.PublishedStatus}); code != http.StatusNotFound {
+		t.Errorf("not 404 code for transition of not existing product: %d", code)
+	}
+}
+
+func TestUnpublishedProductsAreHidden(t *testing.T) {
+	parent := models.InputProduct{SKU: "HIDDEN", Name: "Hidden", Type: "Hidden", Cost: 5}
+	if code, body := postProduct(parent); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(parent.SKU)
+	// Products created without publishing stay drafts
+	var drafts []models.Product
+	for _, product := range []models.InputProduct{
+		{SKU: "HIDDEN-DRAFT", Name: "Hidden draft", Type: "Hidden", Cost: 5},
+		{SKU: "HIDDEN-VARIANT", Cost: 5, ParentSKU: parent.SKU, Attributes: map[string]string{"size": "M"}},
+	} {
+		jsonProduct, _ := json.Marshal(product)
+		resp, err := http.Post(baseUrl, "application/json", bytes.NewBuffer(jsonProduct))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var created models.Product
+		err = json.NewDecoder(resp.Body).Decode(&created)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer deleteProduct(product.SKU)
+		drafts = append(drafts, created)
+	}
+	draftUrl := baseUrl + "/" + drafts[0].SKU
+	for _, request := range []struct {
+		method string
+		url    string
+	}{
+		{http.MethodGet, draftUrl},
+		{http.MethodHead, draftUrl},
+		{http.MethodGet, baseUrl + "?sku=" + drafts[0].SKU},
+		{http.MethodGet, baseUrl + "?id=" + strconv.FormatInt(drafts[0].Id, 10)},
+		{http.MethodGet, draftUrl + "/images"},
+		{http.MethodGet, draftUrl + "/translations"},
+		{http.MethodGet, draftUrl + "/tags"},
+		{http.MethodGet, draftUrl + "/categories"},
+		{http.MethodGet, draftUrl + "/variants"},
+		{http.MethodGet, baseUrl + "/" + drafts[1].SKU},
+	} {
+		for client, expectedCode := range map[*http.Client]int{
+			anonymousClient:    http.StatusNotFound,
+			http.DefaultClient: http.StatusOK,
+		} {
+			req, _ := http.NewRequest(request.method, request.url, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != expectedCode {
+				t.Errorf("Wrong status code of %s %s: %d instead of %d", request.method, request.url, resp.StatusCode, expectedCode)
+			}
+		}
+	}
+
+	// Anonymous clients get only published variants of a published product
+	resp, err := anonymousClient.Get(baseUrl + "/" + parent.SKU + "/variants")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var variants []models.Product
+	err = json.NewDecoder(resp.Body).Decode(&variants)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	} else if len(variants) != 0 {
+		t.Errorf("Draft variants are returned: %v", variants)
+	}
+	if variants, err := getProductsFromURL(baseUrl + "/" + parent.SKU + "/variants"); err != nil {
+		t.Fatal(err)
+	} else if len(variants) != 1 {
+		t.Errorf("Wrong variants for products:read: %v", variants)
+	}
+}
@@ -0,0 +1,84 @@
+package productServer
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// getTags godoc
+// @Summary get all tags with numbers of tagged products
+// @Produces json
+// @Success 200 {array} models.TagCount
+// @Failure 500 {object} string
+// @Router /tags [get]
+func (srv *ProductServer) getTags(ctx *gin.Context) {
+	tags, err := tenantDB(ctx).GetAllTags(ctx.Request.Context())
+	if err == nil {
+		ctx.JSON(http.StatusOK, tags)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// getProductTags godoc
+// @Summary get tags of the product with specific SKU
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Success 200 {array} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Router /products/{SKU}/tags [get]
+func (srv *ProductServer) getProductTags(ctx *gin.Context) {
+	var tags []string
+	err := checkSKUVisible(ctx, ctx.Param("SKU"))
+	if err == nil {
+		tags, err = tenantDB(ctx).GetProductTags(ctx.Request.Context(), ctx.Param("SKU"))
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, tags)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// addProductTag godoc
+// @Summary tag the product with specific SKU
+// @Description Tags are case insensitive, they are stored in lower case
+// @Param SKU path string true "SKU of the product"
+// @Param tag path string true "Adding tag"
+// @Success 204
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/tags/{tag} [put]
+func (srv *ProductServer) addProductTag(ctx *gin.Context) {
+	if err := tenantDB(ctx).AddProductTag(ctx.Request.Context(), ctx.Param("SKU"), ctx.Param("tag")); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// removeProductTag godoc
+// @Summary remove tag from the product with specific SKU
+// @Param SKU path string true "SKU of the product"
+// @Param tag path string true "Removing tag"
+// @Success 204
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist or it is not tagged with the tag"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/tags/{tag} [delete]
+func (srv *ProductServer) removeProductTag(ctx *gin.Context) {
+	if err := tenantDB(ctx).RemoveProductTag(ctx.Request.Context(), ctx.Param("SKU"), ctx.Param("tag")); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
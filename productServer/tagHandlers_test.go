@@ -0,0 +1,76 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	productTags := map[string][]string{
+		"TAGGED1": {"indie", "Holiday "},
+		"TAGGED2": {"INDIE"},
+		"TAGGED3": {"early-access"},
+	}
+	for SKU, tags := range productTags {
+		if code, body := postProduct(models.InputProduct{SKU: SKU, Name: SKU, Type: "Game", Cost: 1}); code != http.StatusCreated {
+			t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+		defer deleteProduct(SKU)
+		for _, tag := range tags {
+			if code, body := sendRequest(http.MethodPut, baseUrl+"/"+SKU+"/tags/"+tag, nil); code != http.StatusNoContent {
+				t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+			}
+		}
+	}
+
+	code, body := sendRequest(http.MethodGet, baseUrl+"/TAGGED1/tags", nil)
+	var tags []string
+	if code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &tags); err != nil {
+		t.Error(err)
+	} else if len(tags) != 2 || tags[0] != "holiday" || tags[1] != "indie" {
+		t.Errorf("Wrong product tags: %v", tags)
+	}
+
+	code, body = sendRequest(http.MethodGet, serverUrl+"/api/v1/tags", nil)
+	var tagCounts []models.TagCount
+	if code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &tagCounts); err != nil {
+		t.Error(err)
+	} else if len(tagCounts) != 3 || tagCounts[0] != (models.TagCount{Tag: "indie", Count: 2}) {
+		t.Errorf("Wrong tag counts: %v", tagCounts)
+	}
+
+	for url, expectedCount := range map[string]int{
+		baseUrl + "?tag=indie":                               2,
+		baseUrl + "?tag=indie&tag=holiday":                   1,
+		baseUrl + "?tag=indie&tag=holiday&tagMode=or":        2,
+		baseUrl + "?tag=holiday&tag=early-access&tagMode=or": 2,
+		baseUrl + "?tag=holiday&tag=early-access":            0,
+	} {
+		if filtered, err := getProductsFromURL(url); err != nil {
+			t.Error(err)
+		} else if len(filtered) != expectedCount {
+			t.Errorf("Wrong number of products for %s: %d", url, len(filtered))
+		}
+	}
+	if _, err := getProductsFromURL(baseUrl + "?tag=indie&tagMode=xor"); err == nil {
+		t.Error("wrong tagMode has been accepted")
+	}
+
+	if code, _ := sendRequest(http.MethodDelete, baseUrl+"/TAGGED2/tags/indie", nil); code != http.StatusNoContent {
+		t.Errorf("Bad status code: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodDelete, baseUrl+"/TAGGED2/tags/indie", nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for removing absent tag: %d", code)
+	}
+	if filtered, err := getProductsFromURL(baseUrl + "?tag=indie"); err != nil {
+		t.Error(err)
+	} else if len(filtered) != 1 {
+		t.Errorf("removed tag is still found: %v", filtered)
+	}
+}
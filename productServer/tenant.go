@@ -0,0 +1,43 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/models"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// tenantHeader is a request header containing id of the tenant, which catalog is requested
+const tenantHeader = "X-Tenant"
+
+// tenantDBContextKey is a key of the database scoped to the tenant of the request in gin context
+const tenantDBContextKey = "tenantDB"
+
+// selectTenant scopes the database to the tenant of the request. Authenticated clients can access only catalogs of
+// their tenants, which are used if the header is not sent; anonymous clients access the default tenant by default.
+func (srv *ProductServer) selectTenant(ctx *gin.Context) {
+	ctx.Writer.Header().Add("Vary", tenantHeader)
+	client := getPrincipal(ctx)
+	tenant := ctx.GetHeader(tenantHeader)
+	if tenant == "" && client != nil {
+		tenant = client.Tenant
+	} else if tenant == "" {
+		tenant = models.DefaultTenant
+	}
+	if !models.ValidTenant(tenant) {
+		ctx.String(http.StatusBadRequest, "tenant must consist of up to 63 lowercase latin letters, digits, hyphens and underscores")
+		ctx.Abort()
+		return
+	}
+	if client != nil && client.Tenant != tenant {
+		ctx.String(http.StatusForbidden, client.Subject+" belongs to tenant "+client.Tenant+" and can not access tenant "+tenant)
+		ctx.Abort()
+		return
+	}
+	ctx.Set(tenantDBContextKey, srv.db.WithTenant(tenant).WithLogger(srv.requestLogger(ctx)))
+}
+
+// tenantDB returns the database scoped to the tenant of the request
+func tenantDB(ctx *gin.Context) DB.DB {
+	return ctx.MustGet(tenantDBContextKey).(DB.DB)
+}
@@ -0,0 +1,136 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	names := map[string]string{"alpha": "Alpha game", "beta": "Beta game"}
+	keys := make(map[string]string)
+	for tenant := range names {
+		key, err := testServer.db.WithTenant(tenant).AddAPIKey(context.Background(), models.InputAPIKey{Name: tenant, Scopes: []string{models.AdminScope}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[tenant] = key.Key
+	}
+	send := func(tenant string, key string, method string, url string, body interface{}) (int, string) {
+		return sendRequestWithHeaders(map[string]string{tenantHeader: tenant, apiKeyHeader: key}, method, url, body)
+	}
+
+	// Tenants have products with the same SKU, categories with the same name and schemas of the same type
+	for tenant, name := range names {
+		product := models.InputProduct{SKU: "SHARED", Name: name, Type: "Game", Cost: 10}
+		requests := []struct {
+			method string
+			url    string
+			body   interface{}
+		}{
+			{http.MethodPost, baseUrl, product},
+			{http.MethodPut, baseUrl + "/SHARED/status", models.StatusTransition{Status: models.PublishedStatus}},
+			{http.MethodPut, baseUrl + "/SHARED/tags/exclusive", nil},
+			{http.MethodPost, serverUrl + "/api/v1/categories", models.InputCategory{Name: "Featured"}},
+			{http.MethodPut, serverUrl + "/api/v1/types/Game/schema", []models.AttributeDefinition{{Name: "platform", DataType: "string"}}},
+		}
+		for _, r := range requests {
+			if code, body := send("", keys[tenant], r.method, r.url, r.body); code >= http.StatusBadRequest {
+				t.Fatalf("%s %s of %s: bad status code %d\nResponse body: %s", r.method, r.url, tenant, code, body)
+			}
+		}
+		defer send(tenant, keys[tenant], http.MethodDelete, baseUrl+"/SHARED", nil)
+	}
+	var betaProduct models.Product
+	if _, body := send("beta", "", http.MethodGet, baseUrl+"?sku=SHARED", nil); !strings.Contains(body, "Beta game") {
+		t.Fatalf("Beta product is not found: %s", body)
+	} else {
+		var products []models.Product
+		json.Unmarshal([]byte(body), &products)
+		betaProduct = products[0]
+	}
+	betaId := strconv.FormatInt(betaProduct.Id, 10)
+
+	// Every read of alpha tenant returns only its data
+	reads := []string{
+		baseUrl + "/SHARED",
+		baseUrl + "?status=all",
+		baseUrl + "?category=Featured",
+		baseUrl + "/search?q=game",
+		baseUrl + "/suggestions?prefix=SHA",
+		serverUrl + "/api/v1/tags",
+		serverUrl + "/api/v1/categories",
+		serverUrl + "/api/v1/apikeys",
+	}
+	for _, url := range reads {
+		code, body := send("alpha", keys["alpha"], http.MethodGet, url, nil)
+		if code != http.StatusOK || strings.Contains(body, "Beta") || strings.Contains(body, "beta") {
+			t.Errorf("GET %s of alpha tenant: %d\nResponse body: %s", url, code, body)
+		}
+	}
+	if code, body := send("alpha", "", http.MethodGet, serverUrl+"/api/v1/tags", nil); !strings.Contains(body, `{"Tag":"exclusive","Count":1}`) {
+		t.Errorf("Wrong tags of alpha tenant: %d %s", code, body)
+	}
+	if code, _ := send("", "", http.MethodGet, baseUrl+"/SHARED", nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for product of other tenant in default tenant: %d", code)
+	}
+
+	// Products of beta tenant are not found by id in alpha tenant
+	changed := models.InputProduct{SKU: "SHARED", Name: "Stolen", Type: "Game", Cost: 1}
+	for _, r := range []struct {
+		method string
+		url    string
+		body   interface{}
+	}{
+		{http.MethodGet, baseUrl + "?id=" + betaId, nil},
+		{http.MethodPut, baseUrl + "?id=" + betaId, changed},
+		{http.MethodDelete, baseUrl + "?id=" + betaId, nil},
+		{http.MethodGet, serverUrl + "/api/v1/apikeys", nil},
+	} {
+		code, body := send("alpha", keys["alpha"], r.method, r.url, r.body)
+		if r.method == http.MethodGet && strings.Contains(body, "beta") {
+			t.Errorf("%s %s of alpha tenant returned data of beta: %s", r.method, r.url, body)
+		} else if r.method != http.MethodGet && code != http.StatusNotFound {
+			t.Errorf("not 404 code for %s %s of beta product in alpha tenant: %d", r.method, r.url, code)
+		}
+	}
+
+	// Clients can not access catalogs of other tenants
+	for _, r := range []struct {
+		method string
+		url    string
+		body   interface{}
+	}{
+		{http.MethodGet, baseUrl + "?status=all", nil},
+		{http.MethodGet, baseUrl + "/SHARED", nil},
+		{http.MethodPut, baseUrl + "/SHARED", changed},
+		{http.MethodPut, baseUrl + "/SHARED/status", models.StatusTransition{Status: models.ArchivedStatus}},
+		{http.MethodDelete, baseUrl + "/SHARED", nil},
+		{http.MethodDelete, baseUrl + "?id=" + betaId, nil},
+		{http.MethodGet, serverUrl + "/api/v1/apikeys", nil},
+	} {
+		if code, body := send("beta", keys["alpha"], r.method, r.url, r.body); code != http.StatusForbidden {
+			t.Errorf("not 403 code for %s %s of beta tenant with alpha key: %d\nResponse body: %s", r.method, r.url, code, body)
+		}
+	}
+	if code, _ := send("beta", "", http.MethodPut, baseUrl+"/SHARED", changed); code != http.StatusUnauthorized {
+		t.Errorf("not 401 code for anonymous update in beta tenant: %d", code)
+	}
+	if code, _ := send("Beta!", "", http.MethodGet, baseUrl, nil); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for invalid tenant: %d", code)
+	}
+
+	// Beta product is intact
+	prod, err, _ := getProductFromURL(baseUrl + "/SHARED")
+	if err == nil {
+		t.Errorf("Product of beta tenant is found in default tenant: %v", prod)
+	}
+	code, body := send("beta", keys["beta"], http.MethodGet, baseUrl+"/SHARED", nil)
+	if code != http.StatusOK || !strings.Contains(body, `"Name":"Beta game"`) || !strings.Contains(body, `"Status":"published"`) {
+		t.Errorf("Beta product is changed: %d %s", code, body)
+	}
+}
@@ -0,0 +1,74 @@
+package productServer
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// thumbnailSize is the maximal width and height of image thumbnails
+const thumbnailSize = 200
+
+// imageExtensions contains extensions of image files of supported formats
+var imageExtensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+}
+
+// encodeThumbnail scales the image down to fit into thumbnailSize square keeping its aspect ratio,
+// thumbnails of JPEG images are encoded as JPEG, thumbnails of other images are encoded as PNG
+func encodeThumbnail(img image.Image, format string) ([]byte, string, error) {
+	thumbnail := scaleDown(img, thumbnailSize)
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err := jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), imageExtensions["jpeg"], err
+	}
+	err := png.Encode(&buf, thumbnail)
+	return buf.Bytes(), imageExtensions["png"], err
+}
+
+// scaleDown averages colors of source pixels covered by every pixel of the scaled image
+func scaleDown(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+	scaledWidth, scaledHeight := size, size
+	if width > height {
+		scaledHeight = height * size / width
+	} else {
+		scaledWidth = width * size / height
+	}
+	if scaledWidth == 0 {
+		scaledWidth = 1
+	}
+	if scaledHeight == 0 {
+		scaledHeight = 1
+	}
+
+	scaled := image.NewRGBA64(image.Rect(0, 0, scaledWidth, scaledHeight))
+	for y := 0; y < scaledHeight; y++ {
+		top, bottom := bounds.Min.Y+y*height/scaledHeight, bounds.Min.Y+(y+1)*height/scaledHeight
+		for x := 0; x < scaledWidth; x++ {
+			left, right := bounds.Min.X+x*width/scaledWidth, bounds.Min.X+(x+1)*width/scaledWidth
+			var r, g, b, a, count uint64
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					count++
+				}
+			}
+			scaled.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / count), G: uint16(g / count), B: uint16(b / count), A: uint16(a / count),
+			})
+		}
+	}
+	return scaled
+}
@@ -0,0 +1,175 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/config"
+	"XsollaSchoolBE/logging"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// certificateCheckInterval is a period of checking modification times of certificate files
+const certificateCheckInterval = 10 * time.Second
+
+var tlsVersions = map[string]uint16{config.TLS12: tls.VersionTLS12, config.TLS13: tls.VersionTLS13}
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	config.NoClientAuth:       tls.NoClientCert,
+	config.OptionalClientAuth: tls.VerifyClientCertIfGiven,
+	config.RequiredClientAuth: tls.RequireAndVerifyClientCert,
+}
+
+// certificateReloader serves TLS config built from files, which are loaded again when they are modified or on
+// Reload. New connections use the new certificates, established connections are not affected.
+type certificateReloader struct {
+	cfg    config.TLSConfig
+	logger *logging.Logger
+	mutex  sync.RWMutex
+	tls    *tls.Config
+	// modTimes are modification times of loaded files
+	modTimes map[string]time.Time
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// newCertificateReloader loads the files and starts checking their modification times
+func newCertificateReloader(cfg config.TLSConfig, logger *logging.Logger) (*certificateReloader, error) {
+	reloader := &certificateReloader{cfg: cfg, logger: logger, stop: make(chan struct{}), done: make(chan struct{})}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	go reloader.watch()
+	return reloader, nil
+}
+
+// serverConfig returns the config of the listener, which takes the current config for every handshake.
+// HTTP/2 is negotiated with ALPN.
+func (reloader *certificateReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tlsVersions[reloader.cfg.MinVersion],
+		NextProtos: []string{"h2", "http/1.1"},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			reloader.mutex.RLock()
+			defer reloader.mutex.RUnlock()
+			return &reloader.tls.Certificates[0], nil
+		},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			reloader.mutex.RLock()
+			defer reloader.mutex.RUnlock()
+			return reloader.tls, nil
+		},
+	}
+}
+
+// Reload loads the certificate, the key and client CAs, the current config is kept if they are invalid
+func (reloader *certificateReloader) Reload() error {
+	files := reloader.files()
+	modTimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTimes[file] = info.ModTime()
+	}
+	certificate, err := tls.LoadX509KeyPair(reloader.cfg.CertFile, reloader.cfg.KeyFile)
+	if err != nil {
+		return err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tlsVersions[reloader.cfg.MinVersion],
+		NextProtos:   []string{"h2", "http/1.1"},
+		ClientAuth:   clientAuthTypes[reloader.cfg.ClientAuth],
+	}
+	if reloader.cfg.ClientCAFile != "" {
+		if tlsConfig.ClientCAs, err = loadCertPool(reloader.cfg.ClientCAFile); err != nil {
+			return err
+		}
+	}
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	reloader.tls, reloader.modTimes = tlsConfig, modTimes
+	return nil
+}
+
+// files returns names of files of the config
+func (reloader *certificateReloader) files() []string {
+	files := []string{reloader.cfg.CertFile, reloader.cfg.KeyFile}
+	if reloader.cfg.ClientCAFile != "" {
+		files = append(files, reloader.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// modified reports if any file is changed after it is loaded
+func (reloader *certificateReloader) modified() bool {
+	reloader.mutex.RLock()
+	defer reloader.mutex.RUnlock()
+	for file, modTime := range reloader.modTimes {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(modTime) {
+			return true
+		}
+	}
+	return false
+}
+
+func (reloader *certificateReloader) watch() {
+	defer close(reloader.done)
+	ticker := time.NewTicker(certificateCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !reloader.modified() {
+				continue
+			}
+			// Files can be replaced one by one, a mismatching pair is loaded on the next check
+			if err := reloader.Reload(); err != nil {
+				reloader.logger.Warn("certificates are not reloaded", "error", err)
+			} else {
+				reloader.logger.Info("certificates are reloaded")
+			}
+		case <-reloader.stop:
+			return
+		}
+	}
+}
+
+// shutdown stops checking files, it does nothing with nil reloader
+func (reloader *certificateReloader) shutdown() {
+	if reloader == nil {
+		return
+	}
+	reloader.once.Do(func() { close(reloader.stop) })
+	<-reloader.done
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s: no PEM certificates", file)
+	}
+	return pool, nil
+}
+
+// TLSDisabledError is returned by ReloadCertificates of a server without TLS
+var TLSDisabledError = errors.New("TLS is not enabled")
+
+// ReloadCertificates loads certificate files again, new connections use the new certificates
+func (srv *ProductServer) ReloadCertificates() error {
+	if srv.certificates == nil {
+		return TLSDisabledError
+	}
+	return srv.certificates.Reload()
+}
@@ -0,0 +1,213 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/config"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testCertificate is a certificate with its key, which are written to files name.crt and name.key
+type testCertificate struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// issueTestCertificate issues a certificate with the serial number signed by the issuer, it is a CA if issuer is nil
+func issueTestCertificate(t *testing.T, dir string, name string, serial int64, issuer *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parent, parentKey := template, key
+	if issuer == nil {
+		template.IsCA, template.BasicConstraintsValid = true, true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		parent, parentKey = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	certificate := &testCertificate{cert: cert, key: key,
+		certFile: filepath.Join(dir, name+".crt"), keyFile: filepath.Join(dir, name+".key")}
+	certificate.write(t, certificate.certFile, certificate.keyFile)
+	return certificate
+}
+
+// write writes the certificate and its key to the files
+func (certificate *testCertificate) write(t *testing.T, certFile string, keyFile string) {
+	keyDer, err := x509.MarshalECPrivateKey(certificate.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.cert.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (certificate *testCertificate) tlsCertificate() tls.Certificate {
+	return tls.Certificate{Certificate: [][]byte{certificate.cert.Raw}, PrivateKey: certificate.key}
+}
+
+// startTLSTestServer starts a server with its own database and TLS config
+func startTLSTestServer(t *testing.T, DSN string, tlsConfig config.TLSConfig) *ProductServer {
+	os.Remove(DSN)
+	cfg := config.Default()
+	cfg.Server.Address = "localhost:0"
+	cfg.Server.MediaDir = "testMedia"
+	cfg.DB.DSN = DSN
+	cfg.Log.Level = config.ErrorLevel
+	cfg.TLS = tlsConfig
+	srv, err := Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return srv
+}
+
+func stopTLSTestServer(t *testing.T, srv *ProductServer, DSN string) {
+	if err := srv.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+	// The second shutdown does not stop the certificate reloader again
+	srv.Shutdown(context.Background())
+	os.Remove(DSN)
+}
+
+// newTLSTestClient returns a client trusting the CA, which sends the certificates if they are given
+func newTLSTestClient(ca *testCertificate, certificates ...tls.Certificate) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots, Certificates: certificates},
+		ForceAttemptHTTP2: true,
+	}}
+}
+
+// getServerSerial sends a request and returns the serial number of the certificate of the server
+func getServerSerial(t *testing.T, client *http.Client, url string) int64 {
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Bad status code %d", resp.StatusCode)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("HTTP/2 is not negotiated: %s", resp.Proto)
+	}
+	return resp.TLS.PeerCertificates[0].SerialNumber.Int64()
+}
+
+func TestTLSCertificateReload(t *testing.T) {
+	const DSN = "testTLSDB.db"
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := issueTestCertificate(t, dir, "ca", 1, nil)
+	server := issueTestCertificate(t, dir, "server", 2, ca)
+	srv := startTLSTestServer(t, DSN, config.TLSConfig{CertFile: server.certFile, KeyFile: server.keyFile,
+		MinVersion: config.TLS12, ClientAuth: config.NoClientAuth})
+	defer stopTLSTestServer(t, srv, DSN)
+	url := "https://" + srv.Addr + "/healthz"
+
+	oldClient := newTLSTestClient(ca)
+	if serial := getServerSerial(t, oldClient, url); serial != 2 {
+		t.Fatalf("Bad certificate %d", serial)
+	}
+
+	// New connections get the new certificate, the established connection is not dropped
+	issueTestCertificate(t, dir, "renewed", 3, ca).write(t, server.certFile, server.keyFile)
+	if err := srv.ReloadCertificates(); err != nil {
+		t.Fatal(err)
+	}
+	if serial := getServerSerial(t, newTLSTestClient(ca), url); serial != 3 {
+		t.Fatalf("Certificate is not reloaded: %d", serial)
+	}
+	if serial := getServerSerial(t, oldClient, url); serial != 2 {
+		t.Fatalf("Established connection is not kept: %d", serial)
+	}
+
+	// Invalid files are not loaded, the server keeps the current certificate
+	if err := ioutil.WriteFile(server.certFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ReloadCertificates(); err == nil {
+		t.Fatal("Invalid certificate is loaded")
+	}
+	if serial := getServerSerial(t, newTLSTestClient(ca), url); serial != 3 {
+		t.Fatalf("Current certificate is not kept: %d", serial)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	const DSN = "testMutualTLSDB.db"
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := issueTestCertificate(t, dir, "ca", 1, nil)
+	server := issueTestCertificate(t, dir, "server", 2, ca)
+	client := issueTestCertificate(t, dir, "client", 3, ca)
+	otherCA := issueTestCertificate(t, dir, "otherCA", 4, nil)
+	stranger := issueTestCertificate(t, dir, "stranger", 5, otherCA)
+	srv := startTLSTestServer(t, DSN, config.TLSConfig{CertFile: server.certFile, KeyFile: server.keyFile,
+		MinVersion: config.TLS13, ClientCAFile: ca.certFile, ClientAuth: config.RequiredClientAuth})
+	defer stopTLSTestServer(t, srv, DSN)
+	url := "https://" + srv.Addr + "/healthz"
+
+	getServerSerial(t, newTLSTestClient(ca, client.tlsCertificate()), url)
+	clients := map[string]*http.Client{
+		"without certificate":          newTLSTestClient(ca),
+		"with certificate of other CA": newTLSTestClient(ca, stranger.tlsCertificate()),
+	}
+	for name, client := range clients {
+		if resp, err := client.Get(url); err == nil {
+			resp.Body.Close()
+			t.Fatalf("Client %s is accepted", name)
+		}
+	}
+
+	// TLS 1.2 is rejected by the minimum version
+	old := newTLSTestClient(ca, client.tlsCertificate())
+	old.Transport.(*http.Transport).TLSClientConfig.MaxVersion = tls.VersionTLS12
+	if resp, err := old.Get(url); err == nil {
+		resp.Body.Close()
+		t.Fatal("TLS 1.2 is accepted")
+	}
+}
@@ -0,0 +1,58 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/config"
+	"XsollaSchoolBE/logging"
+	"XsollaSchoolBE/tracing"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"os"
+	"time"
+)
+
+// spanFlushTimeout limits exporting spans on shutdown, it does not depend on the drain of requests,
+// which may take all the time given to Shutdown
+const spanFlushTimeout = 5 * time.Second
+
+// newTracer returns the tracer exporting spans to the configured exporter or nil if tracing is disabled
+func newTracer(cfg config.TracingConfig, logger *logging.Logger) *tracing.Tracer {
+	switch cfg.Exporter {
+	case config.StdoutExporter:
+		return tracing.NewTracer(tracing.NewWriterExporter(os.Stdout), cfg.SampleRatio)
+	case config.OTLPExporter:
+		return tracing.NewTracer(tracing.NewOTLPExporter(cfg.Endpoint, cfg.ServiceName, logger), cfg.SampleRatio)
+	default:
+		return nil
+	}
+}
+
+// traceRequest starts a server span of the request continuing the trace of W3C traceparent header.
+// Handlers and the database get the span through the request context.
+func (srv *ProductServer) traceRequest(ctx *gin.Context) {
+	if srv.tracer == nil {
+		return
+	}
+	route := ctx.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	parent := tracing.Extract(ctx.Request.Context(), ctx.Request.Header)
+	spanCtx, span := srv.tracer.Start(parent, ctx.Request.Method+" "+route, tracing.ServerKind)
+	defer span.End()
+	span.SetAttributes(
+		"http.request.method", ctx.Request.Method,
+		"http.route", route,
+		"url.path", ctx.Request.URL.Path,
+		"client.address", ctx.ClientIP(),
+	)
+	ctx.Request = ctx.Request.WithContext(spanCtx)
+	ctx.Next()
+	status := ctx.Writer.Status()
+	span.SetAttributes("http.response.status_code", status)
+	if client := getPrincipal(ctx); client != nil {
+		span.SetAttributes("enduser.id", client.Subject, "tenant", client.Tenant)
+	}
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(tracing.ErrorStatus, http.StatusText(status))
+	}
+}
@@ -0,0 +1,216 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/tracing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTracing(t *testing.T) {
+	exporter := tracing.NewInMemoryExporter()
+	testServer.tracer = tracing.NewTracer(exporter, 1)
+	defer func() { testServer.tracer = nil }()
+
+	sendTraced := func(traceparent string) {
+		request, _ := http.NewRequest(http.MethodGet, baseUrl+"/TEST1231", nil)
+		if traceparent != "" {
+			request.Header.Set("traceparent", traceparent)
+		}
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// The server span ends after the response is sent
+	waitServerSpan := func() *tracing.SpanData {
+		for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			for _, span := range exporter.Spans() {
+				if span.Kind == tracing.ServerKind {
+					return &span
+				}
+			}
+		}
+		return nil
+	}
+
+	// The trace of the caller is continued
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+	sendTraced("00-" + traceID + "-" + parentID + "-01")
+	server := waitServerSpan()
+	if server == nil {
+		t.Fatal("Server span is not exported")
+	}
+	if server.SpanContext.TraceID.String() != traceID || server.Parent.String() != parentID {
+		t.Fatalf("Server span does not continue the trace: %s, parent %s", server.SpanContext.TraceID, server.Parent)
+	}
+	if server.Name != "GET /api/v1/products/:SKU" || server.Attribute("http.route") != "/api/v1/products/:SKU" ||
+		server.Attribute("http.response.status_code") == nil || server.Attribute("request_id") == nil {
+		t.Fatalf("Bad server span: %+v", *server)
+	}
+	operations := make(map[string]bool)
+	for _, span := range exporter.Spans() {
+		if span.Kind != tracing.ClientKind {
+			continue
+		}
+		if span.SpanContext.TraceID != server.SpanContext.TraceID || span.Parent != server.SpanContext.SpanID {
+			t.Fatalf("Database span %s is not a child of the server span", span.Name)
+		}
+		operations[span.Name] = true
+	}
+	if !operations["GetAPIKey"] || !operations["GetProductBySKU"] {
+		t.Fatalf("Database operations are not traced: %v", operations)
+	}
+
+	// A new trace is started without traceparent or with an invalid one
+	for _, traceparent := range []string{"", "00-" + traceID + "-0000000000000000-01"} {
+		exporter.Reset()
+		sendTraced(traceparent)
+		if server = waitServerSpan(); server == nil || server.Parent.IsValid() || server.SpanContext.TraceID.String() == traceID {
+			t.Fatalf("New trace is not started for traceparent %q", traceparent)
+		}
+	}
+
+	// Requests of not sampled traces are not exported
+	exporter.Reset()
+	sendTraced("00-" + traceID + "-" + parentID + "-00")
+	if server = waitServerSpan(); server != nil || len(exporter.Spans()) != 0 {
+		t.Fatal("Spans of not sampled trace are exported")
+	}
+}
+
+// Messages of ExportTraceServiceRequest in OTLP JSON encoding, which is checked for unknown fields:
+// names are in lowerCamelCase, ids are hex strings and 64-bit integers are decimal strings
+type otlpTraceRequest struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes             []otlpKeyValue `json:"attributes"`
+			DroppedAttributesCount uint32         `json:"droppedAttributesCount"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Scope struct {
+				Name       string         `json:"name"`
+				Version    string         `json:"version"`
+				Attributes []otlpKeyValue `json:"attributes"`
+			} `json:"scope"`
+			Spans     []otlpTraceSpan `json:"spans"`
+			SchemaURL string          `json:"schemaUrl"`
+		} `json:"scopeSpans"`
+		SchemaURL string `json:"schemaUrl"`
+	} `json:"resourceSpans"`
+}
+
+type otlpTraceSpan struct {
+	TraceID                string         `json:"traceId"`
+	SpanID                 string         `json:"spanId"`
+	TraceState             string         `json:"traceState"`
+	ParentSpanID           string         `json:"parentSpanId"`
+	Flags                  uint32         `json:"flags"`
+	Name                   string         `json:"name"`
+	Kind                   int            `json:"kind"`
+	StartTimeUnixNano      string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano        string         `json:"endTimeUnixNano"`
+	Attributes             []otlpKeyValue `json:"attributes"`
+	DroppedAttributesCount uint32         `json:"droppedAttributesCount"`
+	Events                 []interface{}  `json:"events"`
+	DroppedEventsCount     uint32         `json:"droppedEventsCount"`
+	Links                  []interface{}  `json:"links"`
+	DroppedLinksCount      uint32         `json:"droppedLinksCount"`
+	Status                 struct {
+		Message string `json:"message"`
+		Code    int    `json:"code"`
+	} `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue *string      `json:"stringValue"`
+		BoolValue   *bool        `json:"boolValue"`
+		IntValue    *string      `json:"intValue"`
+		DoubleValue *float64     `json:"doubleValue"`
+		ArrayValue  *interface{} `json:"arrayValue"`
+		KvlistValue *interface{} `json:"kvlistValue"`
+		BytesValue  *string      `json:"bytesValue"`
+	} `json:"value"`
+}
+
+func TestOTLPExport(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []otlpTraceRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Bad export request: %s %s %s", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var request otlpTraceRequest
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&request); err != nil {
+			t.Errorf("Payload does not match OTLP JSON encoding: %v", err)
+		}
+		mutex.Lock()
+		requests = append(requests, request)
+		mutex.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer collector.Close()
+	tracer := tracing.NewTracer(tracing.NewOTLPExporter(collector.URL, "products", testServer.logger), 1)
+	testServer.tracer = tracer
+	request, _ := http.NewRequest(http.MethodGet, baseUrl+"/UNKNOWN", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	// Spans are sent on shutdown after the server span ends
+	testServer.active.wait()
+	testServer.tracer = nil
+	if err := tracer.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(requests) != 1 || len(requests[0].ResourceSpans) != 1 || len(requests[0].ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("Bad structure of exported spans: %+v", requests)
+	}
+	resource := requests[0].ResourceSpans[0]
+	if len(resource.Resource.Attributes) != 1 || resource.Resource.Attributes[0].Key != "service.name" ||
+		resource.Resource.Attributes[0].Value.StringValue == nil || *resource.Resource.Attributes[0].Value.StringValue != "products" {
+		t.Errorf("Bad resource: %+v", resource.Resource)
+	}
+	traceID, spanID := regexp.MustCompile("^[0-9a-f]{32}$"), regexp.MustCompile("^[0-9a-f]{16}$")
+	kinds := make(map[int]bool)
+	for _, span := range resource.ScopeSpans[0].Spans {
+		kinds[span.Kind] = true
+		if !traceID.MatchString(span.TraceID) || !spanID.MatchString(span.SpanID) || !spanID.MatchString(span.ParentSpanID) {
+			t.Errorf("Bad ids of span %s: %s %s %s", span.Name, span.TraceID, span.SpanID, span.ParentSpanID)
+		}
+		start, startErr := strconv.ParseInt(span.StartTimeUnixNano, 10, 64)
+		end, endErr := strconv.ParseInt(span.EndTimeUnixNano, 10, 64)
+		if startErr != nil || endErr != nil || start <= 0 || end < start {
+			t.Errorf("Bad times of span %s: %s %s", span.Name, span.StartTimeUnixNano, span.EndTimeUnixNano)
+		}
+		// Span kinds are from 0 (unspecified) to 5 (consumer), status codes from 0 (unset) to 2 (error)
+		if span.Kind < 0 || span.Kind > 5 || span.Status.Code < 0 || span.Status.Code > 2 {
+			t.Errorf("Bad kind or status of span %s: %d %d", span.Name, span.Kind, span.Status.Code)
+		}
+		for _, attribute := range span.Attributes {
+			if attribute.Key == "http.response.status_code" && (attribute.Value.IntValue == nil || *attribute.Value.IntValue != "404") {
+				t.Errorf("Bad status code attribute of span %s: %+v", span.Name, attribute.Value)
+			}
+		}
+	}
+	if !kinds[int(tracing.ServerKind)] || !kinds[int(tracing.ClientKind)] {
+		t.Errorf("Server and database spans are not exported: %+v", resource.ScopeSpans[0].Spans)
+	}
+}
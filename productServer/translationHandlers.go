@@ -0,0 +1,140 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/DB"
+	"XsollaSchoolBE/models"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// getProductTranslations godoc
+// @Summary get translations of name and description of the product with specific SKU
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Success 200 {array} models.Translation
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Router /products/{SKU}/translations [get]
+func (srv *ProductServer) getProductTranslations(ctx *gin.Context) {
+	var translations []*models.Translation
+	err := checkSKUVisible(ctx, ctx.Param("SKU"))
+	if err == nil {
+		translations, err = tenantDB(ctx).GetProductTranslations(ctx.Request.Context(), ctx.Param("SKU"))
+	}
+	if err == nil {
+		ctx.JSON(http.StatusOK, translations)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// setProductTranslation godoc
+// @Summary add or replace translation of name and description of the product with specific SKU
+// @Description Locale is a language tag like "pt-BR", it is case insensitive.
+// @Accept json
+// @Produces json
+// @Param SKU path string true "SKU of the product"
+// @Param locale path string true "Locale of the translation"
+// @Param translation body models.InputTranslation true "translated name and description, empty description falls back to the next locale"
+// @Success 200 {object} models.Translation
+// @Failure 400 {object} string
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product with such SKU does not exist"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/translations/{locale} [put]
+func (srv *ProductServer) setProductTranslation(ctx *gin.Context) {
+	input := models.EmptyInputTranslation()
+	if err := ctx.ShouldBindJSON(input); err != nil {
+		ctx.String(http.StatusBadRequest, "json format error: "+err.Error())
+		return
+	}
+	translation := models.Translation{InputTranslation: *input, Locale: models.NormalizeLocale(ctx.Param("locale"))}
+	if err := tenantDB(ctx).SetProductTranslation(ctx.Request.Context(), ctx.Param("SKU"), translation); err == nil {
+		ctx.JSON(http.StatusOK, translation)
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// deleteProductTranslation godoc
+// @Summary delete translation of the product with specific SKU
+// @Param SKU path string true "SKU of the product"
+// @Param locale path string true "Locale of the translation"
+// @Success 204
+// @Failure 401 {object} string
+// @Failure 403 {object} string
+// @Failure 404 {object} string "product does not exist or has no translation to the locale"
+// @Failure 500 {object} string
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /products/{SKU}/translations/{locale} [delete]
+func (srv *ProductServer) deleteProductTranslation(ctx *gin.Context) {
+	if err := tenantDB(ctx).DeleteProductTranslation(ctx.Request.Context(), ctx.Param("SKU"), ctx.Param("locale")); err == nil {
+		ctx.String(http.StatusNoContent, "")
+	} else {
+		ctx.String(getHttpCodeFromError(err), err.Error())
+	}
+}
+
+// localizeProducts translates names and descriptions of the products to the locales requested by client
+func (srv *ProductServer) localizeProducts(ctx *gin.Context, products []*models.Product) error {
+	ctx.Writer.Header().Add("Vary", "Accept-Language")
+	locales, err := getLocalesFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+	return tenantDB(ctx).LocalizeProducts(ctx.Request.Context(), products, locales)
+}
+
+// getLocalesFromRequest returns the chain of normalized locales requested with locale parameter
+// or, if it is not specified, with Accept-Language header
+func getLocalesFromRequest(ctx *gin.Context) ([]string, error) {
+	if locale, ok := ctx.GetQuery("locale"); ok {
+		locale = models.NormalizeLocale(locale)
+		if !models.ValidLocale(locale) {
+			return nil, fmt.Errorf("%w: locale parameter must be a language tag like pt-BR", DB.InvalidLocaleError)
+		}
+		return models.LocaleFallbacks([]string{locale}), nil
+	}
+	return models.LocaleFallbacks(parseAcceptLanguage(ctx.GetHeader("Accept-Language"))), nil
+}
+
+// parseAcceptLanguage returns valid locales of Accept-Language header ordered by their weights,
+// wildcard and locales with zero weight are skipped
+func parseAcceptLanguage(header string) []string {
+	type weightedLocale struct {
+		locale string
+		weight float64
+	}
+	weighted := make([]weightedLocale, 0)
+	for _, item := range strings.Split(header, ",") {
+		parts := strings.Split(item, ";")
+		locale := models.NormalizeLocale(parts[0])
+		weight := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				var err error
+				if weight, err = strconv.ParseFloat(param[2:], 64); err != nil {
+					weight = 0
+				}
+			}
+		}
+		if weight > 0 && models.ValidLocale(locale) {
+			weighted = append(weighted, weightedLocale{locale, weight})
+		}
+	}
+	sort.SliceStable(weighted, func(i, j int) bool { return weighted[i].weight > weighted[j].weight })
+	locales := make([]string, len(weighted))
+	for i := range weighted {
+		locales[i] = weighted[i].locale
+	}
+	return locales
+}
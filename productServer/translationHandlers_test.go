@@ -0,0 +1,106 @@
+package productServer
+
+import (
+	"XsollaSchoolBE/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestTranslations(t *testing.T) {
+	product := models.InputProduct{SKU: "LOCALIZED", Name: "Witcher", Type: "Game", Cost: 30, Description: "Monster hunting"}
+	if code, body := postProduct(product); code != http.StatusCreated {
+		t.Fatalf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	defer deleteProduct(product.SKU)
+	translations := map[string]models.InputTranslation{
+		"pt":    {Name: "Bruxo", Description: "Caça de monstros"},
+		"pt_BR": {Name: "Bruxo (Brasil)"},
+		"de":    {Name: "Hexer", Description: "Monsterjagd"},
+	}
+	for locale, translation := range translations {
+		if code, body := sendRequest(http.MethodPut, baseUrl+"/LOCALIZED/translations/"+locale, translation); code != http.StatusOK {
+			t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+		}
+	}
+
+	code, body := sendRequest(http.MethodGet, baseUrl+"/LOCALIZED/translations", nil)
+	var stored []models.Translation
+	if code != http.StatusOK {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	} else if err := json.Unmarshal([]byte(body), &stored); err != nil {
+		t.Error(err)
+	} else if len(stored) != 3 || stored[2].Locale != "pt-br" {
+		t.Errorf("Wrong product translations: %v", stored)
+	}
+
+	for _, testCase := range []struct {
+		url, acceptLanguage, name, description string
+	}{
+		{baseUrl + "/LOCALIZED?locale=pt-BR", "", "Bruxo (Brasil)", "Caça de monstros"},
+		{baseUrl + "/LOCALIZED?locale=pt-PT", "", "Bruxo", "Caça de monstros"},
+		{baseUrl + "/LOCALIZED?locale=fr", "de", product.Name, product.Description},
+		{baseUrl + "/LOCALIZED", "fr-CH, de;q=0.5, pt;q=0.9", "Bruxo", "Caça de monstros"},
+		{baseUrl + "?sku=LOCALIZED", "de-AT", "Hexer", "Monsterjagd"},
+		{baseUrl + "/search?q=witcher", "de", "Hexer", "Monsterjagd"},
+		{baseUrl + "?type=Game", "", product.Name, product.Description},
+	} {
+		products, err := getLocalizedProducts(testCase.url, testCase.acceptLanguage)
+		if err != nil {
+			t.Error(err)
+		} else if len(products) != 1 || products[0].Name != testCase.name || products[0].Description != testCase.description {
+			t.Errorf("Wrong localized products for %s with Accept-Language %q: %v", testCase.url, testCase.acceptLanguage, products)
+		}
+	}
+
+	// Suggestions are matched by translated names
+	for query, name := range map[string]string{
+		"?prefix=bru&locale=pt-BR": "Bruxo (Brasil)",
+		"?prefix=hex&locale=de-AT": "Hexer",
+		"?prefix=witch&locale=de":  "",
+		"?prefix=witch":            product.Name,
+	} {
+		suggestions, err := getSuggestionsFromURL(baseUrl + "/suggestions" + query)
+		if err != nil {
+			t.Error(err)
+		} else if name == "" && len(suggestions) != 0 || name != "" && (len(suggestions) != 1 || suggestions[0].Name != name) {
+			t.Errorf("Wrong localized suggestions for %s: %v", query, suggestions)
+		}
+	}
+
+	for _, url := range []string{baseUrl + "/LOCALIZED?locale=!", baseUrl + "/suggestions?prefix=bru&locale=!"} {
+		if code, _ := sendRequest(http.MethodGet, url, nil); code != http.StatusBadRequest {
+			t.Errorf("not 400 code for invalid locale of %s: %d", url, code)
+		}
+	}
+	if code, _ := sendRequest(http.MethodPut, baseUrl+"/LOCALIZED/translations/fr", models.InputTranslation{}); code != http.StatusBadRequest {
+		t.Errorf("not 400 code for translation without name: %d", code)
+	}
+	if code, _ := sendRequest(http.MethodPut, baseUrl+"/UNKNOWN/translations/fr", models.InputTranslation{Name: "Sorceleur"}); code != http.StatusNotFound {
+		t.Errorf("not 404 code for translation of not existing product: %d", code)
+	}
+	if code, body := sendRequest(http.MethodDelete, baseUrl+"/LOCALIZED/translations/PT-br", nil); code != http.StatusNoContent {
+		t.Errorf("\nBad status code: %d\nResponse body: %s\n", code, body)
+	}
+	if code, _ := sendRequest(http.MethodDelete, baseUrl+"/LOCALIZED/translations/pt-br", nil); code != http.StatusNotFound {
+		t.Errorf("not 404 code for deleting not existing translation: %d", code)
+	}
+}
+
+func getLocalizedProducts(url string, acceptLanguage string) ([]*models.Product, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if acceptLanguage != "" {
+		req.Header.Set("Accept-Language", acceptLanguage)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var products []*models.Product
+	err = json.NewDecoder(resp.Body).Decode(&products)
+	return products, err
+}
@@ -0,0 +1,148 @@
+package rateLimit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var InvalidLimitError = errors.New("invalid rate limit")
+
+// Limit allows Requests requests per Period, unused requests are accumulated up to Requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Unlimited reports if the limit does not restrict requests
+func (limit Limit) Unlimited() bool {
+	return limit.Requests <= 0 || limit.Period <= 0
+}
+
+func (limit Limit) String() string {
+	return strconv.Itoa(limit.Requests) + "/" + limit.Period.String()
+}
+
+// ParseLimit parses limits like "100/1m", the period must be accepted by time.ParseDuration,
+// the number of the period units is optional: "10/s" equals "10/1s"
+func ParseLimit(value string) (Limit, error) {
+	parts := strings.Split(strings.TrimSpace(value), "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("%w: %q is not in requests/period format", InvalidLimitError, value)
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("%w: number of requests in %q must be a positive integer", InvalidLimitError, value)
+	}
+	period := parts[1]
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	duration, err := time.ParseDuration(period)
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("%w: period of %q must be a positive duration", InvalidLimitError, value)
+	}
+	return Limit{Requests: requests, Period: duration}, nil
+}
+
+// Result is a state of a bucket after a request
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining is the number of requests, which are allowed right now
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed, it is 0 if Remaining is not 0
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets of clients, it can be shared by several instances of the server
+type Store interface {
+	// Take removes a token from the bucket with the key if there is one,
+	// a new bucket is full, the bucket is refilled according to the limit
+	Take(key string, limit Limit) (Result, error)
+}
+
+// Config defines limits of requests, each of them is counted separately for each client
+type Config struct {
+	// Default limits all requests of a client, requests are not limited if it is zero
+	Default Limit
+	// Routes limit requests to specific routes in addition to Default, they are keyed by method and path
+	// of the route with parameters, for example "POST /api/v1/products" or "GET /api/v1/products/:SKU"
+	Routes map[string]Limit
+	// Clients replace Default for specific clients, for example "key:1" for API key with id 1,
+	// "sub:default/alice" for a token of alice from default tenant or "ip:10.0.0.1" for anonymous requests
+	Clients map[string]Limit
+}
+
+// Enabled reports if the config limits any requests
+func (config Config) Enabled() bool {
+	return !config.Default.Unlimited() || len(config.Routes) > 0 || len(config.Clients) > 0
+}
+
+// Limiter checks limits of the config with buckets in the store
+type Limiter struct {
+	config Config
+	store  Store
+}
+
+func NewLimiter(config Config, store Store) *Limiter {
+	return &Limiter{config: config, store: store}
+}
+
+// Allow takes a token from each bucket of the client, that applies to the route,
+// it returns the result of the most restrictive one. The second result is false if no limit applies.
+func (limiter *Limiter) Allow(client string, route string) (Result, bool, error) {
+	var result Result
+	applied := false
+	take := func(key string, limit Limit) error {
+		if limit.Unlimited() {
+			return nil
+		}
+		res, err := limiter.store.Take(key, limit)
+		if err != nil {
+			return err
+		}
+		if !applied || !res.Allowed && result.Allowed || res.Allowed == result.Allowed && res.Remaining < result.Remaining {
+			result = res
+		}
+		applied = true
+		return nil
+	}
+	limit, ok := limiter.config.Clients[client]
+	if !ok {
+		limit = limiter.config.Default
+	}
+	if err := take(client, limit); err != nil {
+		return Result{}, false, err
+	}
+	if limit, ok := limiter.config.Routes[route]; ok {
+		if err := take(client+" "+route, limit); err != nil {
+			return Result{}, false, err
+		}
+	}
+	return result, applied, nil
+}
+
+// ParseLimits parses comma separated list of limits with keys like "GET /api/v1/products=100/1m,key:1=10/s"
+func ParseLimits(value string) (map[string]Limit, error) {
+	limits := make(map[string]Limit)
+	for _, item := range strings.Split(value, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		separator := strings.LastIndex(item, "=")
+		if separator < 0 {
+			return nil, fmt.Errorf("%w: %q is not in key=requests/period format", InvalidLimitError, item)
+		}
+		limit, err := ParseLimit(item[separator+1:])
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.TrimSpace(item[:separator])] = limit
+	}
+	return limits, nil
+}
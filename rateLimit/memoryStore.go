@@ -0,0 +1,72 @@
+package rateLimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full buckets are removed from MemoryStore
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+// refill adds tokens accumulated since the last update
+func (b *bucket) refill(now time.Time) {
+	rate := float64(b.limit.Requests) / float64(b.limit.Period)
+	b.tokens = math.Min(float64(b.limit.Requests), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+}
+
+// MemoryStore keeps buckets in memory of the process, so limits are not shared between instances of the server
+type MemoryStore struct {
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (store *MemoryStore) Take(key string, limit Limit) (Result, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	now := time.Now()
+	store.sweep(now)
+	b, ok := store.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), updated: now, limit: limit}
+		store.buckets[key] = b
+	}
+	b.refill(now)
+	result := Result{Limit: limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	}
+	perToken := float64(limit.Period) / float64(limit.Requests)
+	result.Remaining = int(b.tokens)
+	result.Reset = time.Duration(math.Ceil((float64(limit.Requests) - b.tokens) * perToken))
+	if result.Remaining == 0 {
+		result.RetryAfter = time.Duration(math.Ceil((1 - b.tokens) * perToken))
+	}
+	return result, nil
+}
+
+// sweep removes buckets, which are full by now, they are indistinguishable from new ones
+func (store *MemoryStore) sweep(now time.Time) {
+	if now.Sub(store.lastSweep) < sweepInterval {
+		return
+	}
+	store.lastSweep = now
+	for key, b := range store.buckets {
+		if b.refill(now); b.tokens >= float64(b.limit.Requests) {
+			delete(store.buckets, key)
+		}
+	}
+}
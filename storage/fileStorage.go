@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// fileStorage keeps blobs in files of the local directory
+type fileStorage struct {
+	dir     string
+	baseURL string
+}
+
+// InitFileStorage creates the directory if it does not exist,
+// URLs of blobs are the keys prefixed with baseURL
+func InitFileStorage(dir string, baseURL string) (*fileStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileStorage{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/")}, nil
+}
+
+// Save writes the data to a temporary file and renames it, so incomplete blobs are never opened
+func (storage *fileStorage) Save(key string, data io.Reader) error {
+	filename, err := storage.filename(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	tmpFile, err := ioutil.TempFile(filepath.Dir(filename), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmpFile, data)
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpFile.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(tmpFile.Name())
+	}
+	return err
+}
+
+func (storage *fileStorage) Open(key string) (io.ReadCloser, error) {
+	filename, err := storage.filename(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return nil, BlobNotFoundError
+	}
+	return file, err
+}
+
+func (storage *fileStorage) Delete(key string) error {
+	filename, err := storage.filename(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filename)
+	if os.IsNotExist(err) {
+		return BlobNotFoundError
+	}
+	return err
+}
+
+func (storage *fileStorage) URL(key string) string {
+	return storage.baseURL + "/" + key
+}
+
+// filename returns the path of the blob file, keys leading out of the directory are not found
+func (storage *fileStorage) filename(key string) (string, error) {
+	cleanKey := path.Clean("/" + key)[1:]
+	if cleanKey == "" || cleanKey != key || strings.HasPrefix(path.Base(cleanKey), ".") {
+		return "", BlobNotFoundError
+	}
+	return filepath.Join(storage.dir, filepath.FromSlash(cleanKey)), nil
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+var BlobNotFoundError = errors.New("Blob not found")
+
+// BlobStorage stores binary data, like product images, by keys.
+// Keys are slash separated paths like "images/1f2e3d.png".
+type BlobStorage interface {
+	Save(key string, data io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	// URL returns the address by which the blob is available for clients
+	URL(key string) string
+}
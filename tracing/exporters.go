@@ -0,0 +1,290 @@
+package tracing
+
+import (
+	"XsollaSchoolBE/logging"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exporter sends finished spans to a tracing backend, Export must not block handling of requests
+type Exporter interface {
+	Export(span SpanData)
+	// Shutdown sends buffered spans and releases resources of the exporter
+	Shutdown(ctx context.Context) error
+}
+
+// WriterExporter writes spans as JSON lines, it is used for debugging with os.Stdout
+type WriterExporter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+func (exporter *WriterExporter) Export(span SpanData) {
+	attributes := make(map[string]interface{}, len(span.Attributes))
+	for _, attribute := range span.Attributes {
+		attributes[attribute.Key] = attribute.Value
+	}
+	line := struct {
+		TraceID       string                 `json:"traceId"`
+		SpanID        string                 `json:"spanId"`
+		Parent        string                 `json:"parentSpanId,omitempty"`
+		Name          string                 `json:"name"`
+		Kind          string                 `json:"kind"`
+		Start         time.Time              `json:"start"`
+		DurationMs    float64                `json:"durationMs"`
+		Attributes    map[string]interface{} `json:"attributes,omitempty"`
+		Error         bool                   `json:"error,omitempty"`
+		StatusMessage string                 `json:"statusMessage,omitempty"`
+	}{
+		TraceID:       span.SpanContext.TraceID.String(),
+		SpanID:        span.SpanContext.SpanID.String(),
+		Name:          span.Name,
+		Kind:          span.Kind.String(),
+		Start:         span.Start.UTC(),
+		DurationMs:    float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+		Attributes:    attributes,
+		Error:         span.Status == ErrorStatus,
+		StatusMessage: span.StatusMessage,
+	}
+	if span.Parent.IsValid() {
+		line.Parent = span.Parent.String()
+	}
+	data, err := json.Marshal(line)
+	if err != nil {
+		return
+	}
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.w.Write(append(data, '\n'))
+}
+
+func (exporter *WriterExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+// InMemoryExporter keeps exported spans, it is used by tests
+type InMemoryExporter struct {
+	mutex sync.Mutex
+	spans []SpanData
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (exporter *InMemoryExporter) Export(span SpanData) {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = append(exporter.spans, span)
+}
+
+// Spans returns exported spans in order of their ends
+func (exporter *InMemoryExporter) Spans() []SpanData {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	return append([]SpanData(nil), exporter.spans...)
+}
+
+func (exporter *InMemoryExporter) Reset() {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+	exporter.spans = nil
+}
+
+func (exporter *InMemoryExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+const (
+	// otlpQueueSize limits spans waiting for export, spans are dropped if the collector is slower than the service
+	otlpQueueSize = 2048
+	otlpBatchSize = 512
+	// otlpExportInterval is the longest delay of exporting a finished span
+	otlpExportInterval = 5 * time.Second
+	otlpTimeout        = 10 * time.Second
+)
+
+// OTLPExporter sends batches of spans to an OpenTelemetry collector with OTLP/HTTP protocol in JSON encoding
+type OTLPExporter struct {
+	url     string
+	service string
+	client  *http.Client
+	logger  *logging.Logger
+	queue   chan SpanData
+	stop    chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewOTLPExporter starts sending spans of the service to endpoint, which is a base URL of the collector
+// like http://localhost:4318. Failures of sending are written to the logger.
+func NewOTLPExporter(endpoint string, service string, logger *logging.Logger) *OTLPExporter {
+	exporter := &OTLPExporter{
+		url:     strings.TrimSuffix(endpoint, "/") + "/v1/traces",
+		service: service,
+		client:  &http.Client{Timeout: otlpTimeout},
+		logger:  logger,
+		queue:   make(chan SpanData, otlpQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go exporter.run()
+	return exporter
+}
+
+func (exporter *OTLPExporter) Export(span SpanData) {
+	select {
+	case exporter.queue <- span:
+	case <-exporter.stop:
+	default:
+		exporter.logger.Warn("span is dropped, OTLP export queue is full", "trace_id", span.SpanContext.TraceID)
+	}
+}
+
+// Shutdown sends queued spans, it stops waiting for the collector when ctx is done
+func (exporter *OTLPExporter) Shutdown(ctx context.Context) error {
+	exporter.once.Do(func() { close(exporter.stop) })
+	select {
+	case <-exporter.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (exporter *OTLPExporter) run() {
+	defer close(exporter.done)
+	ticker := time.NewTicker(otlpExportInterval)
+	defer ticker.Stop()
+	batch := make([]SpanData, 0, otlpBatchSize)
+	send := func() {
+		if len(batch) > 0 {
+			if err := exporter.send(batch); err != nil {
+				exporter.logger.Error("spans are not exported", "spans", len(batch), "error", err)
+			}
+			batch = batch[:0]
+		}
+	}
+	for {
+		select {
+		case span := <-exporter.queue:
+			if batch = append(batch, span); len(batch) == otlpBatchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case <-exporter.stop:
+			for {
+				select {
+				case span := <-exporter.queue:
+					if batch = append(batch, span); len(batch) == otlpBatchSize {
+						send()
+					}
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (exporter *OTLPExporter) send(batch []SpanData) error {
+	body, err := json.Marshal(exporter.request(batch))
+	if err != nil {
+		return err
+	}
+	resp, err := exporter.client.Post(exporter.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpValue is AnyValue of OTLP JSON encoding, 64-bit integers are encoded as strings
+type otlpValue map[string]interface{}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	TraceState        string          `json:"traceState,omitempty"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            struct {
+		Code    StatusCode `json:"code,omitempty"`
+		Message string     `json:"message,omitempty"`
+	} `json:"status"`
+}
+
+// request builds ExportTraceServiceRequest with one resource of the service
+func (exporter *OTLPExporter) request(batch []SpanData) interface{} {
+	spans := make([]otlpSpan, len(batch))
+	for i, data := range batch {
+		span := &spans[i]
+		span.TraceID, span.SpanID = data.SpanContext.TraceID.String(), data.SpanContext.SpanID.String()
+		span.TraceState = data.SpanContext.TraceState
+		if data.Parent.IsValid() {
+			span.ParentSpanID = data.Parent.String()
+		}
+		span.Name, span.Kind = data.Name, data.Kind
+		span.StartTimeUnixNano = strconv.FormatInt(data.Start.UnixNano(), 10)
+		span.EndTimeUnixNano = strconv.FormatInt(data.End.UnixNano(), 10)
+		for _, attribute := range data.Attributes {
+			span.Attributes = append(span.Attributes, otlpAttribute{Key: attribute.Key, Value: encodeOTLPValue(attribute.Value)})
+		}
+		span.Status.Code, span.Status.Message = data.Status, data.StatusMessage
+	}
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: encodeOTLPValue(exporter.service)}},
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "XsollaSchoolBE/tracing"},
+				"spans": spans,
+			}},
+		}},
+	}
+}
+
+func encodeOTLPValue(value interface{}) otlpValue {
+	switch v := value.(type) {
+	case string:
+		return otlpValue{"stringValue": v}
+	case bool:
+		return otlpValue{"boolValue": v}
+	case int:
+		return otlpValue{"intValue": strconv.FormatInt(int64(v), 10)}
+	case int64:
+		return otlpValue{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return otlpValue{"doubleValue": v}
+	default:
+		return otlpValue{"stringValue": fmt.Sprint(v)}
+	}
+}
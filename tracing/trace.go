@@ -0,0 +1,287 @@
+// Package tracing implements the part of OpenTelemetry tracing used by the service: W3C Trace Context propagation,
+// sampling and export of spans with OTLP/HTTP in JSON encoding. The OpenTelemetry Go SDK is not used, because
+// its current releases require a much newer Go than the go 1.15 of the module, and the old ones are not maintained.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+type SpanID [8]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports if the id is not all zeros
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports if the id is not all zeros
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies a span within a trace, it is propagated to other services with traceparent header
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	// Sampled spans are exported, children of not sampled spans are not sampled too
+	Sampled bool
+	// TraceState is tracestate header of the vendor specific trace information, it is propagated unchanged
+	TraceState string
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a value of W3C traceparent header
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a value of W3C traceparent header, fields added by future versions are ignored
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	fields := strings.Split(strings.TrimSpace(value), "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" || fields[0] == "00" && len(fields) != 4 {
+		return sc, false
+	}
+	var version, flags [1]byte
+	if !decodeHex(version[:], fields[0]) || !decodeHex(sc.TraceID[:], fields[1]) ||
+		!decodeHex(sc.SpanID[:], fields[2]) || !decodeHex(flags[:], fields[3]) {
+		return sc, false
+	}
+	sc.Sampled = flags[0]&1 == 1
+	return sc, sc.IsValid()
+}
+
+// decodeHex decodes lowercase hex of exactly the length of dst
+func decodeHex(dst []byte, s string) bool {
+	if len(s) != 2*len(dst) || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err == nil
+}
+
+// Extract returns a copy of the context carrying the remote span context of traceparent and tracestate headers,
+// the context is returned unchanged if traceparent is missing or invalid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	sc.TraceState = strings.Join(header.Values("tracestate"), ",")
+	return context.WithValue(ctx, remoteContextKey{}, sc)
+}
+
+// SpanKind is a role of the span in the trace, values are the same as in OTLP
+type SpanKind int
+
+const (
+	InternalKind SpanKind = iota + 1
+	ServerKind
+	ClientKind
+)
+
+var kindNames = map[SpanKind]string{InternalKind: "internal", ServerKind: "server", ClientKind: "client"}
+
+func (kind SpanKind) String() string {
+	return kindNames[kind]
+}
+
+// StatusCode is a status of the operation of the span, values are the same as in OTLP
+type StatusCode int
+
+const (
+	UnsetStatus StatusCode = iota
+	OkStatus
+	ErrorStatus
+)
+
+// Attribute is a key and a value of string, bool, integer or floating-point type, other values are exported as strings
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// SpanData is a finished span passed to exporters
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    []Attribute
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Attribute returns the value of the attribute or nil if the span has no such attribute
+func (data SpanData) Attribute(key string) interface{} {
+	for _, attribute := range data.Attributes {
+		if attribute.Key == key {
+			return attribute.Value
+		}
+	}
+	return nil
+}
+
+// Span is an operation in a trace. Methods of nil Span do nothing.
+type Span struct {
+	tracer *Tracer
+	mutex  sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (span *Span) SpanContext() SpanContext {
+	if span == nil {
+		return SpanContext{}
+	}
+	return span.data.SpanContext
+}
+
+// Tracer returns the tracer, which started the span
+func (span *Span) Tracer() *Tracer {
+	if span == nil {
+		return nil
+	}
+	return span.tracer
+}
+
+// SetAttributes adds attributes to the span, keysAndValues are pairs of string keys and values
+func (span *Span) SetAttributes(keysAndValues ...interface{}) {
+	if span == nil || !span.data.SpanContext.Sampled {
+		return
+	}
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if key, ok := keysAndValues[i].(string); ok {
+			span.data.Attributes = append(span.data.Attributes, Attribute{Key: key, Value: keysAndValues[i+1]})
+		}
+	}
+}
+
+func (span *Span) SetStatus(code StatusCode, message string) {
+	if span == nil {
+		return
+	}
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+	span.data.Status, span.data.StatusMessage = code, message
+}
+
+// SetError sets error status with the message of the error if it is not nil
+func (span *Span) SetError(err error) {
+	if err != nil {
+		span.SetStatus(ErrorStatus, err.Error())
+	}
+}
+
+// End finishes the span and passes it to the exporter if it is sampled, following calls do nothing
+func (span *Span) End() {
+	if span == nil {
+		return
+	}
+	span.mutex.Lock()
+	if span.ended {
+		span.mutex.Unlock()
+		return
+	}
+	span.ended = true
+	span.data.End = time.Now()
+	data := span.data
+	span.mutex.Unlock()
+	if data.SpanContext.Sampled {
+		span.tracer.exporter.Export(data)
+	}
+}
+
+// Tracer starts spans and exports sampled ones. Methods of nil Tracer return nil spans.
+type Tracer struct {
+	exporter Exporter
+	// sampleRatio is a share of sampled traces started by this service
+	sampleRatio float64
+}
+
+// NewTracer returns a tracer sampling the ratio of traces without sampled remote parents, 1 samples all traces
+func NewTracer(exporter Exporter, sampleRatio float64) *Tracer {
+	return &Tracer{exporter: exporter, sampleRatio: sampleRatio}
+}
+
+// Start starts a span, which is a child of the span of ctx or of the remote span of ctx, otherwise it starts a trace.
+// The returned context carries the span.
+func (tracer *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	return tracer.StartAt(ctx, name, kind, time.Now())
+}
+
+// StartAt starts a span like Start with the start time of an operation, which is already running
+func (tracer *Tracer) StartAt(ctx context.Context, name string, kind SpanKind, start time.Time) (context.Context, *Span) {
+	if tracer == nil {
+		return ctx, nil
+	}
+	var ids [24]byte
+	rand.Read(ids[:])
+	span := &Span{tracer: tracer, data: SpanData{Name: name, Kind: kind, Start: start}}
+	parent, ok := SpanFromContext(ctx).SpanContext(), true
+	if !parent.IsValid() {
+		parent, ok = ctx.Value(remoteContextKey{}).(SpanContext)
+	}
+	sc := &span.data.SpanContext
+	copy(sc.SpanID[:], ids[16:])
+	if ok && parent.IsValid() {
+		sc.TraceID, sc.Sampled, sc.TraceState = parent.TraceID, parent.Sampled, parent.TraceState
+		span.data.Parent = parent.SpanID
+	} else {
+		copy(sc.TraceID[:], ids[:16])
+		// The lower bits of trace ids are random, so instances sample the same traces
+		sc.Sampled = float64(binary.BigEndian.Uint64(ids[8:16])>>11)/(1<<53) < tracer.sampleRatio
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Shutdown exports finished spans and stops the exporter
+func (tracer *Tracer) Shutdown(ctx context.Context) error {
+	if tracer == nil {
+		return nil
+	}
+	return tracer.exporter.Shutdown(ctx)
+}
+
+type spanContextKey struct{}
+
+type remoteContextKey struct{}
+
+// ContextWithSpan returns a copy of the context carrying the span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span of the context or nil if it has no span
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}